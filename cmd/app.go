@@ -155,6 +155,34 @@ var appValuesCmd = addCommand(appCmd, &cobra.Command{
 }, func(cmd *cobra.Command) {
 })
 
+var _ = addCommand(appValuesCmd, &cobra.Command{
+	Use:   "explain {app} {path}",
+	Args:  cobra.ExactArgs(2),
+	Short: "Shows every layer which set or overrode a value for the app, in the order they were applied.",
+	Long: `Shows where the value at a path (like "image.tag") came from when resolving the values the app
+would use to deploy to the current target. Each row is a value set (or file, dynamic value,
+command line parameter, or value mapping) which set the value, with the roles and filters which
+made it match. The last row before the final value is the one which won.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		b := MustGetBosun()
+
+		app := mustGetApp(b, args[:1])
+
+		ctx := b.NewContext()
+		appDeploy, err := getAppDeploy(b, app)
+		if err != nil {
+			return err
+		}
+
+		explanation, err := appDeploy.ExplainValue(ctx, args[1])
+		if err != nil {
+			return err
+		}
+
+		return renderOutput(explanation)
+	},
+})
+
 var appBumpCmd = addCommand(appCmd, &cobra.Command{
 	Use:   "bump {name} [major|minor|patch|major.minor.patch]",
 	Args:  cobra.RangeArgs(1, 2),
@@ -180,7 +208,7 @@ var appBumpCmd = addCommand(appCmd, &cobra.Command{
 			bump = args[1]
 		} else {
 			core.Log.Info("Computing version bump from commits...")
-			changes, changeErr := g.ChangeLog(app.Branching.Develop, "HEAD", nil, git.GitChangeLogOptions{})
+			changes, changeErr := g.ChangeLog(app.Branching.Develop, "HEAD", nil, git.GitChangeLogOptions{Rules: b.GetCommitRules(app.Branching)})
 			if changeErr != nil {
 				return errors.Wrap(changeErr, "computing bump")
 			}
@@ -769,11 +797,18 @@ func pullApps(ctx bosun.BosunContext, apps bosun.AppList, rebase bool) error {
 }
 
 var appScriptCmd = addCommand(appCmd, &cobra.Command{
-	Use:          "script [app] {name}",
-	Args:         cobra.RangeArgs(1, 2),
-	Short:        "Run a scripted sequence of commands.",
-	Long:         `If app is not provided, the current directory is used.`,
-	SilenceUsage: true,
+	Use:   "script [app] {name}",
+	Args:  cobra.RangeArgs(1, 2),
+	Short: "Run a scripted sequence of commands.",
+	Long: `If app is not provided, the current directory is used.
+
+If the script has params, they can be provided as flags after the script name,
+like 'bosun app script my-app my-script --version 1.2.3'. Use 'bosun app scripts' to see the params for each script.
+
+The progress of each run is saved, so if a step fails you can fix the problem and
+continue from that step using --resume.`,
+	SilenceUsage:       true,
+	FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		viper.BindPFlags(cmd.Flags())
 
@@ -810,20 +845,26 @@ var appScriptCmd = addCommand(appCmd, &cobra.Command{
 			return errors.Errorf("no script named %q in app %q\navailable scripts:\n-%s", scriptName, app.Name, strings.Join(scriptNames, "\n-"))
 		}
 
+		opts, err := getScriptExecuteOptions(b, script)
+		if err != nil {
+			return err
+		}
+
 		ctx := b.NewContext()
 		values, err := getResolvedValuesFromApp(b, app)
 		if err != nil {
 			return err
 		}
+		if err = applyScriptParams(cmd, script, values); err != nil {
+			return err
+		}
 		ctx = ctx.WithPersistableValues(values).(bosun.BosunContext)
 
-		err = script.Execute(ctx, scriptStepsSlice...)
+		err = script.ExecuteWithOptions(ctx, opts)
 
 		return err
 	},
-}, func(cmd *cobra.Command) {
-	cmd.Flags().IntSliceVar(&scriptStepsSlice, ArgScriptSteps, []int{}, "Steps to run (defaults to all steps)")
-})
+}, withScriptExecuteFlags)
 
 var appScriptsCmd = addCommand(appCmd, &cobra.Command{
 	Use:          "scripts [app]",
@@ -840,6 +881,7 @@ var appScriptsCmd = addCommand(appCmd, &cobra.Command{
 		for _, script := range app.Scripts {
 			color.New(color.Bold).Println(script.Name)
 			color.White("%s\n", script.Description)
+			printScriptParams(script)
 		}
 
 		return nil
@@ -1019,6 +1061,7 @@ var appChangeLog = addCommand(
 			changeLogOptions := git.GitChangeLogOptions{
 				Description: detailsFlag,
 				UnknownType: detailsFlag,
+				Rules:       b.GetCommitRules(app.Branching),
 			}
 
 			logs, err := g.ChangeLog(from, to, svc, changeLogOptions)
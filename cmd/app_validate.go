@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"github.com/fatih/color"
+	"github.com/naveego/bosun/pkg/bosun"
+	"github.com/naveego/bosun/pkg/environment"
+	"github.com/naveego/bosun/pkg/util/stringsn"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var _ = addCommand(appCmd, &cobra.Command{
+	Use:          "validate [name...]",
+	Short:        "Validates the resolved values of apps against their values schema.",
+	SilenceUsage: true,
+	Long: `Resolves the values each app would be deployed with in each environment and checks them
+against the app's values schema. The schema is the JSON Schema file at the app's valuesSchema path,
+or the values.schema.json file in the app's chart if valuesSchema is not set. Apps without a schema
+are skipped. Each error reports the environment and where the invalid value was set.
+
+Values are resolved for the default stack of the default cluster of each environment, so the clusters
+of every environment being validated must be reachable. Use --environments to validate fewer environments.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		b := MustGetBosun()
+		f := getFilterParams(b, args)
+
+		appDeploys, err := f.GetAppDeploys()
+		if err != nil {
+			return err
+		}
+
+		envConfigs, err := b.GetEnvironments()
+		if err != nil {
+			return err
+		}
+
+		envNames := viper.GetStringSlice(argAppValidateEnvironments)
+		var envs []*environment.Config
+		for _, envConfig := range envConfigs {
+			if len(envNames) == 0 || stringsn.Contains(envNames, envConfig.Name) {
+				envs = append(envs, envConfig)
+			}
+		}
+		if len(envs) == 0 {
+			return errors.Errorf("no environments found matching %v", envNames)
+		}
+
+		var results []appValidationError
+		for _, envConfig := range envs {
+			env, buildErr := b.BuildEnvironment(envConfig)
+			if buildErr != nil {
+				results = append(results, appValidationError{
+					Environment: envConfig.Name,
+					Error:       errors.Wrap(buildErr, "build environment").Error(),
+				})
+				continue
+			}
+
+			for _, appDeploy := range appDeploys {
+				ctx := b.NewContext().WithEnv(env).(bosun.BosunContext).WithAppDeploy(appDeploy)
+
+				schemaErrs, validateErr := appDeploy.ValidateValues(ctx)
+				if validateErr != nil {
+					results = append(results, appValidationError{
+						Environment: env.Name,
+						App:         appDeploy.Name,
+						Error:       validateErr.Error(),
+					})
+					continue
+				}
+
+				for _, schemaErr := range schemaErrs {
+					results = append(results, appValidationError{
+						Environment: env.Name,
+						App:         appDeploy.Name,
+						Path:        schemaErr.Path,
+						Error:       schemaErr.Message,
+						Source:      schemaErr.Source,
+					})
+				}
+			}
+		}
+
+		if len(results) == 0 {
+			color.Green("Values for %d apps are valid in %d environments.", len(appDeploys), len(envs))
+			return nil
+		}
+
+		err = renderOutput(results, "environment", "app", "path", "error", "source")
+		if err != nil {
+			return err
+		}
+
+		return errors.Errorf("found %d invalid values", len(results))
+	},
+}, func(cmd *cobra.Command) {
+	cmd.Flags().StringSlice(argAppValidateEnvironments, nil, "Environments to validate the values in. Defaults to all environments.")
+})
+
+const (
+	argAppValidateEnvironments = "environments"
+)
+
+type appValidationError struct {
+	Environment string `yaml:"environment" json:"environment"`
+	App         string `yaml:"app" json:"app"`
+	Path        string `yaml:"path" json:"path"`
+	Error       string `yaml:"error" json:"error"`
+	Source      string `yaml:"source" json:"source"`
+}
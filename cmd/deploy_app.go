@@ -48,6 +48,7 @@ func deployAppFlags(cmd *cobra.Command) {
 	cmd.Flags().String(argDeployAppTag, "", "Tag to use when deploying the app or apps.")
 	cmd.Flags().Bool(argDeployPlanIgnoreDeps, true, "Don't validate dependencies.")
 	cmd.Flags().Bool(argDeployPlanAutoDeps, false, "Automatically include dependencies.")
+	cmd.Flags().Bool(argDeployPlanSkipCompatibilityCheck, false, "Don't check that apps are compatible with the versions of their dependencies.")
 	cmd.Flags().Bool(argAppDeployValuesOnly, false, "Just dump the values which would be used to deploy, then exit.")
 	cmd.Flags().Bool(argAppDeployRenderOnly, false, "Just dump the rendered chart which would be used to deploy, then exit.")
 	cmd.Flags().Bool(ArgAppLatest, false, "Force bosun to pull the latest of the app and deploy that.")
@@ -131,10 +132,11 @@ func getAppDeploy(b *bosun.Bosun, app *bosun.App) (*bosun.AppDeploy, error){
 // deployApps deploys the provided app names from the specified platform with the provided value sets
 func deployApps(b *bosun.Bosun, p *bosun.Platform, appNames []string, valueSets values.ValueSets, forceAppNames []string) error {
 	var req = bosun.CreateDeploymentPlanRequest{
-		Apps:                  appNames,
-		ProviderPriority:      viper.GetStringSlice(argDeployPlanProviderPriority),
-		IgnoreDependencies:    viper.GetBool(argDeployPlanIgnoreDeps),
-		AutomaticDependencies: viper.GetBool(argDeployPlanAutoDeps),
+		Apps:                   appNames,
+		ProviderPriority:       viper.GetStringSlice(argDeployPlanProviderPriority),
+		IgnoreDependencies:     viper.GetBool(argDeployPlanIgnoreDeps),
+		AutomaticDependencies:  viper.GetBool(argDeployPlanAutoDeps),
+		SkipCompatibilityCheck: viper.GetBool(argDeployPlanSkipCompatibilityCheck),
 	}
 
 	planCreator := bosun.NewDeploymentPlanCreator(b, p)
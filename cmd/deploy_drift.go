@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"github.com/naveego/bosun/pkg/bosun"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var deployDriftCmd = addCommand(deployCmd, &cobra.Command{
+	Use:   "drift [path|release|stable|unstable] [apps...]",
+	Short: "Compares the apps deployed from a plan with the live releases in the current stack.",
+	Long: `Reports apps whose live helm release no longer matches what the plan deployed,
+for example because someone ran "helm upgrade" manually. An app is out of sync if its
+recorded commit, helm release status, chart version, or values differ from the plan.
+
+If apps are provided, only those apps will be checked.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		b, p := MustGetPlatform()
+
+		plan, err := getPlan(b, args)
+		if err != nil {
+			return err
+		}
+
+		var apps []string
+		if len(args) > 1 {
+			apps = args[1:]
+		}
+
+		executor := bosun.NewDeploymentPlanExecutor(b, p)
+
+		report, err := executor.DetectDrift(bosun.DetectDriftRequest{
+			Plan:        plan,
+			IncludeApps: apps,
+		})
+		if err != nil {
+			return err
+		}
+
+		if viper.GetBool(argDeployDriftOutOfSyncOnly) {
+			report = report.OutOfSync()
+		}
+
+		err = renderOutput(report)
+		if err != nil {
+			return err
+		}
+
+		if viper.GetBool(argDeployDriftFail) {
+			if outOfSync := report.OutOfSync(); len(outOfSync) > 0 {
+				return errors.Errorf("%d app(s) have drifted from the deployment plan", len(outOfSync))
+			}
+		}
+
+		return nil
+	},
+}, func(cmd *cobra.Command) {
+	cmd.Flags().Bool(argDeployDriftOutOfSyncOnly, false, "Only show apps which are out of sync.")
+	cmd.Flags().Bool(argDeployDriftFail, false, "Exit with an error if any app is out of sync (useful for scheduled checks).")
+})
+
+const (
+	argDeployDriftOutOfSyncOnly = "out-of-sync-only"
+	argDeployDriftFail          = "fail"
+)
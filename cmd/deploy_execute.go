@@ -44,10 +44,12 @@ var deployExecuteCmd = addCommand(deployCmd, &cobra.Command{
 			return err
 		}
 		req := bosun.ExecuteDeploymentPlanRequest{
-			Validate:       !viper.GetBool(argDeployExecuteSkipValidate),
-			DiffOnly:       viper.GetBool(argDeployExecuteDiffOnly),
-			DumpValuesOnly: viper.GetBool(argDeployExecuteValuesOnly),
-			UseSudo:        viper.GetBool(ArgGlobalSudo),
+			Validate:          !viper.GetBool(argDeployExecuteSkipValidate),
+			DiffOnly:          viper.GetBool(argDeployExecuteDiffOnly),
+			DumpValuesOnly:    viper.GetBool(argDeployExecuteValuesOnly),
+			UseSudo:           viper.GetBool(ArgGlobalSudo),
+			Concurrency:       viper.GetInt(argDeployExecuteParallel),
+			RollbackOnFailure: viper.GetBool(argDeployExecuteRollbackOnFailure),
 		}
 
 		pathOrSlot := args[0]
@@ -97,12 +99,16 @@ var deployExecuteCmd = addCommand(deployCmd, &cobra.Command{
 func applyDeployExecuteCmdFlags(cmd *cobra.Command) {
 	cmd.Flags().Bool(argDeployExecuteSkipValidate, false, "Skip validation")
 	cmd.Flags().Bool(argDeployExecuteValuesOnly, false, "Display the values which would be used for the deploy, but do not actually execute.")
+	cmd.Flags().Bool(argDeployExecuteRollbackOnFailure, false, "If any app fails to deploy, roll back every app deployed by this execution to the helm revision it was at before (apps which were not installed before are uninstalled).")
+	cmd.Flags().Int(argDeployExecuteParallel, 1, "Maximum number of apps to deploy at the same time. Apps are never deployed before the apps they depend on.")
 }
 
 const (
-	argDeployExecuteSkipValidate = "skip-validation"
-	argDeployExecuteDiffOnly     = "diff-only"
-	argDeployExecuteValuesOnly   = "values-only"
+	argDeployExecuteSkipValidate      = "skip-validation"
+	argDeployExecuteDiffOnly          = "diff-only"
+	argDeployExecuteValuesOnly        = "values-only"
+	argDeployExecuteParallel          = "parallel"
+	argDeployExecuteRollbackOnFailure = "rollback-on-failure"
 )
 
 var deployDiffCmd = addCommand(deployCmd, &cobra.Command{
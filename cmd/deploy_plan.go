@@ -60,9 +60,10 @@ var deployPlanCmd = addCommand(deployCmd, &cobra.Command{
 		log.Debugf("Saving plan to %q", path)
 
 		var req = bosun.CreateDeploymentPlanRequest{
-			Path:                  path,
-			IgnoreDependencies:    viper.GetBool(argDeployPlanIgnoreDeps),
-			AutomaticDependencies: viper.GetBool(argDeployPlanAutoDeps),
+			Path:                   path,
+			IgnoreDependencies:     viper.GetBool(argDeployPlanIgnoreDeps),
+			AutomaticDependencies:  viper.GetBool(argDeployPlanAutoDeps),
+			SkipCompatibilityCheck: viper.GetBool(argDeployPlanSkipCompatibilityCheck),
 		}
 		replace := viper.GetBool(argDeployPlanReplace)
 		var previousPlan *bosun.DeploymentPlan
@@ -130,6 +131,9 @@ var deployPlanCmd = addCommand(deployCmd, &cobra.Command{
 		if req.AutomaticDependencies {
 			cli += "--auto-deps "
 		}
+		if req.SkipCompatibilityCheck {
+			cli += "--skip-compatibility-check "
+		}
 		if viper.GetBool(argDeployPlanAll) {
 			cli += "--all "
 		} else {
@@ -149,17 +153,19 @@ func applyDeployPlanFlags(cmd *cobra.Command) {
 	cmd.Flags().Bool(argDeployPlanAll, false, "Deploy all apps which target the current environment.")
 	cmd.Flags().Bool(argDeployPlanIgnoreDeps, false, "Don't validate dependencies.")
 	cmd.Flags().Bool(argDeployPlanAutoDeps, false, "Automatically include dependencies.")
+	cmd.Flags().Bool(argDeployPlanSkipCompatibilityCheck, false, "Don't check that apps are compatible with the versions of their dependencies.")
 	cmd.Flags().Bool(argDeployPlanReplace, false, "Replace an existing plan rather than updating it if it already exists.")
 }
 
 const (
-	argDeployPlanPath             = "path"
-	argDeployPlanApps             = "apps"
-	argDeployPlanAll              = "all"
-	argDeployPlanProviderPriority = "providers"
-	argDeployPlanIgnoreDeps       = "ignore-deps"
-	argDeployPlanAutoDeps         = "auto-deps"
-	argDeployPlanReplace          = "replace"
+	argDeployPlanPath                   = "path"
+	argDeployPlanApps                   = "apps"
+	argDeployPlanAll                    = "all"
+	argDeployPlanProviderPriority       = "providers"
+	argDeployPlanIgnoreDeps             = "ignore-deps"
+	argDeployPlanAutoDeps               = "auto-deps"
+	argDeployPlanReplace                = "replace"
+	argDeployPlanSkipCompatibilityCheck = "skip-compatibility-check"
 )
 
 var deployReleasePlanCmd = addCommand(deployPlanCmd, &cobra.Command{
@@ -16,11 +16,14 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/fatih/color"
 	"github.com/naveego/bosun/pkg/bosun"
 	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"os"
+	"strings"
 )
 
 var e2eCmd = addCommand(rootCmd, &cobra.Command{
@@ -63,12 +66,27 @@ var e2eRunCmd = addCommand(e2eCmd, &cobra.Command{
 			SkipSetup:    viper.GetBool(ArgE2ERunSkipSetup),
 			SkipTeardown: viper.GetBool(ArgE2ERunSkipTeardown),
 			Tests:        viper.GetStringSlice(ArgE2ERunTests),
+			KeepGoing:    viper.GetBool(ArgE2ERunKeepGoing),
+			Parallelism:  viper.GetInt(ArgE2ERunParallel),
 		}
+		reports := map[string]string{}
+		for _, report := range viper.GetStringSlice(ArgE2ERunReport) {
+			segs := strings.SplitN(report, "=", 2)
+			if len(segs) != 2 || segs[1] == "" {
+				return errors.Errorf("invalid report %q, expected format=path (like junit=results.xml)", report)
+			}
+			reports[segs[1]] = segs[0]
+		}
+
 		ctx := bosun.WithE2EContext(b.NewContext(), e2eCtx)
-		results, err := suite.Run(ctx)
+		results, runErr := suite.Run(ctx)
 
-		if err != nil {
-			return err
+		for path, format := range reports {
+			err = bosun.NewE2EReport(suite.Name, results, runErr).WriteFile(format, path)
+			if err != nil {
+				return errors.Wrapf(err, "write %s report to %q", format, path)
+			}
+			ctx.Log().Infof("Wrote %s report to %s.", format, path)
 		}
 
 		for _, result := range results {
@@ -76,6 +94,8 @@ var e2eRunCmd = addCommand(e2eCmd, &cobra.Command{
 			colorHeader.Printf("Test: %s  ", result.Name)
 			if result.Passed {
 				colorOK.Println("PASS")
+			} else if result.Skipped {
+				color.Yellow("SKIPPED (%s)", result.Error)
 			} else {
 				colorError.Println("FAIL")
 			}
@@ -100,6 +120,8 @@ var e2eRunCmd = addCommand(e2eCmd, &cobra.Command{
 				fail := ""
 				if step.Passed {
 					pass = "PASS"
+				} else if step.Skipped {
+					fail = "SKIPPED"
 				} else {
 					fail = step.Error
 				}
@@ -109,16 +131,33 @@ var e2eRunCmd = addCommand(e2eCmd, &cobra.Command{
 			t.Render()
 			fmt.Println()
 		}
+
+		if runErr != nil {
+			return runErr
+		}
+
+		for _, result := range results {
+			if !result.Passed {
+				return errors.Errorf("one or more tests failed")
+			}
+		}
+
 		return nil
 	},
 }, func(cmd *cobra.Command) {
 	cmd.Flags().StringSlice(ArgE2ERunTests, []string{}, "Specific tests to run.")
 	cmd.Flags().Bool(ArgE2ERunSkipSetup, false, "Skip setup scripts.")
 	cmd.Flags().Bool(ArgE2ERunSkipTeardown, false, "Skip teardown scripts.")
+	cmd.Flags().Bool(ArgE2ERunKeepGoing, false, "Keep running the remaining tests, in order, after a test fails.")
+	cmd.Flags().Int(ArgE2ERunParallel, 1, "Number of tests marked as independent which can be run at the same time.")
+	cmd.Flags().StringSlice(ArgE2ERunReport, []string{}, "Write the results to a file, as format=path. Supported formats are junit and json (like --report junit=results.xml).")
 })
 
 const (
 	ArgE2ERunTests        = "tests"
 	ArgE2ERunSkipSetup    = "skip-setup"
 	ArgE2ERunSkipTeardown = "skip-teardown"
+	ArgE2ERunReport       = "report"
+	ArgE2ERunKeepGoing    = "keep-going"
+	ArgE2ERunParallel     = "parallel"
 )
@@ -48,7 +48,7 @@ var gitDeployDryRunCmd = addCommand(gitDeploymentCmd, &cobra.Command{
 			return err
 		}
 		head := g.GetCurrentCommit()
-		changeLog, err := g.ChangeLog(previousRef, head, nil, git.GitChangeLogOptions{})
+		changeLog, err := g.ChangeLog(previousRef, head, nil, git.GitChangeLogOptions{Rules: b.GetCommitRules(app.Branching)})
 		if err != nil {
 			return err
 		}
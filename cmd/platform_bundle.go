@@ -38,6 +38,17 @@ var bundleCmd = addCommand(platformCmd, &cobra.Command{
 var _ = addCommand(bundleCmd, &cobra.Command{
 	Use:          "create",
 	Short:        "Creates a portable bundle zip from a platform and the active release.",
+	Long: `Creates a portable bundle zip from a platform and the active release.
+
+The bundle contains a manifest of the digest of every file in the bundle. If a private key is configured
+in the workspace (bundleSigning.privateKeyPath) or provided using --signing-key, the bundle also contains
+a detached signature of the manifest. The key can be an ed25519 key in PKCS8 PEM format
+(create one using "openssl genpkey -algorithm ed25519") or a cosign key (create one using
+"cosign generate-key-pair"; the password is read from COSIGN_PASSWORD or bundleSigning.password).
+
+If --offline is set, every image used by the apps in the release is pulled and saved into the bundle
+using "docker save", and the chart of every app is packaged (or pulled from its chart repo) into the
+bundle, so that the bundle can be pushed to a cluster which cannot reach the original registries.`,
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		b := MustGetBosun()
@@ -46,7 +57,10 @@ var _ = addCommand(bundleCmd, &cobra.Command{
 			return err
 		}
 
-		result, err := bosun.NewPlatformBundler(b, p).Execute()
+		result, err := bosun.NewPlatformBundler(b, p).Execute(bosun.BundlePlatformRequest{
+			SigningKeyPath: viper.GetString(argBundleSigningKey),
+			Offline:        viper.GetBool(argBundleOffline),
+		})
 		if err != nil {
 			return err
 		}
@@ -56,14 +70,23 @@ var _ = addCommand(bundleCmd, &cobra.Command{
 		return nil
 	},
 }, func(cmd *cobra.Command) {
-
+	cmd.Flags().String(argBundleSigningKey, "", "The private key to sign the bundle with (defaults to bundleSigning.privateKeyPath in the workspace).")
+	cmd.Flags().Bool(argBundleOffline, false, "Include the images and charts used by the release in the bundle.")
 })
 
 
 var _ = addCommand(bundleCmd, &cobra.Command{
 	Use:          "push",
 	Short:        "Pushes a bundle to a kubernetes cluster.",
-	Long:         "By default, pushes to the current cluster selected in the kubeconfig",
+	Long: `By default, pushes to the current cluster selected in the kubeconfig.
+
+The bundle must be signed by the private key matching the public key provided using --public-key
+(or bundleSigning.publicKeyPath in the environment file), and every file in the bundle must match
+the digest in the bundle manifest. Use --allow-unverified to push an unsigned or modified bundle.
+
+If the bundle was created with --offline, the images and charts in the bundle are pushed to the
+registry in the environment file (registry.host) before the apps are deployed. Images keep their
+path but are moved to the registry host and project; charts are pushed to oci://<host>/<project>/charts.`,
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		bundleDir := viper.GetString(argBundleDir)
@@ -117,6 +140,9 @@ var _ = addCommand(bundleCmd, &cobra.Command{
 			PushApp: pushApp,
 			PushAllApps: bundlePushAllApps,
 			Cluster: envConfig.Clusters[0].Name,
+			PublicKeyPath: viper.GetString(argBundlePublicKey),
+			AllowUnverified: viper.GetBool(argBundleAllowUnverified),
+			SkipOfflineContent: viper.GetBool(argBundleSkipOfflineContent),
 		})
 
 		if err != nil {
@@ -129,6 +155,9 @@ var _ = addCommand(bundleCmd, &cobra.Command{
 	cmd.Flags().String(argBundleDir, os.Getenv("BOSUN_BUNDLE_DIR"), "The directory of the unzipped bundle")
 	cmd.Flags().String(argBundleEnv, os.Getenv("BOSUN_BUNDLE_ENV"), "The path to the environment file")
 	cmd.Flags().Bool(argBundlePushAll, os.Getenv("BOSUN_BUNDLE_PUSH_ALL") != "", "Whether or not to push all apps or only upgraded apps")
+	cmd.Flags().String(argBundlePublicKey, os.Getenv("BOSUN_BUNDLE_PUBLIC_KEY"), "The public key to verify the bundle signature with")
+	cmd.Flags().Bool(argBundleAllowUnverified, false, "Push the bundle even if it is unsigned or does not match its manifest")
+	cmd.Flags().Bool(argBundleSkipOfflineContent, false, "Don't load the images and charts in an offline bundle into the environment registry")
 
 })
 
@@ -137,6 +166,11 @@ const (
 	argBundleDir = "bundle-dir"
 	argBundleEnv = "bundle-env"
 	argBundlePushAll = "bundle-push-all"
+	argBundleSigningKey = "signing-key"
+	argBundlePublicKey = "public-key"
+	argBundleAllowUnverified = "allow-unverified"
+	argBundleOffline = "offline"
+	argBundleSkipOfflineContent = "skip-offline-content"
 
 	LabelBundleConfigMapHash = "naveego.com/bosun-bundle-hash"
 )
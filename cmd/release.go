@@ -294,7 +294,7 @@ const (
 var releaseValidateCmd = addCommand(releaseCmd, &cobra.Command{
 	Use:           "validate [names...]",
 	Short:         "Validates the release.",
-	Long:          "Validation checks that all apps (or the named apps) in the current release have a published chart and docker image.",
+	Long:          "Validation checks that all apps (or the named apps) in the current release have a published chart and docker image, and that every app is compatible with the versions of its dependencies in the release.",
 	SilenceErrors: true,
 	SilenceUsage:  true,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -329,10 +329,12 @@ var releaseValidateCmd = addCommand(releaseCmd, &cobra.Command{
 	withFilteringFlags,
 	func(cmd *cobra.Command) {
 		cmd.Flags().Bool(ArgReleaseValidateNoProgress, false, "Do not emit progress bars.")
+		cmd.Flags().Bool(ArgReleaseValidateSkipCompatibilityCheck, false, "Don't check that apps are compatible with the versions of their dependencies.")
 	})
 
 const (
-	ArgReleaseValidateNoProgress = "no-progress"
+	ArgReleaseValidateNoProgress             = "no-progress"
+	ArgReleaseValidateSkipCompatibilityCheck = "skip-compatibility-check"
 )
 
 func validateDeploy(b *bosun.Bosun, ctx bosun.BosunContext, release *bosun.Deploy) error {
@@ -413,6 +415,22 @@ func validateDeploy(b *bosun.Bosun, ctx bosun.BosunContext, release *bosun.Deplo
 
 	t.Render()
 
+	manifests := map[string]*bosun.AppManifest{}
+	for _, app := range apps {
+		manifests[app.Name] = app.AppManifest
+	}
+	var compatibility bosun.DependencyCompatibilityMatrix
+	if !viper.GetBool(ArgReleaseValidateSkipCompatibilityCheck) {
+		compatibility = bosun.CheckDependencyCompatibility(manifests)
+	}
+	if len(compatibility) > 0 {
+		fmt.Println("Dependency compatibility:")
+		fmt.Println(compatibility)
+		if len(compatibility.Failures()) > 0 {
+			hasErrors = true
+		}
+	}
+
 	if hasErrors {
 		return errors.New("Some apps are invalid.")
 	}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"github.com/naveego/bosun/pkg/bosun"
+	"github.com/naveego/bosun/pkg/git"
+	"github.com/naveego/bosun/pkg/semver"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+var _ = addCommand(releaseCmd, &cobra.Command{
+	Use:   "notes [release]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Generates release notes for all apps in a release.",
+	Long: `Generates release notes by comparing the commit of each app in the release to its commit
+in the previous release, grouping the conventional commits by the version bump they require,
+and linking them to their issues and stories.
+
+The release can be "stable", "unstable", "current", or a release version, and defaults to "stable".
+The previous release is the release with the highest version lower than the release's version,
+unless --previous is set.
+
+The notes are rendered as Markdown or HTML using a Go template. The template is executed against
+a bosun.ReleaseNotes value. The app repos must be cloned and up to date.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		viper.BindPFlags(cmd.Flags())
+		b := MustGetBosun()
+		p, err := b.GetCurrentPlatform()
+		if err != nil {
+			return err
+		}
+
+		name := bosun.SlotStable
+		if len(args) > 0 {
+			name = args[0]
+		}
+		release, err := getReleaseByNameOrVersion(p, name, bosun.SlotStable)
+		if err != nil {
+			return errors.Wrapf(err, "get release %q", name)
+		}
+
+		var previous *bosun.ReleaseManifest
+		if previousName := viper.GetString(ArgReleaseNotesPrevious); previousName != "" {
+			previous, err = getReleaseByNameOrVersion(p, previousName, bosun.SlotPrevious)
+		} else if previousMetadata := p.GetPreviousReleaseMetadata(release.Version); previousMetadata != nil {
+			previous, err = getReleaseByNameOrVersion(p, previousMetadata.Version.String(), bosun.SlotPrevious)
+		} else {
+			b.NewContext().Log().Warnf("No release found before %s, all apps will be listed as new.", release.Version)
+		}
+		if err != nil {
+			return errors.Wrap(err, "get previous release")
+		}
+
+		ctx := b.NewContext()
+		req := bosun.ReleaseNotesRequest{
+			Release:         release,
+			PreviousRelease: previous,
+		}
+		if !viper.GetBool(ArgReleaseNotesNoStories) {
+			req.IssueService, err = b.GetIssueService()
+			if err != nil {
+				ctx.Log().WithError(err).Warn("Could not get issue service, changes will not be linked to stories.")
+				req.IssueService = nil
+			}
+		}
+
+		var tmpl []byte
+		if templatePath := viper.GetString(ArgReleaseNotesTemplate); templatePath != "" {
+			tmpl, err = ioutil.ReadFile(templatePath)
+			if err != nil {
+				return errors.Wrap(err, "read template")
+			}
+		}
+
+		notes, err := bosun.GenerateReleaseNotes(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if outPath := viper.GetString(ArgReleaseNotesFile); outPath != "" {
+			f, createErr := os.Create(outPath)
+			if createErr != nil {
+				return createErr
+			}
+			defer f.Close()
+			w = f
+		}
+
+		return notes.Render(w, viper.GetString(ArgReleaseNotesFormat), string(tmpl))
+	},
+}, func(cmd *cobra.Command) {
+	cmd.Flags().String(ArgReleaseNotesPrevious, "", "The release to compare to (defaults to the release before the requested release).")
+	cmd.Flags().String(ArgReleaseNotesFormat, bosun.ReleaseNotesFormatMarkdown, "The format of the notes, markdown or html.")
+	cmd.Flags().String(ArgReleaseNotesTemplate, "", "Path to a Go template to render the notes with (defaults to a built in template for the format).")
+	cmd.Flags().String(ArgReleaseNotesFile, "", "Path to write the notes to (defaults to stdout).")
+	cmd.Flags().Bool(ArgReleaseNotesNoStories, false, "Don't link changes to stories using the issue service.")
+})
+
+const (
+	ArgReleaseNotesPrevious  = "previous"
+	ArgReleaseNotesFormat    = "format"
+	ArgReleaseNotesTemplate  = "template"
+	ArgReleaseNotesFile      = "file"
+	ArgReleaseNotesNoStories = "no-stories"
+)
+
+// getReleaseByNameOrVersion gets a release by its slot ("stable", "unstable", "current" or "previous")
+// or by its version, in which case it is loaded from its release branch into asSlot.
+func getReleaseByNameOrVersion(p *bosun.Platform, name string, asSlot string) (*bosun.ReleaseManifest, error) {
+	switch name {
+	case bosun.SlotStable, bosun.SlotUnstable:
+		return p.GetReleaseManifestBySlot(name)
+	case "current":
+		return p.GetCurrentRelease()
+	case bosun.SlotPrevious:
+		return p.GetPreviousRelease()
+	}
+
+	version, err := semver.Parse(name)
+	if err != nil {
+		return nil, errors.Errorf("%q is not a release slot or version", name)
+	}
+	if _, err = p.GetReleaseMetadataByVersion(version); err != nil {
+		return nil, err
+	}
+	return p.GetReleaseManifestBySlotAndBranch(bosun.SlotStable, asSlot, git.BranchName(p.MakeReleaseBranchName(version)))
+}
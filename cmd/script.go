@@ -17,21 +17,35 @@ package cmd
 import (
 	"fmt"
 	"github.com/fatih/color"
+	"github.com/naveego/bosun/pkg/bosun"
 	script2 "github.com/naveego/bosun/pkg/script"
+	"github.com/naveego/bosun/pkg/values"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 var scriptCmd = &cobra.Command{
-	Use:          "script {script-file}",
-	Args:         cobra.ExactArgs(1),
-	Aliases:      []string{"scripts"},
-	Short:        "Run a scripted sequence of commands.",
-	Long:         `Provide a script file path.`,
-	SilenceUsage: true,
+	Use:     "script {script-file}",
+	Args:    cobra.ExactArgs(1),
+	Aliases: []string{"scripts"},
+	Short:   "Run a scripted sequence of commands.",
+	Long: `Provide a script name or a script file path.
+
+If the script has params, they can be provided as flags after the script name,
+like 'bosun script my-script --version 1.2.3'. Use 'bosun script list' to see the params for each script.
+
+The progress of each run is saved, so if a step fails you can fix the problem and
+continue from that step using --resume. Params provided as flags when resuming
+override the values from the failed run.`,
+	SilenceUsage:       true,
+	FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		viper.BindPFlags(cmd.Flags())
 
@@ -43,13 +57,13 @@ var scriptCmd = &cobra.Command{
 		script, err := b.GetScript(args[0])
 		if err != nil {
 			scriptFilePath := args[0]
-			var script script2.Script
-			data, err := ioutil.ReadFile(scriptFilePath)
-			if err != nil {
-				return err
+			data, readErr := ioutil.ReadFile(scriptFilePath)
+			if readErr != nil {
+				return readErr
 			}
 
-			err = yaml.Unmarshal(data, &script)
+			script = &script2.Script{}
+			err = yaml.Unmarshal(data, script)
 			if err != nil {
 				return err
 			}
@@ -59,7 +73,18 @@ var scriptCmd = &cobra.Command{
 			return errors.New("script was nil")
 		}
 
-		return script.Execute(b.NewContext(), scriptStepsSlice...)
+		opts, err := getScriptExecuteOptions(b, script)
+		if err != nil {
+			return err
+		}
+
+		releaseValues := &values.PersistableValues{Values: values.Values{}}
+		if err = applyScriptParams(cmd, script, releaseValues); err != nil {
+			return err
+		}
+		ctx := b.NewContext().WithPersistableValues(releaseValues).(bosun.BosunContext)
+
+		return script.ExecuteWithOptions(ctx, opts)
 	},
 }
 
@@ -88,6 +113,7 @@ var scriptListCmd = &cobra.Command{
 			color.New(color.Bold).Println(script.Name)
 			color.Blue("FROM: %s\n", script.FromPath)
 			color.White("%s\n", script.Description)
+			printScriptParams(script)
 			fmt.Println()
 		}
 
@@ -96,16 +122,107 @@ var scriptListCmd = &cobra.Command{
 }
 
 const (
-	ArgScriptSteps = "steps"
+	ArgScriptSteps    = "steps"
+	ArgScriptResume   = "resume"
+	ArgScriptFromStep = "from-step"
+	ArgScriptToStep   = "to-step"
 )
 
+func withScriptExecuteFlags(cmd *cobra.Command) {
+	cmd.Flags().IntSliceVar(&scriptStepsSlice, ArgScriptSteps, []int{}, "Steps to run (defaults to all steps)")
+	cmd.Flags().Bool(ArgScriptResume, false, "Resume the last run of the script from the step which failed, using the same params (except any provided as flags) and the values captured by the completed steps.")
+	cmd.Flags().String(ArgScriptFromStep, "", "Name or index of the first step to run.")
+	cmd.Flags().String(ArgScriptToStep, "", "Name or index of the last step to run.")
+}
+
+// getScriptExecuteOptions gets the steps to run from the flags added by withScriptExecuteFlags.
+// Checkpoints are saved in the checkpoints directory next to the workspace file.
+func getScriptExecuteOptions(b *bosun.Bosun, script *script2.Script) (script2.ExecuteOptions, error) {
+	opts := script2.ExecuteOptions{
+		Steps:         scriptStepsSlice,
+		CheckpointDir: filepath.Join(filepath.Dir(b.GetWorkspace().Path), "checkpoints"),
+		Resume:        viper.GetBool(ArgScriptResume),
+	}
+
+	from, to := viper.GetString(ArgScriptFromStep), viper.GetString(ArgScriptToStep)
+	if from == "" && to == "" {
+		if opts.Resume && len(opts.Steps) > 0 {
+			return opts, errors.Errorf("--%s cannot be used with --%s", ArgScriptResume, ArgScriptSteps)
+		}
+		return opts, nil
+	}
+
+	if opts.Resume || len(opts.Steps) > 0 {
+		return opts, errors.Errorf("--%s and --%s cannot be used with --%s or --%s", ArgScriptFromStep, ArgScriptToStep, ArgScriptResume, ArgScriptSteps)
+	}
+
+	var err error
+	opts.Steps, err = script.GetStepRange(from, to)
+	return opts, err
+}
+
+// applyScriptParams parses the flags for the script's params from the command line
+// and sets the values in releaseValues. The script commands allow unknown flags
+// because the flags for the params can't be added until the script has been found,
+// so this parses the command line again with the params added.
+func applyScriptParams(cmd *cobra.Command, script *script2.Script, releaseValues *values.PersistableValues) error {
+	flags := pflag.NewFlagSet(script.Name, pflag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	if err := script.AddParamFlags(flags); err != nil {
+		return errors.Wrapf(err, "script %q", script.Name)
+	}
+
+	var conflicts []string
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if flags.Lookup(f.Name) != nil {
+			conflicts = append(conflicts, f.Name)
+			return
+		}
+		// the bosun flags have already been parsed, so their values are ignored here
+		flags.AddFlag(&pflag.Flag{Name: f.Name, Shorthand: f.Shorthand, NoOptDefVal: f.NoOptDefVal, Value: &ignoredFlagValue{}})
+	})
+	if len(conflicts) > 0 {
+		return errors.Errorf("script %q has params which conflict with bosun flags: %s", script.Name, strings.Join(conflicts, ", "))
+	}
+
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		return errors.Wrapf(err, "script %q", script.Name)
+	}
+
+	for name, value := range script.GetParamValues(flags) {
+		releaseValues.Values[name] = value
+	}
+
+	return nil
+}
+
+// printScriptParams prints the flags for the script's params.
+func printScriptParams(script *script2.Script) {
+	if len(script.Params) == 0 {
+		return
+	}
+	flags := pflag.NewFlagSet(script.Name, pflag.ContinueOnError)
+	if err := script.AddParamFlags(flags); err != nil {
+		color.Red("Invalid params: %s\n", err)
+		return
+	}
+	fmt.Println("Params:")
+	fmt.Print(flags.FlagUsages())
+}
+
+type ignoredFlagValue struct{}
+
+func (ignoredFlagValue) String() string   { return "" }
+func (ignoredFlagValue) Set(string) error { return nil }
+func (ignoredFlagValue) Type() string     { return "" }
+
 var (
 	scriptStepsSlice []int
 )
 
 func init() {
 
-	scriptCmd.Flags().IntSliceVar(&scriptStepsSlice, ArgScriptSteps, []int{}, "Steps to run (defaults to all steps)")
+	withScriptExecuteFlags(scriptCmd)
 
 	scriptCmd.AddCommand(scriptListCmd)
 
@@ -17,11 +17,17 @@ package cmd
 import (
 	"fmt"
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/naveego/bosun/pkg/bosun"
 	"github.com/naveego/bosun/pkg/cli"
 	"github.com/naveego/bosun/pkg/environment"
+	"github.com/naveego/bosun/pkg/util/stringsn"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"log"
+	"sort"
+	"time"
 )
 
 var secretsCmd = addCommand(rootCmd, &cobra.Command{
@@ -43,16 +49,35 @@ var secretsListCmd = addCommand(secretsCmd, &cobra.Command{
 		if err != nil {
 			return err
 		}
+
+		now := time.Now()
+		staleAfter := time.Duration(viper.GetInt(argSecretsListStaleAfterDays)) * 24 * time.Hour
+
 		for _, group := range secretGroups {
-			fmt.Printf("%s:\n", group.Name)
+			fmt.Printf("%s (%s):\n", group.Name, group.GetBackendType())
 			for _, secret := range group.Secrets {
-				fmt.Printf("\t%s\n", secret.Name)
+				rotated := ""
+				if !secret.RotatedAt.IsZero() {
+					rotated = fmt.Sprintf("rotated %s (%d days ago)", secret.RotatedAt.Format("2006-01-02"), int(now.Sub(secret.RotatedAt).Hours()/24))
+				} else if secret.Generation != nil {
+					rotated = "rotated UNKNOWN"
+				}
+				if secret.IsStale(now, staleAfter) {
+					rotated += color.YellowString(" STALE")
+				}
+				fmt.Printf("\t%-30s %s\n", secret.Name, rotated)
 			}
 		}
 		return nil
 	},
+}, func(cmd *cobra.Command) {
+	cmd.Flags().Int(argSecretsListStaleAfterDays, 90, "Generated secrets which have not been rotated for this many days are flagged as stale, unless they set rotateAfterDays.")
 })
 
+const (
+	argSecretsListStaleAfterDays = "stale-after-days"
+)
+
 var secretsAddGroupCmd = addCommand(secretsCmd, &cobra.Command{
 	Use:   "add-group {name}",
 	Args:  cobra.ExactArgs(1),
@@ -66,6 +91,17 @@ func addSecretGroup(groupName string) error {
 	b := MustGetBosun()
 	e := b.GetCurrentEnvironment()
 
+	keyConfig, err := promptForSecretKeyConfig(e)
+	if err != nil {
+		return err
+	}
+
+	err = e.AddSecretGroup(groupName, keyConfig)
+
+	return err
+}
+
+func promptForSecretKeyConfig(e *environment.Environment) (*environment.SecretKeyConfig, error) {
 	var passwordStrategyIndex int
 	prompt := &survey.Select{
 		Message: "How do you want to provide the password?",
@@ -97,7 +133,7 @@ func addSecretGroup(groupName string) error {
 		}
 	case 2:
 		if !e.IsLocal {
-			return errors.New("Insecure storage of the key only allowed in local environments.")
+			return nil, errors.New("Insecure storage of the key only allowed in local environments.")
 		}
 
 		var password string
@@ -109,9 +145,7 @@ func addSecretGroup(groupName string) error {
 		panic("invalid response")
 	}
 
-	err := e.AddSecretGroup(groupName, keyConfig)
-
-	return err
+	return keyConfig, nil
 }
 
 var secretsSetCmd = addCommand(secretsCmd, &cobra.Command{
@@ -178,3 +212,238 @@ var secretsShowCmd = addCommand(secretsCmd, &cobra.Command{
 		return nil
 	},
 })
+
+var secretsMigrateCmd = addCommand(secretsCmd, &cobra.Command{
+	Use:   "migrate {group}",
+	Args:  cobra.ExactArgs(1),
+	Short: "Moves the values of a secret group to a different backend.",
+	Long: `Moves the values of a secret group to a different backend.
+
+Supported backends are "file" (values are encrypted and stored in the group file),
+"vault" (a Vault KV v2 engine), "sops" (a SOPS encrypted file), and "kubernetes"
+(a Kubernetes Secret). The values are not removed from the previous backend.`,
+	Example: `bosun secrets migrate my-group --to vault --vault-path bosun/my-group
+bosun secrets migrate my-group --to sops --sops-age-recipient age1...
+bosun secrets migrate my-group --to kubernetes --kube-namespace bosun`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		b := MustGetBosun()
+		e := b.GetCurrentEnvironment()
+
+		groupName := args[0]
+
+		backend := &environment.SecretBackendConfig{
+			Type: viper.GetString(argSecretsMigrateTo),
+		}
+
+		var keyConfig *environment.SecretKeyConfig
+
+		switch backend.Type {
+		case environment.SecretBackendFile:
+			var err error
+			keyConfig, err = promptForSecretKeyConfig(e)
+			if err != nil {
+				return err
+			}
+		case environment.SecretBackendVault:
+			backend.Vault = &environment.VaultSecretBackendConfig{
+				Address: viper.GetString(argSecretsMigrateVaultAddress),
+				Mount:   viper.GetString(argSecretsMigrateVaultMount),
+				Path:    viper.GetString(argSecretsMigrateVaultPath),
+			}
+		case environment.SecretBackendSops:
+			backend.Sops = &environment.SopsSecretBackendConfig{
+				Path:          viper.GetString(argSecretsMigrateSopsPath),
+				AgeRecipients: viper.GetStringSlice(argSecretsMigrateSopsAgeRecipients),
+			}
+		case environment.SecretBackendKubernetes:
+			backend.Kubernetes = &environment.KubernetesSecretBackendConfig{
+				Namespace: viper.GetString(argSecretsMigrateKubeNamespace),
+				Name:      viper.GetString(argSecretsMigrateKubeSecretName),
+				Context:   viper.GetString(argSecretsMigrateKubeContext),
+			}
+		default:
+			return errors.Errorf("invalid --%s value %q (must be one of %v)", argSecretsMigrateTo, backend.Type, environment.SecretBackends)
+		}
+
+		err := e.MigrateSecretGroup(groupName, backend, keyConfig)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Migrated secret group %s to %s backend.", groupName, backend.Type)
+
+		return nil
+	},
+}, func(cmd *cobra.Command) {
+	cmd.Flags().String(argSecretsMigrateTo, "", fmt.Sprintf("The backend to move the group to (one of %v).", environment.SecretBackends))
+	cmd.Flags().String(argSecretsMigrateVaultAddress, "", "Vault address (defaults to VAULT_ADDR).")
+	cmd.Flags().String(argSecretsMigrateVaultMount, "", "Path the Vault KV v2 engine is mounted at (defaults to secret).")
+	cmd.Flags().String(argSecretsMigrateVaultPath, "", "Path of the secret in the Vault KV engine (defaults to bosun/{group}).")
+	cmd.Flags().String(argSecretsMigrateSopsPath, "", "Path to the SOPS encrypted file, relative to the group file (defaults to {group}.secrets.sops.yaml).")
+	cmd.Flags().StringSlice(argSecretsMigrateSopsAgeRecipients, nil, "Age public keys to encrypt the SOPS file for (defaults to the .sops.yaml creation rules).")
+	cmd.Flags().String(argSecretsMigrateKubeNamespace, "", "Namespace of the Kubernetes Secret (defaults to default).")
+	cmd.Flags().String(argSecretsMigrateKubeSecretName, "", "Name of the Kubernetes Secret (defaults to bosun-secrets-{group}).")
+	cmd.Flags().String(argSecretsMigrateKubeContext, "", "Kubeconfig context to use (defaults to the current context).")
+	_ = cmd.MarkFlagRequired(argSecretsMigrateTo)
+})
+
+const (
+	argSecretsMigrateTo                = "to"
+	argSecretsMigrateVaultAddress      = "vault-address"
+	argSecretsMigrateVaultMount        = "vault-mount"
+	argSecretsMigrateVaultPath         = "vault-path"
+	argSecretsMigrateSopsPath          = "sops-path"
+	argSecretsMigrateSopsAgeRecipients = "sops-age-recipient"
+	argSecretsMigrateKubeNamespace     = "kube-namespace"
+	argSecretsMigrateKubeSecretName    = "kube-secret-name"
+	argSecretsMigrateKubeContext       = "kube-context"
+)
+
+var secretsRotateCmd = addCommand(secretsCmd, &cobra.Command{
+	Use:   "rotate {group} [names...]",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Generates new values for secrets which have a generation config.",
+	Long: `Generates new values for secrets which have a generation config, using that config.
+If no names are provided, all generated secrets in the group are rotated.
+
+The previous value of each secret is retained and can be resolved as {group}/{name}.previous
+until the secret is rotated again.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		b := MustGetBosun()
+		e := b.GetCurrentEnvironment()
+
+		groupName := args[0]
+
+		rotated, err := e.RotateSecrets(groupName, args[1:]...)
+		if err != nil {
+			return err
+		}
+
+		if len(rotated) == 0 {
+			log.Printf("No secrets in group %s have a generation config, nothing was rotated.", groupName)
+			return nil
+		}
+
+		log.Printf("Rotated secrets %v in group %s.", rotated, groupName)
+
+		if !viper.GetBool(argSecretsRotateRedeploy) {
+			return nil
+		}
+
+		check(b.ConfirmEnvironment())
+
+		p, err := b.GetCurrentPlatform()
+		if err != nil {
+			return err
+		}
+
+		var secretPaths []*environment.SecretPath
+		for _, name := range rotated {
+			secretPaths = append(secretPaths, &environment.SecretPath{GroupName: groupName, SecretName: name})
+		}
+
+		ctx := b.NewContext()
+		var appNames []string
+		for _, platformApp := range p.GetApps(ctx).FilterByEnvironment(e) {
+			app, getAppErr := b.GetApp(platformApp.Name)
+			if getAppErr != nil {
+				ctx.Log().WithError(getAppErr).Warnf("Could not get app %q to check whether it uses the rotated secrets.", platformApp.Name)
+				continue
+			}
+			references, referencesErr := app.ReferencesSecret(ctx, secretPaths...)
+			if referencesErr != nil {
+				return referencesErr
+			}
+			if references {
+				appNames = append(appNames, app.Name)
+			}
+		}
+
+		if len(appNames) == 0 {
+			log.Printf("No apps reference the rotated secrets.")
+			return nil
+		}
+
+		sort.Strings(appNames)
+		log.Printf("Redeploying apps which reference the rotated secrets: %v", appNames)
+
+		return deployApps(b, p, appNames, nil, appNames)
+	},
+}, func(cmd *cobra.Command) {
+	cmd.Flags().Bool(argSecretsRotateRedeploy, false, "Redeploy the apps whose values reference the rotated secrets.")
+})
+
+const (
+	argSecretsRotateRedeploy = "redeploy"
+)
+
+var secretsLintCmd = addCommand(secretsCmd, &cobra.Command{
+	Use:   "lint [environments...]",
+	Short: "Checks secret references in apps, environments, and stack templates against the secret groups of each environment.",
+	Long: `Finds every secret path referenced using resolveSecretPath in the values of the platform's apps,
+in the environment configs, and in the stack templates of their clusters, then checks each reference
+against the secret groups of each environment. Reports secrets which are referenced but missing,
+secrets which are defined but unused, and secrets which are defined in some environments but not others.
+
+Only the platform files are read, so no secret values are decrypted and no cluster access is needed.
+The command fails if any secrets are missing, or if there are any findings at all when --strict is set.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		b, p := MustGetPlatform()
+
+		allEnvironments, err := b.GetEnvironments()
+		if err != nil {
+			return err
+		}
+
+		req := bosun.SecretLintRequest{}
+		for _, env := range allEnvironments {
+			if len(args) == 0 || stringsn.Contains(args, env.Name) {
+				req.Environments = append(req.Environments, env)
+			}
+		}
+		if len(req.Environments) == 0 {
+			return errors.Errorf("no environments found matching %v", args)
+		}
+
+		ctx := b.NewContext()
+		providerPriority := viper.GetStringSlice(argSecretsLintProviderPriority)
+		for _, platformApp := range p.GetApps(ctx) {
+			app, getAppErr := b.GetApp(platformApp.Name, providerPriority...)
+			if getAppErr != nil {
+				ctx.Log().WithError(getAppErr).Warnf("Could not get app %q, its secret references will not be checked.", platformApp.Name)
+				continue
+			}
+			req.Apps = append(req.Apps, app)
+		}
+
+		findings, err := p.LintSecrets(req)
+		if err != nil {
+			return err
+		}
+
+		if err = renderOutput(findings); err != nil {
+			return err
+		}
+
+		if missing := findings.OfKind(bosun.SecretLintMissing); len(missing) > 0 {
+			return errors.Errorf("%d secret reference(s) could not be resolved", len(missing))
+		}
+		if viper.GetBool(argSecretsLintStrict) && len(findings) > 0 {
+			return errors.Errorf("found %d problem(s) with secrets", len(findings))
+		}
+
+		return nil
+	},
+}, func(cmd *cobra.Command) {
+	cmd.Flags().Bool(argSecretsLintStrict, false, "Fail if there are any unused or inconsistent secrets, not just missing ones.")
+	cmd.Flags().StringSlice(argSecretsLintProviderPriority, []string{bosun.WorkspaceProviderName, bosun.SlotUnstable, bosun.SlotStable}, "Providers in priority order to get apps from.")
+})
+
+const (
+	argSecretsLintStrict           = "strict"
+	argSecretsLintProviderPriority = "providers"
+)
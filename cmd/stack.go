@@ -349,6 +349,101 @@ const (
 	argStackAppsColumns = "columns"
 )
 
+var stackHistoryCmd = addCommand(stackCmd, &cobra.Command{
+	Use:   "history [app]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Shows the deployment history of the current stack, optionally for a single app.",
+	Long: "Shows who deployed what and when. Use --diff with the sequence number of an entry to see what changed in that deployment, " +
+		"or with two sequence numbers to compare the app as deployed by those two entries.",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		b, _ := MustGetPlatform()
+		env := b.GetCurrentEnvironment()
+
+		stack := env.Stack()
+
+		history, err := stack.GetHistory()
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			history = history.ForApp(args[0])
+		}
+
+		diffSequences, _ := cmd.Flags().GetIntSlice(argStackHistoryDiff)
+		if len(diffSequences) > 0 {
+			return diffStackHistory(history, diffSequences)
+		}
+
+		limit := viper.GetInt(argStackHistoryLimit)
+		if limit > 0 && len(history) > limit {
+			history = history[len(history)-limit:]
+		}
+
+		return renderOutput(history)
+	},
+}, func(cmd *cobra.Command) {
+	cmd.Flags().Int(argStackHistoryLimit, 20, "Maximum number of recent entries to show; use 0 to show all.")
+	cmd.Flags().IntSlice(argStackHistoryDiff, nil, "Sequence number of an entry to diff against the version it replaced, or two sequence numbers to diff against each other.")
+})
+
+const (
+	argStackHistoryLimit = "limit"
+	argStackHistoryDiff  = "diff"
+)
+
+func diffStackHistory(history kube.StackHistory, sequences []int) error {
+
+	var left, right *kube.StackApp
+	var leftLabel, rightLabel string
+
+	switch len(sequences) {
+	case 1:
+		entry, ok := history.Get(sequences[0])
+		if !ok {
+			return errors.Errorf("no history entry with sequence %d", sequences[0])
+		}
+		left, right = entry.Previous, &entry.Current
+		leftLabel = "(not deployed)"
+		if left != nil {
+			leftLabel = fmt.Sprintf("%s before #%d", entry.App, entry.Sequence)
+		}
+		rightLabel = fmt.Sprintf("%s after #%d (%s)", entry.App, entry.Sequence, entry.Outcome)
+	case 2:
+		leftEntry, ok := history.Get(sequences[0])
+		if !ok {
+			return errors.Errorf("no history entry with sequence %d", sequences[0])
+		}
+		rightEntry, ok := history.Get(sequences[1])
+		if !ok {
+			return errors.Errorf("no history entry with sequence %d", sequences[1])
+		}
+		left, right = &leftEntry.Current, &rightEntry.Current
+		leftLabel = fmt.Sprintf("%s after #%d (%s)", leftEntry.App, leftEntry.Sequence, leftEntry.Outcome)
+		rightLabel = fmt.Sprintf("%s after #%d (%s)", rightEntry.App, rightEntry.Sequence, rightEntry.Outcome)
+	default:
+		return errors.New("--diff accepts one or two sequence numbers")
+	}
+
+	leftYaml, rightYaml := "", ""
+	if left != nil {
+		leftYaml, _ = yaml.MarshalString(left)
+	}
+	if right != nil {
+		rightYaml, _ = yaml.MarshalString(right)
+	}
+
+	color.Red("--- %s\n", leftLabel)
+	color.Green("+++ %s\n", rightLabel)
+	for _, line := range diffStrings(leftYaml, rightYaml) {
+		fmt.Println(renderDiff(line))
+	}
+
+	return nil
+}
+
 var stackResetCmd = addCommand(stackCmd, &cobra.Command{
 	Use:   "reset {stable|unstable}",
 	Args:  cobra.ExactArgs(1),
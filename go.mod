@@ -160,9 +160,10 @@ require (
 	github.com/spacemonkeygo/spacelog v0.0.0-20180420211403-2296661a0572 // indirect
 	github.com/spf13/afero v1.2.2 // indirect
 	github.com/spf13/cobra v0.0.5
+	github.com/spf13/pflag v1.0.3
 	github.com/spf13/viper v1.3.2
 	github.com/stevenle/topsort v0.0.0-20130922064739-8130c1d7596b
-	github.com/streadway/amqp v0.0.0-20190225234609-30f8ed68076e // indirect
+	github.com/streadway/amqp v0.0.0-20190225234609-30f8ed68076e
 	github.com/tidwall/pretty v0.0.0-20190325153808-1166b9ac2b65 // indirect
 	github.com/tmc/grpc-websocket-proxy v0.0.0-20190109142713-0ad062ec5ee5 // indirect
 	github.com/vbauerster/mpb/v4 v4.7.0
@@ -7,6 +7,7 @@ import (
 	"github.com/naveego/bosun/pkg/vault"
 	"github.com/naveego/bosun/pkg/yaml"
 	"io/ioutil"
+	"strings"
 )
 
 type VaultAction struct {
@@ -62,6 +63,33 @@ func (a *VaultAction) Execute(ctx ActionContext) error {
 	return err
 }
 
+func (a *VaultAction) DryRun(ctx ActionContext) (string, error) {
+	var vaultClient *vaultapi.Client
+	err := ctx.Provide(&vaultClient)
+	if err != nil {
+		return "", err
+	}
+
+	var layoutBytes []byte
+	if a.File != "" {
+		layoutBytes, err = ioutil.ReadFile(ctx.ResolvePath(a.File))
+		if err != nil {
+			return "", err
+		}
+	} else if a.Literal != "" {
+		layoutBytes = []byte(a.Literal)
+	} else {
+		layoutBytes, _ = yaml.Marshal(a.Layout)
+	}
+
+	vaultLayout, err := vault.LoadVaultLayoutFromBytes("action", layoutBytes, ctx.TemplateValues(), vaultClient)
+	if err != nil {
+		return "", err
+	}
+
+	return "apply vault layout (values are not shown):\n" + strings.Join(vaultLayout.DescribeWrites(), "\n"), nil
+}
+
 func (a *VaultAction) MakeSelfContained(ctx ActionContext) error {
 	if a.File != "" {
 
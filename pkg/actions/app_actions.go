@@ -18,6 +18,8 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 )
@@ -26,6 +28,21 @@ const (
 	ActionBeforeDeploy = "BeforeDeploy"
 	ActionAfterDeploy  = "AfterDeploy"
 	ActionManual       = "Manual"
+	// ActionPostCanary actions are run as health gates after a canary release
+	// has been deployed; if any of them fail the rollout is aborted.
+	ActionPostCanary = "PostCanary"
+	// ActionBeforeDelete and ActionAfterDelete actions are run around
+	// deleting the app's release.
+	ActionBeforeDelete = "BeforeDelete"
+	ActionAfterDelete  = "AfterDelete"
+	// ActionOnDeployFailure actions are run when a step of a deploy fails.
+	// Their errors are added to the error from the deploy rather than replacing it.
+	ActionOnDeployFailure = "OnDeployFailure"
+	// ActionBeforeRollback actions are run before the app's release is rolled back.
+	ActionBeforeRollback = "BeforeRollback"
+	// ActionAfterRecycle actions are run after the app's pods have been recycled
+	// and the new pods are ready.
+	ActionAfterRecycle = "AfterRecycle"
 )
 
 type AppAction struct {
@@ -35,6 +52,8 @@ type AppAction struct {
 	Where              core.EnvironmentRoles `yaml:"where,omitempty"`
 	WhereFilter        filter.MatchMapConfig `yaml:"whereFilter,omitempty" json:"where,omitempty"`
 	MaxAttempts        int                   `yaml:"maxAttempts,omitempty" json:"maxAttempts,omitempty"`
+	// Timeout limits each attempt. It defaults to 5 seconds, or to
+	// DefaultKubeActionTimeout if the action has a kube action.
 	Timeout            time.Duration         `yaml:"timeout,omitempty" json:"timeout,omitempty"`
 	Interval           time.Duration         `yaml:"interval,omitempty" json:"interval,omitempty"`
 	Vault              *VaultAction          `yaml:"vault,omitempty" json:"vault,omitempty"`
@@ -44,7 +63,11 @@ type AppAction struct {
 	DNSTest            *DNSTestAction          `yaml:"dnsTest,omitempty"`
 	Mongo              *MongoAction            `yaml:"mongo,omitempty" json:"mongo,omitempty"`
 	MongoAssert        *MongoAssertAction      `yaml:"mongoAssert,omitempty" json:"mongoAssert,omitempty"`
+	SQL                *SQLAction              `yaml:"sql,omitempty" json:"sql,omitempty"`
+	SQLAssert          *SQLAssertAction        `yaml:"sqlAssert,omitempty" json:"sqlAssert,omitempty"`
 	HTTP               *HTTPAction             `yaml:"http,omitempty" json:"http,omitempty"`
+	Queue              *QueueAction            `yaml:"queue,omitempty" json:"queue,omitempty"`
+	Kube               *KubeAction             `yaml:"kube,omitempty" json:"kube,omitempty"`
 	ExcludeFromRelease bool                    `yaml:"excludeFromRelease,omitempty" json:"excludeFromRelease,omitempty"`
 }
 
@@ -90,10 +113,7 @@ func (a *AppAction) Execute(ctx ActionContext) error {
 	if attempts == 0 {
 		attempts = 1
 	}
-	timeout := a.Timeout
-	if timeout == 0 {
-		timeout = 5 * time.Second
-	}
+	timeout := a.GetTimeout()
 	interval := a.Interval
 	if interval == 0 {
 		interval = 5 * time.Second
@@ -122,6 +142,10 @@ func (a *AppAction) Execute(ctx ActionContext) error {
 		return errors.Wrapf(err, "parsing rendered action:\n%s\n", renderedRawAction)
 	}
 
+	if ctx.GetParameters().DryRun {
+		return renderedAction.dryRun(ctx)
+	}
+
 	for i := 0; i < attempts; i++ {
 		if i > 0 && err != nil {
 			seconds := int(interval.Seconds())
@@ -165,6 +189,17 @@ func (a *AppAction) Execute(ctx ActionContext) error {
 
 }
 
+// GetTimeout returns the timeout for each attempt, applying the default if Timeout is not set.
+func (a *AppAction) GetTimeout() time.Duration {
+	if a.Timeout != 0 {
+		return a.Timeout
+	}
+	if a.Kube != nil {
+		return DefaultKubeActionTimeout
+	}
+	return 5 * time.Second
+}
+
 func (a *AppAction) execute(ctx ActionContext) error {
 	actions := a.GetActions()
 	if len(actions) == 0 {
@@ -211,6 +246,10 @@ func (a *AppAction) GetActions() []Action {
 }
 type ScriptAction string
 
+func (a *ScriptAction) DryRun(ctx ActionContext) (string, error) {
+	return fmt.Sprintf("run script:\n%s", string(*a)), nil
+}
+
 func (a *ScriptAction) Execute(ctx ActionContext) error {
 
 	script := *a
@@ -225,6 +264,10 @@ func (a *ScriptAction) Execute(ctx ActionContext) error {
 
 type BosunAction []string
 
+func (a BosunAction) DryRun(ctx ActionContext) (string, error) {
+	return fmt.Sprintf("run bosun %s", strings.Join(a.getArgs(ctx), " ")), nil
+}
+
 func (a BosunAction) Execute(ctx ActionContext) error {
 
 	exe, err := os.Executable()
@@ -232,16 +275,7 @@ func (a BosunAction) Execute(ctx ActionContext) error {
 		return err
 	}
 
-	var stepArgs []string
-	stepArgs = append(stepArgs, a...)
-	if ctx.GetParameters().Verbose {
-		stepArgs = append(stepArgs, "--verbose")
-	}
-	if ctx.GetParameters().DryRun {
-		stepArgs = append(stepArgs, "--dry-run")
-	}
-
-	stepArgs = append(stepArgs, "--clusters", ctx.GetStringValue(core.KeyCluster))
+	stepArgs := a.getArgs(ctx)
 
 	log := ctx.WithLogField("args", stepArgs).Log()
 	log.WithField("args", stepArgs).Info("Executing step")
@@ -255,10 +289,52 @@ func (a BosunAction) Execute(ctx ActionContext) error {
 	return nil
 }
 
+func (a BosunAction) getArgs(ctx ActionContext) []string {
+	var stepArgs []string
+	stepArgs = append(stepArgs, a...)
+	if ctx.GetParameters().Verbose {
+		stepArgs = append(stepArgs, "--verbose")
+	}
+	if ctx.GetParameters().DryRun {
+		stepArgs = append(stepArgs, "--dry-run")
+	}
+
+	stepArgs = append(stepArgs, "--clusters", ctx.GetStringValue(core.KeyCluster))
+	return stepArgs
+}
+
 type TestAction struct {
 	Exec *command.Command `yaml:"exec,omitempty" json:"exec,omitempty"`
 	HTTP string           `yaml:"http,omitempty" json:"http,omitempty"`
 	TCP  string           `yaml:"tcp,omitempty" json:"tcp,omitempty"`
+	// Assert and Capture apply to the response to the HTTP test.
+	Assert  *HTTPAssertions `yaml:"assert,omitempty" json:"assert,omitempty"`
+	Capture []HTTPCapture   `yaml:"capture,omitempty" json:"capture,omitempty"`
+}
+
+func (t *TestAction) DryRun(ctx ActionContext) (string, error) {
+	switch {
+	case t.Exec != nil:
+		return fmt.Sprintf("run command: %s", t.Exec.String()), nil
+	case t.HTTP != "":
+		target, err := templating.RenderTemplate(t.HTTP, ctx.TemplateValues())
+		if err != nil {
+			return "", err
+		}
+		description := fmt.Sprintf("send HTTP request: GET %s", target)
+		if t.Assert != nil || len(t.Capture) > 0 {
+			y, _ := yaml.MarshalString(map[string]interface{}{"assert": t.Assert, "capture": t.Capture})
+			description += "\n" + y
+		}
+		return description, nil
+	case t.TCP != "":
+		target, err := templating.RenderTemplate(t.TCP, ctx.TemplateValues())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("open TCP connection to %s", target), nil
+	}
+	return "", errors.New("test must have exec, http, or tcp element")
 }
 
 func (t *TestAction) Execute(ctx ActionContext) error {
@@ -282,11 +358,13 @@ func (t *TestAction) Execute(ctx ActionContext) error {
 
 		ctx.Log().WithField("url", target).Infof("Making HTTP GET request...")
 
+		start := time.Now()
 		resp, err := c.Get(target)
 		if err != nil {
 			return err
 		}
 		body, _ := ioutil.ReadAll(resp.Body)
+		latency := time.Since(start)
 		err = resp.Body.Close()
 		if err != nil {
 			return err
@@ -295,7 +373,12 @@ func (t *TestAction) Execute(ctx ActionContext) error {
 			return errors.Errorf("got non-success code %d - %s: %s", resp.StatusCode, resp.Status, string(body))
 		}
 
-		return nil
+		return checkHTTPResponse(ctx, &HTTPResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       body,
+			Latency:    latency,
+		}, t.Assert, t.Capture)
 	}
 
 	if t.TCP != "" {
@@ -319,6 +402,71 @@ type MongoAction struct {
 	Script         string           `yaml:"script,omitempty"`
 }
 
+func (a *MongoAction) DryRun(ctx ActionContext) (string, error) {
+	conn := a.Connection
+	if a.ConnectionName != "" {
+		var ok bool
+		conn, ok = ctx.GetValue(a.ConnectionName).(mongo.Connection)
+		if !ok {
+			return "", errors.Errorf("action had connectionName %q, but no connection with that name was found in the context", a.ConnectionName)
+		}
+	}
+
+	if a.Script != "" {
+		script, err := templating.RenderTemplate(a.Script, ctx.TemplateValues())
+		if err != nil {
+			return "", errors.Wrap(err, "render script")
+		}
+		return fmt.Sprintf("run mongo script against %s:\n%s", describeMongoConnection(conn), script), nil
+	}
+
+	databaseFilePath := ctx.ResolvePath(a.DatabaseFile)
+	dataFile, err := ioutil.ReadFile(databaseFilePath)
+	if err != nil {
+		return "", errors.Errorf("could not read file directly: %s", err)
+	}
+	db := mongo.Database{}
+	if err = yaml.Unmarshal(dataFile, &db); err != nil {
+		return "", errors.Errorf("could not read file as yaml '%s': %v", databaseFilePath, err)
+	}
+	if db.Name != "" {
+		conn.DBName = db.Name
+	}
+
+	lines := []string{fmt.Sprintf("import database file %s into %s", databaseFilePath, describeMongoConnection(conn))}
+	if a.RebuildDB {
+		lines = append(lines, "drop and rebuild the database")
+	}
+	var collectionNames []string
+	for name := range db.Collections {
+		collectionNames = append(collectionNames, name)
+	}
+	sort.Strings(collectionNames)
+	for _, name := range collectionNames {
+		collection := db.Collections[name]
+		line := fmt.Sprintf("collection %s:", name)
+		if collection.Drop {
+			line += " drop,"
+		}
+		if len(collection.Indexes) > 0 {
+			line += fmt.Sprintf(" ensure %d indexes,", len(collection.Indexes))
+		}
+		if collection.DataFile != "" {
+			line += fmt.Sprintf(" insert documents from %s,", filepath.Join(filepath.Dir(databaseFilePath), collection.DataFile))
+		}
+		lines = append(lines, strings.TrimSuffix(line, ","))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func describeMongoConnection(conn mongo.Connection) string {
+	host := conn.Host
+	if conn.KubePort.Forward {
+		host = fmt.Sprintf("service %s/%s (port forwarded)", conn.KubePort.Namespace, conn.KubePort.ServiceName)
+	}
+	return fmt.Sprintf("database %q on %s", conn.DBName, host)
+}
+
 func (a *MongoAction) Execute(ctx ActionContext) error {
 
 	if a.Script != "" {
@@ -395,6 +543,23 @@ type MongoAssertAction struct {
 	ExpectedResultCount int64                  `yaml:"expectedResultCount" json:"expectedResultCount"`
 }
 
+func (a *MongoAssertAction) DryRun(ctx ActionContext) (string, error) {
+	conn := a.Connection
+	if a.ConnectionName != "" {
+		var ok bool
+		conn, ok = ctx.GetValue(a.ConnectionName).(mongo.Connection)
+		if !ok {
+			return "", errors.Errorf("action had connectionName %q, but no connection with that name was found in the context", a.ConnectionName)
+		}
+	}
+	if a.Database != "" {
+		conn.DBName = a.Database
+	}
+	query, _ := json.Marshal(a.Query)
+	return fmt.Sprintf("count documents in collection %s of %s matching %s, expecting %d",
+		a.Collection, describeMongoConnection(conn), string(query), a.ExpectedResultCount), nil
+}
+
 func (a *MongoAssertAction) Execute(ctx ActionContext) error {
 
 	if a.ConnectionName != "" {
@@ -5,6 +5,7 @@ import (
 	"github.com/naveego/bosun/pkg/yaml"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"time"
 )
 
 var _ = Describe("AppActions", func() {
@@ -38,6 +39,12 @@ var _ = Describe("AppActions", func() {
 
 	})
 
+	It("should default the timeout to longer for kube actions", func() {
+		Expect((&AppAction{HTTP: &HTTPAction{}}).GetTimeout()).To(Equal(5 * time.Second))
+		Expect((&AppAction{Kube: &KubeAction{}}).GetTimeout()).To(Equal(DefaultKubeActionTimeout))
+		Expect((&AppAction{Kube: &KubeAction{}, Timeout: time.Minute}).GetTimeout()).To(Equal(time.Minute))
+	})
+
 	Describe("HTTPAction", func() {
 		It("should execute request", func() {
 
@@ -1,6 +1,7 @@
 package actions
 
 import (
+	"fmt"
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -13,6 +14,10 @@ type DNSTestAction struct {
 	DomainName  string `yaml:"domainName"`
 }
 
+func (a *DNSTestAction) DryRun(ctx ActionContext) (string, error) {
+	return fmt.Sprintf("check that %s resolves to the load balancer of service %s/%s", a.DomainName, a.Namespace, a.ServiceName), nil
+}
+
 func (a *DNSTestAction) Execute(ctx ActionContext) error {
 
 	if a.Namespace == "" {
@@ -0,0 +1,49 @@
+package actions
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/naveego/bosun/pkg/yaml"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// DryRunner is implemented by actions which can describe what they would do.
+// During a dry run, AppAction calls DryRun instead of Execute, and prints the description.
+// DryRun must not have side effects.
+type DryRunner interface {
+	DryRun(ctx ActionContext) (string, error)
+}
+
+// PrintDryRun prints the description of what something would have done if this wasn't a dry run.
+func PrintDryRun(title string, description string) {
+	color.Yellow("[dry run] %s\n", title)
+	for _, line := range strings.Split(strings.TrimRight(description, "\n"), "\n") {
+		fmt.Printf("    %s\n", line)
+	}
+}
+
+// dryRun prints what each of the actions in the rendered action would do.
+func (a *AppAction) dryRun(ctx ActionContext) error {
+	actions := a.GetActions()
+	if len(actions) == 0 {
+		return errors.New("no actions defined")
+	}
+
+	for _, action := range actions {
+		var description string
+		if dryRunner, ok := action.(DryRunner); ok {
+			var err error
+			description, err = dryRunner.DryRun(ctx)
+			if err != nil {
+				return errors.Wrapf(err, "%T dry run", action)
+			}
+		} else {
+			y, _ := yaml.MarshalString(action)
+			description = fmt.Sprintf("execute %T:\n%s", action, y)
+		}
+		PrintDryRun(fmt.Sprintf("action %s", a.Name), description)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package actions_test
+
+import (
+	. "github.com/naveego/bosun/pkg/actions"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("DryRun", func() {
+
+	It("should describe an HTTP request without sending it", func() {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+		}))
+		defer server.Close()
+
+		sut := &HTTPAction{
+			Method:  "post",
+			URL:     server.URL + "/orders",
+			Headers: map[string]string{"X-Tenant": "acme"},
+			Body:    map[string]interface{}{"count": 3},
+		}
+
+		description, err := sut.DryRun(NewTestActionContext())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(description).To(ContainSubstring("POST /orders HTTP/1.1"))
+		Expect(description).To(ContainSubstring("X-Tenant: acme"))
+		Expect(description).To(ContainSubstring(`{"count":3}`))
+		Expect(requests).To(Equal(0))
+	})
+
+	It("should redact credentials when describing an HTTP request", func() {
+		sut := &HTTPAction{
+			URL: "http://localhost/orders",
+			Headers: map[string]string{
+				"Authorization":       "Bearer secret-token",
+				"cookie":              "session=secret-session",
+				"Proxy-Authorization": "Basic secret-proxy",
+				"X-Tenant":            "acme",
+			},
+		}
+
+		description, err := sut.DryRun(NewTestActionContext())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(description).To(ContainSubstring("Authorization: <redacted>"))
+		Expect(description).To(ContainSubstring("Cookie: <redacted>"))
+		Expect(description).To(ContainSubstring("Proxy-Authorization: <redacted>"))
+		Expect(description).To(ContainSubstring("X-Tenant: acme"))
+		Expect(description).ToNot(ContainSubstring("secret"))
+	})
+
+	It("should redact credentials when describing a raw HTTP request", func() {
+		sut := &HTTPAction{
+			Raw: "GET http://localhost/orders HTTP/1.1\nHost: localhost\nAuthorization: Bearer secret-token\n\n",
+		}
+
+		description, err := sut.DryRun(NewTestActionContext())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(description).To(ContainSubstring("Authorization: <redacted>"))
+		Expect(description).ToNot(ContainSubstring("secret"))
+	})
+
+	It("should describe a kube readiness check", func() {
+		sut := &KubeAction{Kind: KubeKindDeployment, Name: "api", Namespace: "prod"}
+
+		Expect(sut.DryRun(NewTestActionContext())).To(Equal(`wait for deployment "api" in namespace prod to be ready`))
+	})
+})
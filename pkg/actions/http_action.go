@@ -4,11 +4,14 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/hashicorp/go-getter/helper/url"
 	"github.com/pkg/errors"
 	"io/ioutil"
 	"net/http"
+	"net/http/httputil"
 	"strings"
+	"time"
 )
 
 type HTTPAction struct {
@@ -18,19 +21,66 @@ type HTTPAction struct {
 	Body    map[string]interface{} `yaml:"body,omitempty" json:"body"`
 	Raw     string                 `yaml:"raw,omitempty" json:"raw,omitempty"`
 	OKCodes []int                  `yaml:"okCodes,omitempty,flow" json:"okCodes,omitempty"` // codes which should be treated as OK (passing). Defaults to [200, 201, 202, 204].
+	Assert  *HTTPAssertions        `yaml:"assert,omitempty" json:"assert,omitempty"`
+	Capture []HTTPCapture          `yaml:"capture,omitempty" json:"capture,omitempty"`
 }
 
 func (a *HTTPAction) Execute(ctx ActionContext) error {
 
+	req, err := a.makeRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "made request")
+	}
+
+	ctx.Log().Debugf("Dependencies returned %d - %s.", resp.StatusCode, resp.Status)
+
+	if len(a.OKCodes) == 0 {
+		a.OKCodes = []int{http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent}
+	}
+
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "read response body")
+	}
+	latency := time.Since(start)
+
+	isOK := false
+	for _, okCode := range a.OKCodes {
+		if resp.StatusCode == okCode {
+			isOK = true
+			break
+		}
+	}
+
+	if !isOK {
+		err = errors.Errorf("Response had non-success status code %d (OKCodes: %v): %s", resp.StatusCode, a.OKCodes, string(respBody))
+		return err
+	}
+
+	return checkHTTPResponse(ctx, &HTTPResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       respBody,
+		Latency:    latency,
+	}, a.Assert, a.Capture)
+}
+
+func (a *HTTPAction) makeRequest(ctx ActionContext) (*http.Request, error) {
 	var req *http.Request
 	var err error
 	if a.Raw == "" {
 		var bodyBytes []byte
-		var err error
 		if a.Body != nil {
 			bodyBytes, err = json.Marshal(a.Body)
 			if err != nil {
-				return errors.Wrap(err, "marshal body")
+				return nil, errors.Wrap(err, "marshal body")
 			}
 		}
 
@@ -41,7 +91,7 @@ func (a *HTTPAction) Execute(ctx ActionContext) error {
 
 		req, err = http.NewRequest(a.Method, a.URL, bodyBuffer)
 		if err != nil {
-			return errors.Wrap(err, "create req")
+			return nil, errors.Wrap(err, "create req")
 		}
 		for k, v := range a.Headers {
 			req.Header.Add(k, v)
@@ -55,40 +105,34 @@ func (a *HTTPAction) Execute(ctx ActionContext) error {
 
 		req, err = http.ReadRequest(r)
 		if err != nil {
-			return errors.Wrapf(err, "create req from raw input:\n%s", a.Raw)
+			return nil, errors.Wrapf(err, "create req from raw input:\n%s", a.Raw)
 		}
 		req.URL, err = url.Parse(req.RequestURI)
 		if err != nil {
-			return errors.Wrapf(err, "parse url %q", req.RequestURI)
+			return nil, errors.Wrapf(err, "parse url %q", req.RequestURI)
 		}
 		req.RequestURI = ""
 	}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return errors.Wrap(err, "made request")
-	}
+	return req, nil
+}
 
-	ctx.Log().Debugf("Dependencies returned %d - %s.", resp.StatusCode, resp.Status)
+// dryRunRedactedHeaders are the headers whose values are hidden when describing a request.
+var dryRunRedactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
 
-	if len(a.OKCodes) == 0 {
-		a.OKCodes = []int{http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent}
+func (a *HTTPAction) DryRun(ctx ActionContext) (string, error) {
+	req, err := a.makeRequest(ctx)
+	if err != nil {
+		return "", err
 	}
-
-	defer resp.Body.Close()
-	isOK := false
-	for _, okCode := range a.OKCodes {
-		if resp.StatusCode == okCode {
-			isOK = true
-			break
+	for _, header := range dryRunRedactedHeaders {
+		if _, ok := req.Header[header]; ok {
+			req.Header.Set(header, "<redacted>")
 		}
 	}
-
-	if !isOK {
-		respBody, _ := ioutil.ReadAll(resp.Body)
-		err = errors.Errorf("Response had non-success status code %d (OKCodes: %v): %s", resp.StatusCode, a.OKCodes, string(respBody))
-		return err
+	dump, err := httputil.DumpRequest(req, true)
+	if err != nil {
+		return "", errors.Wrap(err, "dump request")
 	}
-
-	return nil
+	return fmt.Sprintf("send HTTP request:\n%s", strings.TrimSpace(string(dump))), nil
 }
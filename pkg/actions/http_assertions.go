@@ -0,0 +1,293 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/naveego/bosun/pkg/values"
+	"github.com/oliveagle/jsonpath"
+	"github.com/pkg/errors"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HTTPAssertions are checks made against the response to an HTTP request.
+type HTTPAssertions struct {
+	// MaxLatency is the longest the request can take, including reading the body.
+	MaxLatency time.Duration `yaml:"maxLatency,omitempty" json:"maxLatency,omitempty"`
+	// Headers maps header names to regexes which the header value must match.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// Body is a list of regexes which the response body must match.
+	Body []string `yaml:"body,omitempty" json:"body,omitempty"`
+	// JSONPath maps JSONPath expressions (like "$.items[0].name") to the value
+	// the expression must produce when evaluated against the JSON response body.
+	JSONPath map[string]interface{} `yaml:"jsonPath,omitempty" json:"jsonPath,omitempty"`
+}
+
+// HTTPCapture copies a value from an HTTP response into the script values,
+// so that later steps can use it (as {{ .Values.<as> }}).
+type HTTPCapture struct {
+	// As is the path in the values to store the captured value at.
+	As string `yaml:"as" json:"as"`
+	// JSONPath is an expression evaluated against the JSON response body.
+	JSONPath string `yaml:"jsonPath,omitempty" json:"jsonPath,omitempty"`
+	// Header is the name of a response header to capture. If neither JSONPath nor Header
+	// is set the whole body is captured.
+	Header string `yaml:"header,omitempty" json:"header,omitempty"`
+	// Regex is applied to the header or body; the first capture group (or the whole match) is captured.
+	Regex string `yaml:"regex,omitempty" json:"regex,omitempty"`
+}
+
+// HTTPResponse is a response which has been fully read, for use with assertions and captures.
+type HTTPResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Latency    time.Duration
+
+	json    interface{}
+	jsonErr error
+	parsed  bool
+}
+
+func (r *HTTPResponse) getJSON() (interface{}, error) {
+	if !r.parsed {
+		r.parsed = true
+		r.jsonErr = json.Unmarshal(r.Body, &r.json)
+		if r.jsonErr != nil {
+			r.jsonErr = errors.Wrap(r.jsonErr, "response body is not JSON")
+		}
+	}
+	return r.json, r.jsonErr
+}
+
+func (r *HTTPResponse) lookupJSONPath(expression string) (interface{}, error) {
+	body, err := r.getJSON()
+	if err != nil {
+		return nil, err
+	}
+	value, err := jsonpath.JsonPathLookup(body, expression)
+	if err != nil {
+		return nil, errors.Wrapf(err, "evaluate %q", expression)
+	}
+	return value, nil
+}
+
+// Check returns an error describing every assertion which the response failed.
+func (a *HTTPAssertions) Check(resp *HTTPResponse) error {
+	if a == nil {
+		return nil
+	}
+
+	var failures []string
+
+	if a.MaxLatency > 0 && resp.Latency > a.MaxLatency {
+		failures = append(failures, fmt.Sprintf("request took %s, which is longer than the maximum of %s", resp.Latency, a.MaxLatency))
+	}
+
+	for _, name := range sortedKeys(a.Headers) {
+		pattern := a.Headers[name]
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("header %q: invalid regex %q: %s", name, pattern, err))
+			continue
+		}
+		actual, ok := resp.Header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("header %q: not present", name))
+			continue
+		}
+		if !re.MatchString(strings.Join(actual, ", ")) {
+			failures = append(failures, fmt.Sprintf("header %q: value %q does not match %q", name, strings.Join(actual, ", "), pattern))
+		}
+	}
+
+	for _, pattern := range a.Body {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("body: invalid regex %q: %s", pattern, err))
+			continue
+		}
+		if !re.Match(resp.Body) {
+			failures = append(failures, fmt.Sprintf("body: does not match %q", pattern))
+		}
+	}
+
+	var expressions []string
+	for expression := range a.JSONPath {
+		expressions = append(expressions, expression)
+	}
+	sort.Strings(expressions)
+	for _, expression := range expressions {
+		expected := a.JSONPath[expression]
+		actual, err := resp.lookupJSONPath(expression)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("jsonPath %s: %s", expression, err))
+			continue
+		}
+		if !jsonValuesEqual(expected, actual) {
+			failures = append(failures, fmt.Sprintf("jsonPath %s: expected %v but got %v", expression, formatJSONValue(expected), formatJSONValue(actual)))
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("response failed %d assertion(s):\n%s\nbody: %s", len(failures), strings.Join(failures, "\n"), string(resp.Body))
+	}
+
+	return nil
+}
+
+// Capture gets the value to capture from the response.
+func (c HTTPCapture) Capture(resp *HTTPResponse) (interface{}, error) {
+	var value interface{}
+	var err error
+
+	switch {
+	case c.JSONPath != "":
+		value, err = resp.lookupJSONPath(c.JSONPath)
+		if err != nil {
+			return nil, err
+		}
+	case c.Header != "":
+		headerValues, ok := resp.Header[http.CanonicalHeaderKey(c.Header)]
+		if !ok {
+			return nil, errors.Errorf("header %q not present", c.Header)
+		}
+		value = strings.Join(headerValues, ", ")
+	default:
+		value = string(resp.Body)
+	}
+
+	if c.Regex == "" {
+		return value, nil
+	}
+
+	re, err := regexp.Compile(c.Regex)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid regex %q", c.Regex)
+	}
+	match := re.FindStringSubmatch(fmt.Sprint(value))
+	switch len(match) {
+	case 0:
+		return nil, errors.Errorf("regex %q did not match %q", c.Regex, value)
+	case 1:
+		return match[0], nil
+	default:
+		return match[1], nil
+	}
+}
+
+// checkHTTPResponse applies the assertions to the response, then stores
+// the captured values in the script values in the context.
+func checkHTTPResponse(ctx ActionContext, resp *HTTPResponse, assertions *HTTPAssertions, captures []HTTPCapture) error {
+
+	if err := assertions.Check(resp); err != nil {
+		return err
+	}
+
+	if len(captures) == 0 {
+		return nil
+	}
+
+	valuesGetter, ok := ctx.(releaseValuesGetter)
+	if !ok || valuesGetter.GetReleaseValues() == nil {
+		return errors.New("response values can only be captured when the action is run as part of a script or test")
+	}
+	scriptValues := valuesGetter.GetReleaseValues()
+	if scriptValues.Values == nil {
+		scriptValues.Values = values.Values{}
+	}
+
+	for _, capture := range captures {
+		value, err := capture.Capture(resp)
+		if err != nil {
+			return errors.Wrapf(err, "capture %q", capture.As)
+		}
+		if err = scriptValues.Values.SetAtPath(capture.As, value); err != nil {
+			return errors.Wrapf(err, "capture %q", capture.As)
+		}
+		ctx.Log().WithField("value", value).Debugf("Captured %q from response.", capture.As)
+	}
+
+	return nil
+}
+
+type releaseValuesGetter interface {
+	GetReleaseValues() *values.PersistableValues
+}
+
+// jsonValuesEqual compares values after round-tripping them through JSON, so that
+// values parsed from YAML compare equal to values parsed from a JSON response.
+// A string expectation also matches a non-string value with the same string form.
+func jsonValuesEqual(expected, actual interface{}) bool {
+	normalizedExpected := normalizeJSONValue(expected)
+	normalizedActual := normalizeJSONValue(actual)
+	if reflect.DeepEqual(normalizedExpected, normalizedActual) {
+		return true
+	}
+	if s, ok := expected.(string); ok {
+		if _, actualIsString := actual.(string); !actualIsString && actual != nil {
+			return s == fmt.Sprint(actual)
+		}
+	}
+	return false
+}
+
+func normalizeJSONValue(value interface{}) interface{} {
+	j, err := json.Marshal(normalizeYAML(value))
+	if err != nil {
+		return value
+	}
+	var out interface{}
+	if err = json.Unmarshal(j, &out); err != nil {
+		return value
+	}
+	return out
+}
+
+func formatJSONValue(value interface{}) string {
+	j, err := json.Marshal(normalizeYAML(value))
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(j)
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// normalizeYAML converts the map[interface{}]interface{} values produced by
+// the YAML parser into map[string]interface{} values which can be marshalled to JSON.
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		out := map[string]interface{}{}
+		for k, item := range v {
+			out[fmt.Sprint(k)] = normalizeYAML(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := map[string]interface{}{}
+		for k, item := range v {
+			out[k] = normalizeYAML(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = normalizeYAML(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
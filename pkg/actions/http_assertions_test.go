@@ -0,0 +1,132 @@
+package actions_test
+
+import (
+	"fmt"
+	. "github.com/naveego/bosun/pkg/actions"
+	"github.com/naveego/bosun/pkg/yaml"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	yamlv2 "gopkg.in/yaml.v2"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("HTTPAssertions", func() {
+
+	response := func() *HTTPResponse {
+		return &HTTPResponse{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}, "Location": []string{"/orders/42"}},
+			Body:       []byte(`{"status": "ok", "items": [{"name": "first", "count": 3}]}`),
+			Latency:    100 * time.Millisecond,
+		}
+	}
+
+	It("should unmarshal from yaml", func() {
+		raw := `
+url: http://localhost/orders
+method: POST
+assert:
+  maxLatency: 500ms
+  headers:
+    content-type: json
+  body:
+    - '"status":\s*"ok"'
+  jsonPath:
+    $.items[0].count: 3
+capture:
+  - as: orderID
+    header: Location
+    regex: /orders/(\d+)
+`
+		var sut HTTPAction
+		Expect(yaml.UnmarshalString(raw, &sut)).To(Succeed())
+		Expect(sut.Assert.MaxLatency).To(Equal(500 * time.Millisecond))
+		Expect(sut.Assert.JSONPath).To(HaveKey("$.items[0].count"))
+		Expect(sut.Capture).To(HaveLen(1))
+	})
+
+	It("should pass when all assertions match", func() {
+		sut := &HTTPAssertions{
+			MaxLatency: time.Second,
+			Headers:    map[string]string{"content-type": "^application/json$"},
+			Body:       []string{`"status":\s*"ok"`},
+			JSONPath: map[string]interface{}{
+				"$.status":         "ok",
+				"$.items[0].count": 3,
+				"$.items[0].name":  "first",
+			},
+		}
+
+		Expect(sut.Check(response())).To(Succeed())
+	})
+
+	It("should report every failed assertion", func() {
+		sut := &HTTPAssertions{
+			MaxLatency: 10 * time.Millisecond,
+			Headers:    map[string]string{"X-Missing": "."},
+			Body:       []string{"error"},
+			JSONPath: map[string]interface{}{
+				"$.items[0].count": 4,
+			},
+		}
+
+		err := sut.Check(response())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("failed 4 assertion(s)"))
+		Expect(err.Error()).To(ContainSubstring("expected 4 but got 3"))
+	})
+
+	It("should compare object and array expectations unmarshalled from yaml", func() {
+		// yaml.v2 produces map[interface{}]interface{} for objects, which can't be marshalled to JSON as is
+		raw := `
+$.items[0]:
+  name: first
+  count: 3
+$.items:
+  - name: first
+    count: 3
+`
+		sut := &HTTPAssertions{}
+		Expect(yamlv2.Unmarshal([]byte(raw), &sut.JSONPath)).To(Succeed())
+		Expect(sut.JSONPath["$.items[0]"]).To(BeAssignableToTypeOf(map[interface{}]interface{}{}))
+		Expect(sut.Check(response())).To(Succeed())
+
+		mismatched := &HTTPAssertions{}
+		Expect(yamlv2.Unmarshal([]byte("$.items[0]: {name: second, count: 3}"), &mismatched.JSONPath)).To(Succeed())
+		err := mismatched.Check(response())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`expected {"count":3,"name":"second"}`))
+	})
+
+	It("should capture values", func() {
+		resp := response()
+
+		Expect(HTTPCapture{As: "status", JSONPath: "$.status"}.Capture(resp)).To(Equal("ok"))
+		Expect(HTTPCapture{As: "id", Header: "location", Regex: `/orders/(\d+)`}.Capture(resp)).To(Equal("42"))
+
+		_, err := HTTPCapture{As: "missing", Header: "X-Missing"}.Capture(resp)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should apply assertions to the response of an HTTP action", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprint(w, `{"status": "ok"}`)
+		}))
+		defer server.Close()
+
+		ctx := NewTestActionContext()
+
+		sut := HTTPAction{
+			Method: "GET",
+			URL:    server.URL,
+			Assert: &HTTPAssertions{JSONPath: map[string]interface{}{"$.status": "ok"}},
+		}
+		Expect(sut.Execute(ctx)).To(Succeed())
+
+		sut.Assert.JSONPath["$.status"] = "failed"
+		Expect(sut.Execute(ctx)).ToNot(Succeed())
+	})
+})
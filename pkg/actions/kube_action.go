@@ -0,0 +1,303 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/naveego/bosun/pkg/core"
+	"github.com/naveego/bosun/pkg/yaml"
+	"github.com/oliveagle/jsonpath"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	KubeKindDeployment  = "deployment"
+	KubeKindStatefulSet = "statefulset"
+	KubeKindJob         = "job"
+)
+
+// DefaultKubeActionTimeout is the timeout used for an action with a kube action
+// if the action doesn't set its own timeout.
+const DefaultKubeActionTimeout = 5 * time.Minute
+
+// KubeAction waits for kubernetes resources to be ready (for deployments and statefulsets)
+// or complete (for jobs), and optionally checks fields of the resources.
+// The action keeps checking until the resources are ready or the action's timeout expires;
+// the timeout defaults to DefaultKubeActionTimeout rather than the usual 5 seconds.
+type KubeAction struct {
+	// Kind is deployment, statefulset, or job.
+	Kind string `yaml:"kind" json:"kind"`
+	// Name selects a single resource by name.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Selector selects resources using a label selector, like "app=my-app".
+	Selector string `yaml:"selector,omitempty" json:"selector,omitempty"`
+	// Namespace defaults to the namespace of the app the action belongs to.
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	// Assert maps JSONPath expressions (like "$.spec.template.spec.containers[0].image")
+	// to the value the expression must produce for each selected resource.
+	Assert map[string]interface{} `yaml:"assert,omitempty" json:"assert,omitempty"`
+}
+
+func (a *KubeAction) Execute(ctx ActionContext) error {
+
+	if a.Name == "" && a.Selector == "" {
+		return errors.New("kube action must have a name or a selector")
+	}
+
+	if ctx.GetParameters().DryRun {
+		ctx.Log().Info("Skipping kube readiness check because this is a dry run.")
+		return nil
+	}
+
+	namespace := a.Namespace
+	if namespace == "" {
+		namespace = ctx.GetStringValue(core.KeyNamespace, "default")
+	}
+
+	var client *kubernetes.Clientset
+	if err := ctx.Provide(&client); err != nil {
+		return errors.Wrap(err, "get kube client")
+	}
+
+	log := ctx.Log().WithField("kind", a.Kind).WithField("namespace", namespace)
+
+	for {
+		err := a.check(client, namespace)
+		if err == nil {
+			log.Info("Resources are ready.")
+			return nil
+		}
+		if _, notReady := err.(kubeNotReadyError); !notReady {
+			return err
+		}
+
+		log.WithField("reason", err.Error()).Info("Waiting for resources to be ready...")
+
+		select {
+		case <-ctx.Ctx().Done():
+			return err
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+func (a *KubeAction) DryRun(ctx ActionContext) (string, error) {
+	namespace := a.Namespace
+	if namespace == "" {
+		namespace = ctx.GetStringValue(core.KeyNamespace, "default")
+	}
+	target := fmt.Sprintf("%s %q", a.Kind, a.Name)
+	if a.Name == "" {
+		target = fmt.Sprintf("each %s matching %q", a.Kind, a.Selector)
+	}
+	description := fmt.Sprintf("wait for %s in namespace %s to be ready", target, namespace)
+	if len(a.Assert) > 0 {
+		y, _ := yaml.MarshalString(a.Assert)
+		description += ", then check:\n" + y
+	}
+	return description, nil
+}
+
+// kubeNotReadyError means the resources aren't ready yet, but may become ready.
+type kubeNotReadyError struct {
+	reason string
+}
+
+func (k kubeNotReadyError) Error() string {
+	return k.reason
+}
+
+func notReady(format string, args ...interface{}) error {
+	return kubeNotReadyError{reason: fmt.Sprintf(format, args...)}
+}
+
+func (a *KubeAction) check(client *kubernetes.Clientset, namespace string) error {
+
+	listOptions := metav1.ListOptions{LabelSelector: a.Selector}
+	if a.Name != "" {
+		listOptions.FieldSelector = "metadata.name=" + a.Name
+	}
+
+	var resources []kubeResource
+
+	switch strings.ToLower(a.Kind) {
+	case KubeKindDeployment:
+		list, err := client.AppsV1().Deployments(namespace).List(listOptions)
+		if err != nil {
+			return errors.Wrap(err, "list deployments")
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			resources = append(resources, kubeResource{name: item.Name, object: item, readiness: getDeploymentReadiness(item)})
+		}
+	case KubeKindStatefulSet:
+		list, err := client.AppsV1().StatefulSets(namespace).List(listOptions)
+		if err != nil {
+			return errors.Wrap(err, "list statefulsets")
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			resources = append(resources, kubeResource{name: item.Name, object: item, readiness: getStatefulSetReadiness(item)})
+		}
+	case KubeKindJob:
+		list, err := client.BatchV1().Jobs(namespace).List(listOptions)
+		if err != nil {
+			return errors.Wrap(err, "list jobs")
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			resources = append(resources, kubeResource{name: item.Name, object: item, readiness: getJobReadiness(item)})
+		}
+	default:
+		return errors.Errorf("unsupported kind %q (supported kinds are %s, %s, and %s)", a.Kind, KubeKindDeployment, KubeKindStatefulSet, KubeKindJob)
+	}
+
+	if len(resources) == 0 {
+		if a.Name != "" {
+			return notReady("%s %q not found in namespace %q", a.Kind, a.Name, namespace)
+		}
+		return notReady("no %s matched selector %q in namespace %q", a.Kind, a.Selector, namespace)
+	}
+
+	var reasons []string
+	for _, resource := range resources {
+		if resource.readiness != nil {
+			if _, isNotReady := resource.readiness.(kubeNotReadyError); !isNotReady {
+				return errors.Wrapf(resource.readiness, "%s %q", a.Kind, resource.name)
+			}
+			reasons = append(reasons, fmt.Sprintf("%s %q: %s", a.Kind, resource.name, resource.readiness))
+			continue
+		}
+		if err := a.checkAssertions(resource); err != nil {
+			return err
+		}
+	}
+
+	if len(reasons) > 0 {
+		return notReady("%s", strings.Join(reasons, "; "))
+	}
+
+	return nil
+}
+
+type kubeResource struct {
+	name   string
+	object interface{}
+	// readiness is nil if the resource is ready, a kubeNotReadyError if it isn't ready yet,
+	// or another error if it will never be ready (like a failed job).
+	readiness error
+}
+
+func (a *KubeAction) checkAssertions(resource kubeResource) error {
+	if len(a.Assert) == 0 {
+		return nil
+	}
+
+	j, err := json.Marshal(resource.object)
+	if err != nil {
+		return err
+	}
+	var object interface{}
+	if err = json.Unmarshal(j, &object); err != nil {
+		return err
+	}
+
+	var expressions []string
+	for expression := range a.Assert {
+		expressions = append(expressions, expression)
+	}
+	sort.Strings(expressions)
+
+	var failures []string
+	for _, expression := range expressions {
+		expected := a.Assert[expression]
+		actual, lookupErr := jsonpath.JsonPathLookup(object, expression)
+		if lookupErr != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", expression, lookupErr))
+			continue
+		}
+		if !jsonValuesEqual(expected, actual) {
+			failures = append(failures, fmt.Sprintf("%s: expected %s but got %s", expression, formatJSONValue(expected), formatJSONValue(actual)))
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("%s %q failed %d assertion(s):\n%s", a.Kind, resource.name, len(failures), strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+// getDeploymentReadiness applies the same checks as `kubectl rollout status`.
+func getDeploymentReadiness(d *appsv1.Deployment) error {
+	if d.Generation > d.Status.ObservedGeneration {
+		return notReady("waiting for the update to be observed")
+	}
+	for _, condition := range d.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return errors.Errorf("rollout exceeded its progress deadline: %s", condition.Message)
+		}
+	}
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	if d.Status.UpdatedReplicas < replicas {
+		return notReady("%d of %d replicas have been updated", d.Status.UpdatedReplicas, replicas)
+	}
+	if d.Status.Replicas > d.Status.UpdatedReplicas {
+		return notReady("%d old replicas are pending termination", d.Status.Replicas-d.Status.UpdatedReplicas)
+	}
+	if d.Status.AvailableReplicas < d.Status.UpdatedReplicas {
+		return notReady("%d of %d updated replicas are available", d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+	}
+	return nil
+}
+
+func getStatefulSetReadiness(s *appsv1.StatefulSet) error {
+	if s.Generation > s.Status.ObservedGeneration {
+		return notReady("waiting for the update to be observed")
+	}
+	replicas := int32(1)
+	if s.Spec.Replicas != nil {
+		replicas = *s.Spec.Replicas
+	}
+	if s.Status.ReadyReplicas < replicas {
+		return notReady("%d of %d replicas are ready", s.Status.ReadyReplicas, replicas)
+	}
+	if s.Spec.UpdateStrategy.Type == appsv1.RollingUpdateStatefulSetStrategyType {
+		if s.Status.UpdatedReplicas < replicas {
+			return notReady("%d of %d replicas have been updated", s.Status.UpdatedReplicas, replicas)
+		}
+		if s.Status.CurrentRevision != s.Status.UpdateRevision {
+			return notReady("waiting for revision %s to replace %s", s.Status.UpdateRevision, s.Status.CurrentRevision)
+		}
+	}
+	return nil
+}
+
+func getJobReadiness(j *batchv1.Job) error {
+	for _, condition := range j.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return nil
+		case batchv1.JobFailed:
+			return errors.Errorf("job failed: %s", condition.Message)
+		}
+	}
+	completions := int32(1)
+	if j.Spec.Completions != nil {
+		completions = *j.Spec.Completions
+	}
+	return notReady("%d of %d completions succeeded (%d active, %d failed)", j.Status.Succeeded, completions, j.Status.Active, j.Status.Failed)
+}
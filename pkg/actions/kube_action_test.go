@@ -0,0 +1,127 @@
+package actions
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("KubeAction readiness", func() {
+
+	int32Ptr := func(i int32) *int32 { return &i }
+
+	// classify describes the result of a readiness check as ready, not ready or failed.
+	classify := func(err error) string {
+		if err == nil {
+			return "ready"
+		}
+		if _, ok := err.(kubeNotReadyError); ok {
+			return "not ready: " + err.Error()
+		}
+		return "failed: " + err.Error()
+	}
+
+	deployment := func(status appsv1.DeploymentStatus) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(3)},
+			Status:     status,
+		}
+	}
+
+	DescribeTable("getDeploymentReadiness",
+		func(d *appsv1.Deployment, expected string) {
+			Expect(classify(getDeploymentReadiness(d))).To(Equal(expected))
+		},
+		Entry("is ready when every replica is updated and available",
+			deployment(appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3}),
+			"ready"),
+		Entry("waits for the update to be observed",
+			deployment(appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3}),
+			"not ready: waiting for the update to be observed"),
+		Entry("fails when the progress deadline is exceeded",
+			deployment(appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 1, Conditions: []appsv1.DeploymentCondition{{
+				Type:    appsv1.DeploymentProgressing,
+				Status:  corev1.ConditionFalse,
+				Reason:  "ProgressDeadlineExceeded",
+				Message: `ReplicaSet "api-5d4f" has timed out progressing.`,
+			}}}),
+			`failed: rollout exceeded its progress deadline: ReplicaSet "api-5d4f" has timed out progressing.`),
+		Entry("waits for replicas to be updated",
+			deployment(appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 3}),
+			"not ready: 1 of 3 replicas have been updated"),
+		Entry("waits for old replicas pending termination",
+			deployment(appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 3, AvailableReplicas: 3}),
+			"not ready: 1 old replicas are pending termination"),
+		Entry("waits for updated replicas to become available",
+			deployment(appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 2}),
+			"not ready: 2 of 3 updated replicas are available"),
+		Entry("defaults to one replica",
+			&appsv1.Deployment{Status: appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}},
+			"ready"),
+	)
+
+	statefulSet := func(strategy appsv1.StatefulSetUpdateStrategyType, status appsv1.StatefulSetStatus) *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:       int32Ptr(3),
+				UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: strategy},
+			},
+			Status: status,
+		}
+	}
+
+	DescribeTable("getStatefulSetReadiness",
+		func(s *appsv1.StatefulSet, expected string) {
+			Expect(classify(getStatefulSetReadiness(s))).To(Equal(expected))
+		},
+		Entry("is ready when every replica is ready on the update revision",
+			statefulSet(appsv1.RollingUpdateStatefulSetStrategyType, appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 3, CurrentRevision: "api-2", UpdateRevision: "api-2"}),
+			"ready"),
+		Entry("waits for the update to be observed",
+			statefulSet(appsv1.RollingUpdateStatefulSetStrategyType, appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3, UpdatedReplicas: 3}),
+			"not ready: waiting for the update to be observed"),
+		Entry("waits for replicas to be ready",
+			statefulSet(appsv1.RollingUpdateStatefulSetStrategyType, appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 2, UpdatedReplicas: 3}),
+			"not ready: 2 of 3 replicas are ready"),
+		Entry("waits for replicas to be updated",
+			statefulSet(appsv1.RollingUpdateStatefulSetStrategyType, appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 2}),
+			"not ready: 2 of 3 replicas have been updated"),
+		Entry("waits for the update revision to replace the current revision",
+			statefulSet(appsv1.RollingUpdateStatefulSetStrategyType, appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 3, CurrentRevision: "api-1", UpdateRevision: "api-2"}),
+			"not ready: waiting for revision api-2 to replace api-1"),
+		Entry("ignores revisions when updates are applied on delete",
+			statefulSet(appsv1.OnDeleteStatefulSetStrategyType, appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, CurrentRevision: "api-1", UpdateRevision: "api-2"}),
+			"ready"),
+	)
+
+	job := func(status batchv1.JobStatus) *batchv1.Job {
+		return &batchv1.Job{
+			Spec:   batchv1.JobSpec{Completions: int32Ptr(2)},
+			Status: status,
+		}
+	}
+
+	DescribeTable("getJobReadiness",
+		func(j *batchv1.Job, expected string) {
+			Expect(classify(getJobReadiness(j))).To(Equal(expected))
+		},
+		Entry("is ready when the job is complete",
+			job(batchv1.JobStatus{Succeeded: 2, Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}}),
+			"ready"),
+		Entry("fails when the job failed",
+			job(batchv1.JobStatus{Failed: 3, Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "Job has reached the specified backoff limit"}}}),
+			"failed: job failed: Job has reached the specified backoff limit"),
+		Entry("ignores conditions which are not true",
+			job(batchv1.JobStatus{Active: 1, Succeeded: 1, Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionFalse}}}),
+			"not ready: 1 of 2 completions succeeded (1 active, 0 failed)"),
+		Entry("waits for the job to complete",
+			job(batchv1.JobStatus{Active: 1, Failed: 1}),
+			"not ready: 0 of 2 completions succeeded (1 active, 1 failed)"),
+	)
+})
@@ -0,0 +1,194 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/naveego/bosun/pkg/mq"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// QueueAction publishes messages to and consumes messages from a NATS, RabbitMQ or Kafka broker.
+// If both Publish and Consume are set the subscription is created before the messages
+// are published, so a message published by a downstream service in response can be consumed.
+type QueueAction struct {
+	Broker  mq.Config      `yaml:"broker" json:"broker"`
+	Publish []QueueMessage `yaml:"publish,omitempty" json:"publish,omitempty"`
+	Consume *QueueConsume  `yaml:"consume,omitempty" json:"consume,omitempty"`
+}
+
+// QueueMessage is a message to publish.
+type QueueMessage struct {
+	mq.Message `yaml:",inline" json:",inline"`
+	// Body is published as is if it is a string, otherwise it is marshalled to JSON.
+	Body interface{} `yaml:"body,omitempty" json:"body,omitempty"`
+}
+
+// QueueConsume defines the messages to wait for and the assertions they must pass.
+type QueueConsume struct {
+	// Subject is the NATS subject, the AMQP queue or routing key, or the Kafka topic to consume from.
+	Subject string `yaml:"subject" json:"subject"`
+	// Exchange is the AMQP exchange to consume from. If it is set, a temporary queue is
+	// bound to the exchange with the subject as the routing key, so that only messages
+	// published after the action starts are received and other consumers are unaffected.
+	// Otherwise messages are taken from the queue named by the subject, including
+	// messages which were already in the queue.
+	Exchange string `yaml:"exchange,omitempty" json:"exchange,omitempty"`
+	// Count is the number of matching messages to wait for. Defaults to 1.
+	Count int `yaml:"count,omitempty" json:"count,omitempty"`
+	// Timeout is how long to wait for the messages. Defaults to the timeout of the action.
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// FromBeginning makes a Kafka consumer read the topic from the earliest message.
+	FromBeginning bool `yaml:"fromBeginning,omitempty" json:"fromBeginning,omitempty"`
+	// Match filters the messages; messages which fail these assertions are skipped.
+	Match *HTTPAssertions `yaml:"match,omitempty" json:"match,omitempty"`
+	// Assert and Capture apply to each matching message. Message headers can be
+	// checked and captured in the same way as HTTP response headers.
+	Assert  *HTTPAssertions `yaml:"assert,omitempty" json:"assert,omitempty"`
+	Capture []HTTPCapture   `yaml:"capture,omitempty" json:"capture,omitempty"`
+}
+
+func (m QueueMessage) toMessage() (mq.Message, error) {
+	msg := m.Message
+	switch body := m.Body.(type) {
+	case nil:
+	case string:
+		msg.Body = []byte(body)
+	default:
+		b, err := json.Marshal(normalizeYAML(body))
+		if err != nil {
+			return msg, errors.Wrapf(err, "marshal body of message to %q", m.Subject)
+		}
+		msg.Body = b
+	}
+	return msg, nil
+}
+
+func (a *QueueAction) Execute(ctx ActionContext) error {
+
+	if len(a.Publish) == 0 && a.Consume == nil {
+		return errors.New("queue action must publish or consume messages")
+	}
+
+	broker, err := mq.NewBroker(a.Broker)
+	if err != nil {
+		return err
+	}
+
+	var subscription mq.Subscription
+	if a.Consume != nil {
+		if a.Consume.Subject == "" {
+			return errors.New("queue action consume must have a subject")
+		}
+		subscription, err = broker.Subscribe(ctx.Ctx(), a.Consume.Subject, mq.SubscribeOptions{FromBeginning: a.Consume.FromBeginning, Exchange: a.Consume.Exchange})
+		if err != nil {
+			return errors.Wrapf(err, "subscribe to %q", a.Consume.Subject)
+		}
+		defer subscription.Close()
+	}
+
+	for _, m := range a.Publish {
+		msg, msgErr := m.toMessage()
+		if msgErr != nil {
+			return msgErr
+		}
+		ctx.Log().Infof("Publishing message to %s %q...", a.Broker.Type, msg.Subject)
+		if err = broker.Publish(ctx.Ctx(), msg); err != nil {
+			return errors.Wrapf(err, "publish message to %q", msg.Subject)
+		}
+	}
+
+	if subscription == nil {
+		return nil
+	}
+
+	return a.Consume.consume(ctx, subscription)
+}
+
+func (c *QueueConsume) consume(ctx ActionContext, subscription mq.Subscription) error {
+	count := c.Count
+	if count <= 0 {
+		count = 1
+	}
+
+	waitCtx := ctx.Ctx()
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(waitCtx, c.Timeout)
+		defer cancel()
+	}
+
+	ctx.Log().Infof("Waiting for %d message(s) from %q...", count, c.Subject)
+
+	start := time.Now()
+	received, skipped := 0, 0
+	for received < count {
+		msg, err := subscription.Next(waitCtx)
+		if err != nil {
+			if waitCtx.Err() != nil {
+				return errors.Errorf("timed out after %s waiting for message %d of %d from %q (%d non-matching messages were skipped)",
+					time.Since(start).Round(time.Millisecond), received+1, count, c.Subject, skipped)
+			}
+			return errors.Wrapf(err, "consume from %q", c.Subject)
+		}
+
+		resp := messageToResponse(msg, time.Since(start))
+		if c.Match.Check(resp) != nil {
+			skipped++
+			ctx.Log().Debugf("Skipped non-matching message from %q: %s", msg.Subject, string(msg.Body))
+			continue
+		}
+
+		received++
+		ctx.Log().Debugf("Received message %d of %d from %q: %s", received, count, msg.Subject, string(msg.Body))
+		if err = checkHTTPResponse(ctx, resp, c.Assert, c.Capture); err != nil {
+			return errors.Wrapf(err, "message %d from %q", received, c.Subject)
+		}
+	}
+
+	return nil
+}
+
+// messageToResponse adapts a message so that it can be checked using the HTTP assertions and captures.
+// The latency is the time it took for the message to arrive.
+func messageToResponse(msg mq.Message, latency time.Duration) *HTTPResponse {
+	header := http.Header{}
+	for k, v := range msg.Headers {
+		header.Set(k, v)
+	}
+	if msg.Key != "" {
+		header.Set("Key", msg.Key)
+	}
+	return &HTTPResponse{
+		Header:  header,
+		Body:    msg.Body,
+		Latency: latency,
+	}
+}
+
+func (a *QueueAction) DryRun(ctx ActionContext) (string, error) {
+	lines := []string{fmt.Sprintf("connect to %s broker at %s", a.Broker.Type, a.Broker.URL)}
+	if a.Consume != nil && a.Consume.Exchange != "" {
+		lines = append(lines, fmt.Sprintf("subscribe to %q on exchange %q", a.Consume.Subject, a.Consume.Exchange))
+	} else if a.Consume != nil {
+		lines = append(lines, fmt.Sprintf("subscribe to %q", a.Consume.Subject))
+	}
+	for _, m := range a.Publish {
+		msg, err := m.toMessage()
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, fmt.Sprintf("publish to %q: %s", msg.Subject, string(msg.Body)))
+	}
+	if a.Consume != nil {
+		count := a.Consume.Count
+		if count <= 0 {
+			count = 1
+		}
+		lines = append(lines, fmt.Sprintf("wait for %d message(s) from %q", count, a.Consume.Subject))
+	}
+	return strings.Join(lines, "\n"), nil
+}
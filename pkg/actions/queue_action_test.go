@@ -0,0 +1,50 @@
+package actions_test
+
+import (
+	. "github.com/naveego/bosun/pkg/actions"
+	"github.com/naveego/bosun/pkg/yaml"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QueueAction", func() {
+
+	It("should unmarshal from yaml and describe itself", func() {
+		raw := `
+broker:
+  type: amqp
+  url: amqp://localhost:5672
+publish:
+  - subject: orders
+    headers:
+      type: created
+    body:
+      id: 1
+      items: [a, b]
+consume:
+  subject: order-events
+  count: 2
+  timeout: 10s
+  match:
+    jsonPath:
+      $.orderId: 1
+  assert:
+    body: [shipped]
+`
+		var sut QueueAction
+		Expect(yaml.UnmarshalString(raw, &sut)).To(Succeed())
+		Expect(sut.Publish).To(HaveLen(1))
+		Expect(sut.Publish[0].Headers).To(HaveKeyWithValue("type", "created"))
+		Expect(sut.Consume.Count).To(Equal(2))
+
+		description, err := sut.DryRun(NewTestActionContext())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(description).To(ContainSubstring(`publish to "orders": {"id":1,"items":["a","b"]}`))
+		Expect(description).To(ContainSubstring(`wait for 2 message(s) from "order-events"`))
+	})
+
+	It("should be found by GetActions", func() {
+		sut := &AppAction{Queue: &QueueAction{}}
+		Expect(sut.GetActions()).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,203 @@
+package actions
+
+import (
+	"fmt"
+	"github.com/naveego/bosun/pkg/sqldb"
+	"github.com/pkg/errors"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SQLAction runs SQL files or statements against a Postgres or MySQL database.
+type SQLAction struct {
+	// ConnectionName is the name of a connection defined in the context, like an E2E suite's sqlConnections.
+	ConnectionName string           `yaml:"connectionName,omitempty" json:"connectionName,omitempty"`
+	Connection     sqldb.Connection `yaml:"connection,omitempty" json:"connection,omitempty"`
+	// Files are run in order before the statements. Globs are expanded and the matches are
+	// sorted by name, so a directory of numbered migrations can be run using "migrations/*.sql".
+	Files []string `yaml:"files,omitempty" json:"files,omitempty"`
+	// Statements are run after the files.
+	Statements string `yaml:"statements,omitempty" json:"statements,omitempty"`
+}
+
+// SQLAssertAction runs a query and checks the rows it returns.
+type SQLAssertAction struct {
+	ConnectionName string           `yaml:"connectionName,omitempty" json:"connectionName,omitempty"`
+	Connection     sqldb.Connection `yaml:"connection,omitempty" json:"connection,omitempty"`
+	Query          string           `yaml:"query" json:"query"`
+	// ExpectedRowCount is the number of rows the query must return.
+	ExpectedRowCount *int `yaml:"expectedRowCount,omitempty" json:"expectedRowCount,omitempty"`
+	// ExpectedRows are compared to the rows the query returns, in order. Only the columns
+	// included in each expected row are checked. Values are compared as strings, and NULL
+	// values can be expected as null or "NULL".
+	ExpectedRows []map[string]interface{} `yaml:"expectedRows,omitempty" json:"expectedRows,omitempty"`
+}
+
+func getSQLConnection(ctx ActionContext, name string, conn sqldb.Connection) (sqldb.Connection, error) {
+	if name == "" {
+		return conn, nil
+	}
+	conn, ok := ctx.GetValue(name).(sqldb.Connection)
+	if !ok {
+		return conn, errors.Errorf("action had connectionName %q, but no connection with that name was found in the context", name)
+	}
+	return conn, nil
+}
+
+func (a *SQLAction) getFiles(ctx ActionContext) ([]string, error) {
+	var out []string
+	for _, pattern := range a.Files {
+		paths, err := filepath.Glob(ctx.ResolvePath(pattern))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid file pattern %q", pattern)
+		}
+		if len(paths) == 0 {
+			return nil, errors.Errorf("no files matched %q", pattern)
+		}
+		sort.Strings(paths)
+		out = append(out, paths...)
+	}
+	return out, nil
+}
+
+func (a *SQLAction) Execute(ctx ActionContext) error {
+
+	if len(a.Files) == 0 && a.Statements == "" {
+		return errors.New("sql action must have files or statements")
+	}
+
+	conn, err := getSQLConnection(ctx, a.ConnectionName, a.Connection)
+	if err != nil {
+		return err
+	}
+
+	files, err := a.getFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	pc, err := conn.Prepare(ctx.Log())
+	if err != nil {
+		return errors.Wrap(err, "prepare connection")
+	}
+	defer pc.CleanUp()
+
+	for _, file := range files {
+		ctx.Log().Infof("Running SQL file %s against %s...", file, conn)
+		out, execErr := pc.ExecFile(ctx.Ctx(), file)
+		if execErr != nil {
+			return errors.Wrapf(execErr, "run %s", file)
+		}
+		ctx.Log().Debug(out)
+	}
+
+	if a.Statements != "" {
+		ctx.Log().Infof("Running SQL statements against %s...", conn)
+		out, execErr := pc.Exec(ctx.Ctx(), a.Statements)
+		if execErr != nil {
+			return errors.Wrap(execErr, "run statements")
+		}
+		ctx.Log().Debug(out)
+	}
+
+	return nil
+}
+
+func (a *SQLAction) DryRun(ctx ActionContext) (string, error) {
+	conn, err := getSQLConnection(ctx, a.ConnectionName, a.Connection)
+	if err != nil {
+		return "", err
+	}
+	files, err := a.getFiles(ctx)
+	if err != nil {
+		return "", err
+	}
+	lines := []string{fmt.Sprintf("run SQL against %s:", conn)}
+	for _, file := range files {
+		lines = append(lines, fmt.Sprintf("file %s", file))
+	}
+	if a.Statements != "" {
+		lines = append(lines, a.Statements)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (a *SQLAssertAction) Execute(ctx ActionContext) error {
+
+	if a.Query == "" {
+		return errors.New("sqlAssert action must have a query")
+	}
+
+	conn, err := getSQLConnection(ctx, a.ConnectionName, a.Connection)
+	if err != nil {
+		return err
+	}
+
+	pc, err := conn.Prepare(ctx.Log())
+	if err != nil {
+		return errors.Wrap(err, "prepare connection")
+	}
+	defer pc.CleanUp()
+
+	rows, err := pc.Query(ctx.Ctx(), a.Query)
+	if err != nil {
+		return errors.Wrap(err, "run query")
+	}
+
+	return a.Check(rows)
+}
+
+// Check returns an error describing how the rows differ from the expected rows.
+func (a *SQLAssertAction) Check(rows sqldb.Rows) error {
+	if a.ExpectedRowCount != nil && len(rows) != *a.ExpectedRowCount {
+		return errors.Errorf("expected %d rows, but found %d", *a.ExpectedRowCount, len(rows))
+	}
+
+	if a.ExpectedRows == nil {
+		return nil
+	}
+
+	if len(rows) < len(a.ExpectedRows) {
+		return errors.Errorf("expected at least %d rows, but found %d", len(a.ExpectedRows), len(rows))
+	}
+
+	var failures []string
+	for i, expected := range a.ExpectedRows {
+		for column, expectedValue := range expected {
+			actual, ok := rows[i][column]
+			if !ok {
+				failures = append(failures, fmt.Sprintf("row %d: column %q not found", i, column))
+				continue
+			}
+			expectedString := fmt.Sprint(expectedValue)
+			if expectedValue == nil {
+				expectedString = "NULL"
+			}
+			if actual != expectedString {
+				failures = append(failures, fmt.Sprintf("row %d: column %q expected %q but got %q", i, column, expectedString, actual))
+			}
+		}
+	}
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return errors.Errorf("query results failed %d assertion(s):\n%s", len(failures), strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+func (a *SQLAssertAction) DryRun(ctx ActionContext) (string, error) {
+	conn, err := getSQLConnection(ctx, a.ConnectionName, a.Connection)
+	if err != nil {
+		return "", err
+	}
+	description := fmt.Sprintf("run query against %s:\n%s", conn, a.Query)
+	if a.ExpectedRowCount != nil {
+		description += fmt.Sprintf("\nexpecting %d rows", *a.ExpectedRowCount)
+	}
+	if len(a.ExpectedRows) > 0 {
+		description += fmt.Sprintf("\nexpecting the first %d rows to match", len(a.ExpectedRows))
+	}
+	return description, nil
+}
@@ -0,0 +1,93 @@
+package actions_test
+
+import (
+	. "github.com/naveego/bosun/pkg/actions"
+	"github.com/naveego/bosun/pkg/sqldb"
+	"github.com/naveego/bosun/pkg/yaml"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SQLAssertAction", func() {
+
+	rows := sqldb.Rows{
+		{"id": "1", "status": "shipped", "total": "9.50"},
+		{"id": "2", "status": "pending", "total": "NULL"},
+	}
+
+	It("should unmarshal from yaml", func() {
+		raw := `
+connectionName: orders
+query: select id, status from orders order by id
+expectedRowCount: 2
+expectedRows:
+  - id: 1
+    status: shipped
+`
+		var sut SQLAssertAction
+		Expect(yaml.UnmarshalString(raw, &sut)).To(Succeed())
+		Expect(*sut.ExpectedRowCount).To(Equal(2))
+		Expect(sut.Check(rows)).To(Succeed())
+	})
+
+	It("should report mismatched rows", func() {
+		count := 3
+		sut := &SQLAssertAction{
+			ExpectedRows: []map[string]interface{}{
+				{"id": 1, "total": 9.5},
+				{"status": "shipped", "missing": "x"},
+			},
+		}
+
+		err := sut.Check(rows)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("failed 3 assertion(s)"))
+
+		sut = &SQLAssertAction{ExpectedRowCount: &count}
+		Expect(sut.Check(rows)).ToNot(Succeed())
+	})
+
+	It("should compare NULL values", func() {
+		raw := `
+expectedRows:
+  - total: "9.50"
+  - total: null
+`
+		var sut SQLAssertAction
+		Expect(yaml.UnmarshalString(raw, &sut)).To(Succeed())
+		Expect(sut.Check(rows)).To(Succeed())
+
+		sut = SQLAssertAction{ExpectedRows: []map[string]interface{}{{}, {"total": "NULL"}}}
+		Expect(sut.Check(rows)).To(Succeed())
+
+		sut = SQLAssertAction{ExpectedRows: []map[string]interface{}{{"total": nil}}}
+		err := sut.Check(rows)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`row 0: column "total" expected "NULL" but got "9.50"`))
+
+		sut = SQLAssertAction{ExpectedRows: []map[string]interface{}{{}, {"total": "0"}}}
+		err = sut.Check(rows)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`row 1: column "total" expected "0" but got "NULL"`))
+	})
+
+	It("should report missing columns", func() {
+		sut := &SQLAssertAction{
+			ExpectedRows: []map[string]interface{}{
+				{"id": 1, "customer": "acme"},
+				{"id": 2, "shipped_at": nil},
+			},
+		}
+
+		err := sut.Check(rows)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("failed 2 assertion(s)"))
+		Expect(err.Error()).To(ContainSubstring(`row 0: column "customer" not found`))
+		Expect(err.Error()).To(ContainSubstring(`row 1: column "shipped_at" not found`))
+	})
+
+	It("should report too few rows", func() {
+		sut := &SQLAssertAction{ExpectedRows: []map[string]interface{}{{}, {}, {}}}
+		Expect(sut.Check(rows)).To(MatchError("expected at least 3 rows, but found 2"))
+	})
+})
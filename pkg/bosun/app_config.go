@@ -11,6 +11,7 @@ import (
 	"github.com/naveego/bosun/pkg/semver"
 	"github.com/naveego/bosun/pkg/values"
 	"github.com/pkg/errors"
+	"os"
 	"path/filepath"
 	"strings"
 )
@@ -29,18 +30,22 @@ type AppConfig struct {
 	HarborProject    string         `yaml:"harborProject,omitempty" json:"harborProject,omitempty"`
 	Version          semver.Version `yaml:"version,omitempty" json:"version,omitempty"`
 	// The location of a standard go version file for this app.
-	GoVersionFile string                    `yaml:"goVersionFile,omitempty" json:"goVersionFile,omitempty"`
-	Chart         string                    `yaml:"chart,omitempty" json:"chart,omitempty"`
-	ChartPath     string                    `yaml:"chartPath,omitempty" json:"chartPath,omitempty"`
-	RunCommand    []string                  `yaml:"runCommand,omitempty,flow" json:"runCommand,omitempty,flow"`
-	DependsOn     []Dependency              `yaml:"dependsOn,omitempty" json:"dependsOn,omitempty"`
-	Labels        filter.Labels             `yaml:"labels,omitempty" json:"labels,omitempty"`
-	Minikube      *AppMinikubeConfig        `yaml:"minikube,omitempty" json:"minikube,omitempty"`
-	Images        []AppImageConfig          `yaml:"images" json:"images"`
-	ValueMappings values.ValueMappings      `yaml:"valueMappings,omitempty"`
-	Values        values.ValueSetCollection `yaml:"values,omitempty" json:"values,omitempty"`
-	Scripts       []*script.Script          `yaml:"scripts,omitempty" json:"scripts,omitempty"`
-	Actions       []*actions.AppAction      `yaml:"actions,omitempty" json:"actions,omitempty"`
+	GoVersionFile string               `yaml:"goVersionFile,omitempty" json:"goVersionFile,omitempty"`
+	Chart         string               `yaml:"chart,omitempty" json:"chart,omitempty"`
+	ChartPath     string               `yaml:"chartPath,omitempty" json:"chartPath,omitempty"`
+	RunCommand    []string             `yaml:"runCommand,omitempty,flow" json:"runCommand,omitempty,flow"`
+	DependsOn     []Dependency         `yaml:"dependsOn,omitempty" json:"dependsOn,omitempty"`
+	Labels        filter.Labels        `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Minikube      *AppMinikubeConfig   `yaml:"minikube,omitempty" json:"minikube,omitempty"`
+	Images        []AppImageConfig     `yaml:"images" json:"images"`
+	ValueMappings values.ValueMappings `yaml:"valueMappings,omitempty"`
+	// ValuesSchema is the path (relative to the file containing the app config) to a JSON Schema
+	// which the resolved values must match. If not set, the chart's values.schema.json is used if it has one.
+	ValuesSchema string                    `yaml:"valuesSchema,omitempty" json:"valuesSchema,omitempty"`
+	Values       values.ValueSetCollection `yaml:"values,omitempty" json:"values,omitempty"`
+	Scripts      []*script.Script          `yaml:"scripts,omitempty" json:"scripts,omitempty"`
+	Actions      []*actions.AppAction      `yaml:"actions,omitempty" json:"actions,omitempty"`
+	Rollout      *AppRolloutConfig         `yaml:"rollout,omitempty" json:"rollout,omitempty"`
 	// Glob paths (relative to the file containing the app config)
 	// to files and folders  which should be included when the app is packaged for a release or a deployment.
 	// In particular, the path to the chart should be included.
@@ -165,6 +170,21 @@ type Dependency struct {
 	FromPath string         `yaml:"-" json:"fromPath,omitempty"`
 	Repo     string         `yaml:"repo,omitempty" json:"repo,omitempty"`
 	Version  semver.Version `yaml:"version,omitempty" json:"version,omitempty"`
+	// Range constrains the versions of the dependency this app is compatible with,
+	// like ">=2.3.0 <3.0.0". See semver.ParseRange for the syntax.
+	Range string `yaml:"range,omitempty" json:"range,omitempty"`
+}
+
+// GetRange returns the parsed Range, or nil if the dependency has no range.
+func (d Dependency) GetRange() (semver.Range, error) {
+	if d.Range == "" {
+		return nil, nil
+	}
+	r, err := semver.ParseRange(d.Range)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid range %q for dependency %q", d.Range, d.Name)
+	}
+	return r, nil
 }
 
 type Dependencies []Dependency
@@ -186,6 +206,22 @@ func (a *AppConfig) SetFromPath(fromPath string) {
 	}
 }
 
+// LoadValuesSchema returns the schema the app's values must match, or nil if the app doesn't have one.
+func (a *AppConfig) LoadValuesSchema() (*values.Schema, error) {
+	if a.ValuesSchema != "" {
+		return values.ReadSchemaFile(a.ResolveRelative(a.ValuesSchema))
+	}
+
+	if a.ChartPath != "" {
+		schemaPath := filepath.Join(a.ResolveRelative(a.ChartPath), "values.schema.json")
+		if _, err := os.Stat(schemaPath); err == nil {
+			return values.ReadSchemaFile(schemaPath)
+		}
+	}
+
+	return nil, nil
+}
+
 func (a *AppConfig) LoadChartValues() (values.ValueSet, error) {
 
 	if a.ChartPath != "" {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/fatih/color"
 	"github.com/google/go-github/v20/github"
+	"github.com/naveego/bosun/pkg/actions"
 	"github.com/naveego/bosun/pkg/command"
 	"github.com/naveego/bosun/pkg/core"
 	"github.com/naveego/bosun/pkg/filter"
@@ -80,6 +81,8 @@ type AppDeploy struct {
 	helmRelease *HelmRelease   `yaml:"-"`
 	labels      filter.Labels  `yaml:"-"`
 	StackApp    *kube.StackApp `yaml:"-"`
+	// hash of the values used by the last reconcile, used to detect drift
+	valuesHash string
 }
 
 func (a *AppDeploy) Clone() *AppDeploy {
@@ -100,6 +103,8 @@ func (a *AppDeploy) Clone() *AppDeploy {
 }
 
 // Chart gets the path to the chart, or the full name of the chart.
+// If the environment has a registry and the chart isn't available locally,
+// the chart is pulled from the registry.
 func (a *AppDeploy) Chart(ctx BosunContext) string {
 
 	// var chartHandle helm.ChartHandle
@@ -117,8 +122,13 @@ func (a *AppDeploy) Chart(ctx BosunContext) string {
 	//
 	// }
 
-	return filepath.Join(filepath.Dir(a.AppManifest.AppConfig.FromPath), a.AppManifest.AppConfig.ChartPath)
+	chartPath := filepath.Join(filepath.Dir(a.AppManifest.AppConfig.FromPath), a.AppManifest.AppConfig.ChartPath)
 
+	if chartRef := a.getRegistryChartRef(ctx, chartPath); chartRef != "" {
+		return chartRef
+	}
+
+	return chartPath
 }
 
 func NewAppDeploy(ctx BosunContext, settings DeploySettings, manifest *AppManifest) (*AppDeploy, error) {
@@ -283,7 +293,9 @@ func (a *AppDeploy) GetHelmList(filter string, namespace string) ([]*HelmRelease
 	return result, errors.Wrapf(err, "helm list result:\n%s", data)
 }
 
-func (a *AppDeploy) Reconcile(ctx BosunContext) error {
+// Reconcile deploys, updates or deletes the app to reach its desired state.
+// If any part of the reconciliation fails the OnDeployFailure actions are run.
+func (a *AppDeploy) Reconcile(ctx BosunContext) (err error) {
 	ctx = ctx.WithAppDeploy(a)
 	log := ctx.Log()
 
@@ -292,6 +304,18 @@ func (a *AppDeploy) Reconcile(ctx BosunContext) error {
 		return nil
 	}
 
+	// The OnDeployFailure actions run before the persisted values are cleaned up,
+	// so that they can use the values the app was being deployed with.
+	var persistedValues *values.PersistableValues
+	defer func() {
+		if err != nil {
+			err = a.runDeployFailureActions(ctx, err)
+		}
+		if persistedValues != nil {
+			persistedValues.Cleanup()
+		}
+	}()
+
 	resolvedValues, err := a.GetResolvedValues(ctx)
 	if err != nil {
 		return errors.Errorf("create values map for app %q: %s", a.AppManifest.Name, err)
@@ -299,6 +323,11 @@ func (a *AppDeploy) Reconcile(ctx BosunContext) error {
 
 	valuesYaml, _ := yaml.MarshalString(resolvedValues)
 
+	a.valuesHash, err = resolvedValues.Values.Hash()
+	if err != nil {
+		return errors.Wrapf(err, "hash values for app %q", a.AppManifest.Name)
+	}
+
 	if a.AppDeploySettings.DumpValuesOnly {
 		log.Infof("Running in preview only mode, here are the values that would have been used to deploy:")
 		fmt.Printf("# Namespace: %s\n", a.Namespace)
@@ -313,7 +342,7 @@ func (a *AppDeploy) Reconcile(ctx BosunContext) error {
 	if err != nil {
 		return errors.Errorf("persist values for app %q: %s", a.AppManifest.Name, err)
 	}
-	defer resolvedValues.Cleanup()
+	persistedValues = resolvedValues
 
 	ctx = ctx.WithPersistableValues(resolvedValues).(BosunContext)
 
@@ -408,22 +437,42 @@ func (a *AppDeploy) Reconcile(ctx BosunContext) error {
 
 	log.Debug("Executing plan...")
 
+	err = a.executePlan(ctx, plan)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("Plan executed.")
+
+	return nil
+}
+
+// executePlan executes the steps of the plan in order, stopping at the first step which fails.
+func (a *AppDeploy) executePlan(ctx BosunContext, plan Plan) error {
+	log := ctx.Log()
 	for _, step := range plan {
 		stepCtx := ctx.WithLog(log.WithField("step", step.Name))
 		stepCtx.Log().Infof("Executing step %s...", step.Name)
-		err = step.Action(stepCtx)
+		err := step.Action(stepCtx)
 		if err != nil {
 			stepCtx.Log().WithError(err).Error("Deploy failed.")
 			return errors.Wrapf(err, "step %q failed", step.Name)
 		}
 		stepCtx.Log().Infof("Completed step %s.", step.Name)
 	}
-
-	log.Debug("Plan executed.")
-
 	return nil
 }
 
+// runDeployFailureActions runs the OnDeployFailure actions after a deploy failed
+// with err. If they also fail their error is added to err rather than replacing it.
+func (a *AppDeploy) runDeployFailureActions(ctx BosunContext, err error) error {
+	if failureErr := a.ExecuteActionsForSchedule(ctx, actions.ActionOnDeployFailure); failureErr != nil {
+		ctx.Log().WithError(failureErr).Errorf("Error running %s actions.", actions.ActionOnDeployFailure)
+		return errors.Wrapf(err, "%s actions also failed (%s)", actions.ActionOnDeployFailure, failureErr)
+	}
+	return err
+}
+
 func (a *AppDeploy) ReportDeployment(ctx BosunContext) (cleanup func(error), err error) {
 
 	log := ctx.Log()
@@ -558,6 +607,20 @@ func (a *AppDeploy) diff(ctx BosunContext) (string, error) {
 }
 
 func (a *AppDeploy) Delete(ctx BosunContext) error {
+	ctx = ctx.WithAppDeploy(a)
+	if err := a.ExecuteActionsForSchedule(ctx, actions.ActionBeforeDelete); err != nil {
+		return err
+	}
+
+	if err := a.deleteRelease(ctx); err != nil {
+		return err
+	}
+
+	return a.ExecuteActionsForSchedule(ctx, actions.ActionAfterDelete)
+}
+
+// deleteRelease deletes the helm release of the app without running any actions.
+func (a *AppDeploy) deleteRelease(ctx BosunContext) error {
 	args := []string{"delete"}
 	if a.DesiredState.Status == workspace.StatusNotFound {
 		args = append(args, "--purge")
@@ -567,13 +630,26 @@ func (a *AppDeploy) Delete(ctx BosunContext) error {
 
 	out, err := command.NewShellExe("helm", args...).RunOut()
 	ctx.Log().Debug(out)
-	return errors.Wrapf(err, "delete using args %v", args)
+	if err != nil {
+		return errors.Wrapf(err, "delete using args %v", args)
+	}
+	return nil
 }
 
 func (a *AppDeploy) Rollback(ctx BosunContext) error {
+	return a.RollbackToRevision(ctx, a.helmRelease.Revision)
+}
+
+// RollbackToRevision rolls the helm release for the app back to the provided revision.
+func (a *AppDeploy) RollbackToRevision(ctx BosunContext, revision string) error {
+	ctx = ctx.WithAppDeploy(a)
+	if err := a.ExecuteActionsForSchedule(ctx, actions.ActionBeforeRollback); err != nil {
+		return err
+	}
+
 	args := []string{"rollback"}
-	args = append(args, a.AppManifest.Name, a.helmRelease.Revision)
-	// args = append(args, a.getNamespaceFlag(ctx)...)
+	args = append(args, a.AppManifest.Name, revision)
+	args = append(args, a.getNamespaceFlag(ctx)...)
 	args = append(args, a.getHelmDryRunArgs(ctx)...)
 
 	out, err := command.NewShellExe("helm", args...).RunOut()
@@ -621,7 +697,7 @@ func (a *AppDeploy) wrapActionError(ctx BosunContext, err error, args []string)
 		}
 
 		ctx.Log().Warnf("Chart produced invalid yaml. Error was %s", err)
-		ctx.Log().Info("Here is the yaml that was produced:\n%s", out)
+		ctx.Log().Infof("Here is the yaml that was produced:\n%s", out)
 		ctx.Log().Warnf("Yaml above dumped because chart produced invalid yaml. Error was %s", err)
 	}
 
@@ -867,7 +943,7 @@ func (a *AppDeploy) Recycle(ctx BosunContext) error {
 
 	ctx.Log().Info("Recycle complete.")
 
-	return nil
+	return a.ExecuteActionsForSchedule(ctx, actions.ActionAfterRecycle)
 }
 
 func getTagFromImage(image string) string {
@@ -892,6 +968,14 @@ func (a *AppDeploy) Validate(ctx BosunContext) []error {
 		errs = append(errs, errors.Errorf("chart %s@%s not found", a.AppConfig.Chart, a.AppConfig.Version))
 	}
 
+	schemaErrs, err := a.ValidateValues(ctx)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, schemaErr := range schemaErrs {
+		errs = append(errs, errors.Errorf("invalid value at %s", schemaErr))
+	}
+
 	if !a.AppConfig.BranchForRelease {
 		return errs
 	}
@@ -931,6 +1015,26 @@ func (a *AppDeploy) Validate(ctx BosunContext) []error {
 	return errs
 }
 
+// ValidateValues checks the fully resolved values for the app against the schema
+// declared by the app (or provided by its chart). If the app has no schema, no errors are returned.
+func (a *AppDeploy) ValidateValues(ctx BosunContext) (values.SchemaErrors, error) {
+
+	schema, err := a.AppConfig.LoadValuesSchema()
+	if err != nil {
+		return nil, errors.Wrapf(err, "load values schema for %s", a.Name)
+	}
+	if schema == nil {
+		return nil, nil
+	}
+
+	resolvedValues, err := a.GetResolvedValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.Validate(resolvedValues), nil
+}
+
 func checkImageExists(ctx BosunContext, name string) error {
 
 	cmd := exec.Command("docker", "pull", name)
@@ -0,0 +1,173 @@
+package bosun
+
+import (
+	"fmt"
+	"github.com/naveego/bosun/pkg/actions"
+	"github.com/naveego/bosun/pkg/core"
+	"github.com/naveego/bosun/pkg/filter"
+	"github.com/naveego/bosun/pkg/workspace"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var _ = Describe("AppDeploy actions", func() {
+
+	var dir string
+	var logPath string
+	var originalPath string
+	var ctx BosunContext
+	var sut *AppDeploy
+
+	// writeExe writes a fake executable which records how it was called.
+	writeExe := func(name string, script string) {
+		content := fmt.Sprintf("#!/bin/sh\necho \"%s $*\" >> %q\n%s\n", name, logPath, script)
+		Expect(ioutil.WriteFile(filepath.Join(dir, "bin", name), []byte(content), 0700)).To(Succeed())
+	}
+
+	action := func(schedule actions.ActionSchedule, script string) *actions.AppAction {
+		scriptAction := actions.ScriptAction(script)
+		return &actions.AppAction{
+			ConfigShared: core.ConfigShared{Name: string(schedule)},
+			When:         actions.ActionSchedules{schedule},
+			Script:       &scriptAction,
+		}
+	}
+
+	record := func(schedule actions.ActionSchedule) *actions.AppAction {
+		return action(schedule, fmt.Sprintf("echo %s >> %q", schedule, logPath))
+	}
+
+	calls := func() []string {
+		b, _ := ioutil.ReadFile(logPath)
+		return strings.Split(strings.TrimSpace(string(b)), "\n")
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "bosun-app-deploy-actions")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.Mkdir(filepath.Join(dir, "bin"), 0700)).To(Succeed())
+		logPath = filepath.Join(dir, "calls.log")
+
+		writeExe("helm", "")
+		writeExe("kubectl", `if [ "$1" = "get" ]; then echo "api-1:True;"; fi`)
+		originalPath = os.Getenv("PATH")
+		Expect(os.Setenv("PATH", filepath.Join(dir, "bin")+string(os.PathListSeparator)+originalPath)).To(Succeed())
+
+		ctx = NewTestBosunContext()
+		ctx.Bosun.params.NoEnvironment = true
+		ctx.exactMatchArgs = filter.MatchMapArgs{}
+
+		appConfig := &AppConfig{
+			Actions: []*actions.AppAction{
+				record(actions.ActionBeforeDelete),
+				record(actions.ActionAfterDelete),
+				record(actions.ActionBeforeRollback),
+				record(actions.ActionAfterRecycle),
+				record(actions.ActionOnDeployFailure),
+			},
+		}
+		appConfig.Name = "api"
+		bosunFile := filepath.Join(dir, "bosun.yaml")
+		Expect(ioutil.WriteFile(bosunFile, []byte("name: api\n"), 0600)).To(Succeed())
+		appConfig.SetFromPath(bosunFile)
+		sut = &AppDeploy{
+			Name:        "api",
+			AppConfig:   appConfig,
+			AppManifest: &AppManifest{AppMetadata: &AppMetadata{Name: "api"}, AppConfig: appConfig},
+		}
+		sut.FromPath = appConfig.FromPath
+		ctx = ctx.WithAppDeploy(sut)
+	})
+
+	AfterEach(func() {
+		_ = os.Setenv("PATH", originalPath)
+		_ = os.RemoveAll(dir)
+	})
+
+	It("runs the delete actions around deleting the release", func() {
+		Expect(sut.Delete(ctx)).To(Succeed())
+		Expect(calls()).To(Equal([]string{
+			"BeforeDelete",
+			"helm delete api --namespace default",
+			"AfterDelete",
+		}))
+	})
+
+	It("doesn't delete the release if a BeforeDelete action fails", func() {
+		sut.AppConfig.Actions[0] = action(actions.ActionBeforeDelete, "exit 1")
+		err := sut.Delete(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`BeforeDelete action "BeforeDelete"`))
+		Expect(logPath).ToNot(BeAnExistingFile())
+	})
+
+	It("runs the BeforeRollback actions before rolling back", func() {
+		Expect(sut.RollbackToRevision(ctx, "3")).To(Succeed())
+		Expect(calls()).To(Equal([]string{
+			"BeforeRollback",
+			"helm rollback api 3 --namespace default",
+		}))
+	})
+
+	It("runs the AfterRecycle actions once the pods are ready", func() {
+		Expect(sut.Recycle(ctx)).To(Succeed())
+		log := calls()
+		Expect(log).To(HaveLen(3))
+		Expect(log[0]).To(HavePrefix("kubectl delete"))
+		Expect(log[1]).To(HavePrefix("kubectl get"))
+		Expect(log[2]).To(Equal("AfterRecycle"))
+	})
+
+	It("stops at the first step which fails", func() {
+		stepErr := errors.New("upgrade failed")
+		err := sut.executePlan(ctx, Plan{
+			{Name: "Upgrade", Action: func(BosunContext) error { return stepErr }},
+			{Name: "Recycle", Action: func(BosunContext) error { panic("should not run") }},
+		})
+		Expect(err).To(MatchError(`step "Upgrade" failed: upgrade failed`))
+		Expect(logPath).ToNot(BeAnExistingFile())
+	})
+
+	It("runs the OnDeployFailure actions when the values can't be resolved", func() {
+		writeExe("helm", "exit 1")
+		sut.AppConfig.ChartPath = "chart"
+
+		err := sut.Reconcile(ctx)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`create values map for app "api"`))
+		Expect(calls()).To(Equal([]string{
+			"helm inspect values " + filepath.Join(dir, "chart") + " --version 0.0.0",
+			"OnDeployFailure",
+		}))
+	})
+
+	It("runs the OnDeployFailure actions when a step fails", func() {
+		stepErr := errors.New("upgrade failed")
+		err := sut.runDeployFailureActions(ctx, stepErr)
+		Expect(err).To(Equal(stepErr))
+		Expect(calls()).To(Equal([]string{"OnDeployFailure"}))
+	})
+
+	It("keeps the deploy error when an OnDeployFailure action fails", func() {
+		sut.AppConfig.Actions[4] = action(actions.ActionOnDeployFailure, "exit 1")
+		stepErr := errors.New("upgrade failed")
+		err := sut.runDeployFailureActions(ctx, stepErr)
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Cause(err)).To(Equal(stepErr))
+		Expect(err.Error()).To(HavePrefix("OnDeployFailure actions also failed"))
+		Expect(err.Error()).To(HaveSuffix("upgrade failed"))
+	})
+
+	It("doesn't run the OnDeployFailure actions when there is nothing to do", func() {
+		sut.DesiredState.Status = workspace.StatusUnchanged
+		Expect(sut.Reconcile(ctx)).To(Succeed())
+		Expect(logPath).ToNot(BeAnExistingFile())
+	})
+})
@@ -0,0 +1,154 @@
+package bosun
+
+import (
+	"github.com/naveego/bosun/pkg/actions"
+	"github.com/naveego/bosun/pkg/command"
+	"github.com/naveego/bosun/pkg/values"
+	"github.com/pkg/errors"
+	"math"
+)
+
+const (
+	RolloutStrategyDirect = "direct"
+	RolloutStrategyCanary = "canary"
+)
+
+// AppRolloutConfig controls how an upgrade to an app is rolled out.
+type AppRolloutConfig struct {
+	// Strategy is either "direct" (the default), which upgrades the release immediately,
+	// or "canary", which deploys a canary release first and only upgrades the release
+	// if all the app's PostCanary actions succeed. The canary is a separate helm release
+	// installed from the same chart, so the chart must derive the names of the resources
+	// it creates from .Release.Name, or they will collide with the main release.
+	Strategy string           `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+	Canary   *AppCanaryConfig `yaml:"canary,omitempty" json:"canary,omitempty"`
+}
+
+type AppCanaryConfig struct {
+	// The fraction of the app's replicas to run in the canary release. Defaults to 0.1.
+	ReplicaFraction float64 `yaml:"replicaFraction,omitempty" json:"replicaFraction,omitempty"`
+	// If set, the exact number of replicas to run in the canary release, instead of using ReplicaFraction.
+	Replicas int `yaml:"replicas,omitempty" json:"replicas,omitempty"`
+	// The path in the values to the replica count. Defaults to "replicaCount".
+	ReplicaCountPath string `yaml:"replicaCountPath,omitempty" json:"replicaCountPath,omitempty"`
+	// The suffix added to the release name to name the canary release. Defaults to "-canary".
+	ReleaseSuffix string `yaml:"releaseSuffix,omitempty" json:"releaseSuffix,omitempty"`
+}
+
+func (r *AppRolloutConfig) IsCanary() bool {
+	return r != nil && r.Strategy == RolloutStrategyCanary
+}
+
+func (r *AppRolloutConfig) GetCanaryConfig() AppCanaryConfig {
+	var out AppCanaryConfig
+	if r != nil && r.Canary != nil {
+		out = *r.Canary
+	}
+	if out.ReplicaFraction <= 0 {
+		out.ReplicaFraction = 0.1
+	}
+	if out.ReplicaCountPath == "" {
+		out.ReplicaCountPath = "replicaCount"
+	}
+	if out.ReleaseSuffix == "" {
+		out.ReleaseSuffix = "-canary"
+	}
+	return out
+}
+
+// GetCanaryReplicas returns the number of replicas the canary release should run,
+// based on the replica count in the values. The canary always gets at least one replica.
+func (c AppCanaryConfig) GetCanaryReplicas(v values.Values) int {
+	if c.Replicas > 0 {
+		return c.Replicas
+	}
+
+	replicas := 1
+	if raw, err := v.GetAtPath(c.ReplicaCountPath); err == nil {
+		switch n := raw.(type) {
+		case int:
+			replicas = n
+		case int64:
+			replicas = int(n)
+		case float64:
+			replicas = int(n)
+		}
+	}
+
+	canaryReplicas := int(math.Ceil(float64(replicas) * c.ReplicaFraction))
+	if canaryReplicas < 1 {
+		canaryReplicas = 1
+	}
+	return canaryReplicas
+}
+
+// CanaryUpgrade deploys a canary release of the app with a fraction of the replicas,
+// runs the app's PostCanary actions as health gates, and then either promotes the
+// rollout by upgrading the main release or aborts it. The canary release is removed
+// in both cases.
+// The canary release is named after the app with the ReleaseSuffix appended and uses
+// the app's chart, so charts which don't name their resources after the release
+// can't be rolled out this way.
+func (a *AppDeploy) CanaryUpgrade(ctx BosunContext) error {
+	canary := a.AppConfig.Rollout.GetCanaryConfig()
+	canaryName := a.AppManifest.Name + canary.ReleaseSuffix
+	log := ctx.Log().WithField("canary", canaryName)
+
+	canaryValues := &values.PersistableValues{
+		Attribution: ctx.Values.Attribution,
+		Values:      ctx.Values.Values.Clone(),
+	}
+	replicas := canary.GetCanaryReplicas(ctx.Values.Values)
+	if err := canaryValues.Values.SetAtPath(canary.ReplicaCountPath, replicas); err != nil {
+		return errors.Wrapf(err, "set canary replica count at %q", canary.ReplicaCountPath)
+	}
+	if _, err := canaryValues.PersistValues(); err != nil {
+		return errors.Wrap(err, "persist canary values")
+	}
+	defer canaryValues.Cleanup()
+
+	canaryCtx := ctx.WithPersistableValues(canaryValues).(BosunContext)
+
+	log.Infof("Deploying canary release with %d replicas...", replicas)
+
+	args := append([]string{"upgrade", canaryName, "--install", "--history-max", "1", a.Chart(ctx)}, a.makeHelmArgs(canaryCtx)...)
+	out, err := command.NewShellExe("helm", args...).RunOut()
+	ctx.Log().Debug(out)
+	if err != nil {
+		a.deleteCanary(ctx, canaryName)
+		return errors.Wrapf(a.wrapActionError(canaryCtx, err, args), "deploy canary using args %v", args)
+	}
+
+	gates := a.GetActionsForSchedule(ctx, actions.ActionPostCanary)
+	if len(gates) == 0 {
+		log.Warnf("App has a canary rollout strategy but no %s actions; the canary will be promoted without any health gates.", actions.ActionPostCanary)
+	}
+
+	for _, gate := range gates {
+		log.Infof("Running canary health gate %q...", gate.Name)
+		gateErr := gate.Execute(canaryCtx)
+		if gateErr != nil {
+			log.WithError(gateErr).Errorf("Canary health gate %q failed, aborting rollout.", gate.Name)
+			a.deleteCanary(ctx, canaryName)
+			return errors.Wrapf(gateErr, "canary health gate %q failed, rollout aborted", gate.Name)
+		}
+	}
+
+	log.Info("Canary health gates passed, promoting rollout...")
+
+	err = a.Upgrade(ctx)
+
+	a.deleteCanary(ctx, canaryName)
+
+	return err
+}
+
+func (a *AppDeploy) deleteCanary(ctx BosunContext, canaryName string) {
+	args := append([]string{"delete", canaryName}, a.getNamespaceFlag(ctx)...)
+	args = append(args, a.getHelmDryRunArgs(ctx)...)
+	out, err := command.NewShellExe("helm", args...).RunOut()
+	ctx.Log().Debug(out)
+	if err != nil {
+		ctx.Log().WithError(err).Warnf("Could not delete canary release %q, you may need to delete it manually.", canaryName)
+	}
+}
@@ -0,0 +1,147 @@
+package bosun
+
+import (
+	"fmt"
+	"github.com/naveego/bosun/pkg/actions"
+	"github.com/naveego/bosun/pkg/core"
+	"github.com/naveego/bosun/pkg/filter"
+	"github.com/naveego/bosun/pkg/values"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var _ = Describe("AppDeploy canary", func() {
+
+	DescribeTable("GetCanaryReplicas",
+		func(config *AppRolloutConfig, v values.Values, expected int) {
+			Expect(config.GetCanaryConfig().GetCanaryReplicas(v)).To(Equal(expected))
+		},
+		Entry("defaults to a tenth of the replicas, rounded up", nil, values.Values{"replicaCount": 4}, 1),
+		Entry("rounds up a fraction of the replicas", &AppRolloutConfig{Canary: &AppCanaryConfig{ReplicaFraction: 0.25}}, values.Values{"replicaCount": 10}, 3),
+		Entry("reads int64 replica counts", &AppRolloutConfig{Canary: &AppCanaryConfig{ReplicaFraction: 0.5}}, values.Values{"replicaCount": int64(6)}, 3),
+		Entry("reads float64 replica counts", &AppRolloutConfig{Canary: &AppCanaryConfig{ReplicaFraction: 0.5}}, values.Values{"replicaCount": float64(8)}, 4),
+		Entry("reads the replica count from a custom path", &AppRolloutConfig{Canary: &AppCanaryConfig{ReplicaFraction: 0.5, ReplicaCountPath: "api.replicas"}}, values.Values{"api": values.Values{"replicas": 6}}, 3),
+		Entry("assumes one replica when the count is missing", nil, values.Values{}, 1),
+		Entry("assumes one replica when the count is not a number", nil, values.Values{"replicaCount": "many"}, 1),
+		Entry("runs at least one replica", &AppRolloutConfig{Canary: &AppCanaryConfig{ReplicaFraction: 0.1}}, values.Values{"replicaCount": 0}, 1),
+		Entry("uses an exact replica count", &AppRolloutConfig{Canary: &AppCanaryConfig{Replicas: 2}}, values.Values{"replicaCount": 40}, 2),
+	)
+
+	Describe("CanaryUpgrade", func() {
+
+		var dir string
+		var logPath string
+		var originalPath string
+		var ctx BosunContext
+		var sut *AppDeploy
+
+		calls := func() []string {
+			b, _ := ioutil.ReadFile(logPath)
+			return strings.Split(strings.TrimSpace(string(b)), "\n")
+		}
+
+		gate := func(script string) *actions.AppAction {
+			scriptAction := actions.ScriptAction(script)
+			return &actions.AppAction{
+				ConfigShared: core.ConfigShared{Name: "smoke test"},
+				When:         actions.ActionSchedules{actions.ActionPostCanary},
+				Script:       &scriptAction,
+			}
+		}
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "bosun-app-deploy-canary")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.Mkdir(filepath.Join(dir, "bin"), 0700)).To(Succeed())
+			logPath = filepath.Join(dir, "calls.log")
+
+			// the fake helm records the command and the replica count in the values file it was given
+			helm := fmt.Sprintf(`#!/bin/sh
+echo "helm $1 $2" >> %[1]q
+prev=""
+for arg in "$@"; do
+  if [ "$prev" = "-f" ]; then grep replicaCount "$arg" >> %[1]q; fi
+  prev="$arg"
+done
+`, logPath)
+			Expect(ioutil.WriteFile(filepath.Join(dir, "bin", "helm"), []byte(helm), 0700)).To(Succeed())
+			originalPath = os.Getenv("PATH")
+			Expect(os.Setenv("PATH", filepath.Join(dir, "bin")+string(os.PathListSeparator)+originalPath)).To(Succeed())
+
+			ctx = NewTestBosunContext()
+			ctx.Bosun.params.NoEnvironment = true
+			ctx.exactMatchArgs = filter.MatchMapArgs{}
+
+			appConfig := &AppConfig{Rollout: &AppRolloutConfig{Strategy: RolloutStrategyCanary}}
+			appConfig.Name = "api"
+			bosunFile := filepath.Join(dir, "bosun.yaml")
+			Expect(ioutil.WriteFile(bosunFile, []byte("name: api\n"), 0600)).To(Succeed())
+			appConfig.SetFromPath(bosunFile)
+			sut = &AppDeploy{
+				Name:        "api",
+				FromPath:    bosunFile,
+				AppConfig:   appConfig,
+				AppManifest: &AppManifest{AppMetadata: &AppMetadata{Name: "api"}, AppConfig: appConfig},
+			}
+
+			resolved := &values.PersistableValues{Values: values.Values{"replicaCount": 4}}
+			_, err = resolved.PersistValues()
+			Expect(err).ToNot(HaveOccurred())
+			ctx = ctx.WithAppDeploy(sut).WithPersistableValues(resolved).(BosunContext)
+		})
+
+		AfterEach(func() {
+			ctx.Values.Cleanup()
+			_ = os.Setenv("PATH", originalPath)
+			_ = os.RemoveAll(dir)
+		})
+
+		It("promotes the rollout when the health gates pass", func() {
+			sut.AppConfig.Actions = []*actions.AppAction{gate(fmt.Sprintf("echo gate >> %q", logPath))}
+
+			Expect(sut.CanaryUpgrade(ctx)).To(Succeed())
+
+			Expect(calls()).To(Equal([]string{
+				"helm upgrade api-canary",
+				"replicaCount: 1",
+				"gate",
+				"helm upgrade api",
+				"replicaCount: 4",
+				"helm delete api-canary",
+			}))
+		})
+
+		It("aborts the rollout and removes the canary when a health gate fails", func() {
+			sut.AppConfig.Actions = []*actions.AppAction{gate("exit 1")}
+
+			err := sut.CanaryUpgrade(ctx)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`canary health gate "smoke test" failed, rollout aborted`))
+			Expect(calls()).To(Equal([]string{
+				"helm upgrade api-canary",
+				"replicaCount: 1",
+				"helm delete api-canary",
+			}))
+		})
+
+		It("removes the canary when it can't be deployed", func() {
+			Expect(ioutil.WriteFile(filepath.Join(dir, "bin", "helm"), []byte(fmt.Sprintf("#!/bin/sh\necho \"helm $1 $2\" >> %q\n[ \"$1\" = \"delete\" ]\n", logPath)), 0700)).To(Succeed())
+
+			err := sut.CanaryUpgrade(ctx)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("deploy canary"))
+			Expect(calls()).To(Equal([]string{
+				"helm upgrade api-canary",
+				"helm delete api-canary",
+			}))
+		})
+	})
+})
@@ -3,6 +3,7 @@ package bosun
 import (
 	"github.com/naveego/bosun/pkg/actions"
 	"github.com/naveego/bosun/pkg/workspace"
+	"github.com/pkg/errors"
 )
 
 type Plan []PlanStep
@@ -70,18 +71,7 @@ func (a *AppDeploy) PlanReconciliation(ctx BosunContext) (Plan, error) {
 	}
 
 	if desired.Status == workspace.StatusDeployed {
-		for i := range a.AppManifest.AppConfig.Actions {
-			action := a.AppManifest.AppConfig.Actions[i]
-			if action.When.Contains(actions.ActionBeforeDeploy) && action.WhereFilter.Matches(ctx.GetMatchMapArgs()) {
-				steps = append(steps, PlanStep{
-					Name:        action.Name,
-					Description: action.Description,
-					Action: func(ctx BosunContext) error {
-						return action.Execute(ctx)
-					},
-				})
-			}
-		}
+		steps = append(steps, a.makeActionSteps(ctx, actions.ActionBeforeDeploy)...)
 	}
 
 	if needsInstall {
@@ -100,7 +90,13 @@ func (a *AppDeploy) PlanReconciliation(ctx BosunContext) (Plan, error) {
 		})
 	}
 
-	if needsUpgrade {
+	if needsUpgrade && a.AppConfig.Rollout.IsCanary() {
+		steps = append(steps, PlanStep{
+			Name:        "CanaryUpgrade",
+			Description: "Deploy a canary release, run the PostCanary health gates, then upgrade the existing release in kubernetes.",
+			Action:      a.CanaryUpgrade,
+		})
+	} else if needsUpgrade {
 		steps = append(steps, PlanStep{
 			Name:        "Upgrade",
 			Description: "Upgrade existing release in kubernetes.",
@@ -109,20 +105,48 @@ func (a *AppDeploy) PlanReconciliation(ctx BosunContext) (Plan, error) {
 	}
 
 	if desired.Status == workspace.StatusDeployed {
-		for i := range a.AppManifest.AppConfig.Actions {
-			action := a.AppManifest.AppConfig.Actions[i]
-			if action.When.Contains(actions.ActionAfterDeploy) && action.WhereFilter.Matches(ctx.GetMatchMapArgs()) {
-				steps = append(steps, PlanStep{
-					Name:        action.Name,
-					Description: action.Description,
-					Action: func(ctx BosunContext) error {
-						return action.Execute(ctx)
-					},
-				})
-			}
-		}
+		steps = append(steps, a.makeActionSteps(ctx, actions.ActionAfterDeploy)...)
 	}
 
 	return steps, nil
 
 }
+
+// GetActionsForSchedule returns the app's actions which should run on the
+// provided schedule in the current context.
+func (a *AppDeploy) GetActionsForSchedule(ctx BosunContext, schedule actions.ActionSchedule) []*actions.AppAction {
+	var out []*actions.AppAction
+	for _, action := range a.AppManifest.AppConfig.Actions {
+		if action.When.Contains(schedule) && action.WhereFilter.Matches(ctx.GetMatchMapArgs()) {
+			out = append(out, action)
+		}
+	}
+	return out
+}
+
+// ExecuteActionsForSchedule runs the app's actions for the schedule in order,
+// stopping at the first failure.
+func (a *AppDeploy) ExecuteActionsForSchedule(ctx BosunContext, schedule actions.ActionSchedule) error {
+	for _, action := range a.GetActionsForSchedule(ctx, schedule) {
+		ctx.Log().Infof("Running %s action %q...", schedule, action.Name)
+		if err := action.Execute(ctx); err != nil {
+			return errors.Wrapf(err, "%s action %q", schedule, action.Name)
+		}
+	}
+	return nil
+}
+
+func (a *AppDeploy) makeActionSteps(ctx BosunContext, schedule actions.ActionSchedule) []PlanStep {
+	var steps []PlanStep
+	for _, action := range a.GetActionsForSchedule(ctx, schedule) {
+		action := action
+		steps = append(steps, PlanStep{
+			Name:        action.Name,
+			Description: action.Description,
+			Action: func(ctx BosunContext) error {
+				return action.Execute(ctx)
+			},
+		})
+	}
+	return steps
+}
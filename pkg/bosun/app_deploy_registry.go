@@ -0,0 +1,131 @@
+package bosun
+
+import (
+	"fmt"
+	"github.com/naveego/bosun/pkg/environment"
+	"github.com/naveego/bosun/pkg/helm"
+	"github.com/naveego/bosun/pkg/values"
+	"os"
+	"strings"
+)
+
+const registryAttribution = "environment registry"
+
+// getEnvironmentRegistry returns the registry configured in the environment, or nil if there isn't one.
+func getEnvironmentRegistry(ctx BosunContext) *environment.RegistryConfig {
+	if ctx.GetParameters().NoEnvironment || (!ctx.HasEnvironment() && ctx.Bosun.env == nil) {
+		return nil
+	}
+	registry := ctx.Environment().Registry
+	if registry == nil || registry.Host == "" {
+		return nil
+	}
+	return registry
+}
+
+// rewriteImagesForRegistry replaces every reference to one of the images of the app in
+// the values with the name of the image in the registry, which is where PlatformPusher
+// loads the images from an offline bundle. Untagged references (like image.repository)
+// and references with a tag or digest are both rewritten. It returns the paths of the values it changed.
+func (a *AppDeploy) rewriteImagesForRegistry(registry environment.RegistryConfig, resolved *values.PersistableValues) []string {
+	images := map[string]string{}
+	for _, image := range a.AppConfig.GetImages() {
+		name := image.GetFullName()
+		images[name] = registry.ImageName(name)
+	}
+	if len(images) == 0 {
+		return nil
+	}
+
+	paths := rewriteImageValues(images, resolved.Values, "")
+	if resolved.Attribution != nil {
+		for _, path := range paths {
+			if !strings.Contains(path, "[") {
+				_ = resolved.Attribution.SetAtPath(path, registryAttribution)
+			}
+		}
+	}
+	return paths
+}
+
+// rewriteImageValues replaces, in place, the strings in value which refer to one of the keys of images.
+func rewriteImageValues(images map[string]string, value interface{}, path string) []string {
+	var paths []string
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch v := value.(type) {
+	case values.Values:
+		return rewriteImageValues(images, map[string]interface{}(v), path)
+	case map[string]interface{}:
+		for key, child := range v {
+			if s, ok := child.(string); ok {
+				if rewritten, ok := rewriteImageReference(images, s); ok {
+					v[key] = rewritten
+					paths = append(paths, join(key))
+				}
+				continue
+			}
+			paths = append(paths, rewriteImageValues(images, child, join(key))...)
+		}
+	case map[interface{}]interface{}:
+		for key, child := range v {
+			keyPath := join(fmt.Sprint(key))
+			if s, ok := child.(string); ok {
+				if rewritten, ok := rewriteImageReference(images, s); ok {
+					v[key] = rewritten
+					paths = append(paths, keyPath)
+				}
+				continue
+			}
+			paths = append(paths, rewriteImageValues(images, child, keyPath)...)
+		}
+	case []interface{}:
+		for i, child := range v {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if s, ok := child.(string); ok {
+				if rewritten, ok := rewriteImageReference(images, s); ok {
+					v[i] = rewritten
+					paths = append(paths, itemPath)
+				}
+				continue
+			}
+			paths = append(paths, rewriteImageValues(images, child, itemPath)...)
+		}
+	}
+	return paths
+}
+
+// rewriteImageReference returns the rewritten reference if s is one of the keys of images,
+// optionally followed by a tag or digest.
+func rewriteImageReference(images map[string]string, s string) (string, bool) {
+	if rewritten, ok := images[s]; ok {
+		return rewritten, true
+	}
+	for name, rewritten := range images {
+		if len(s) > len(name) && strings.HasPrefix(s, name) && (s[len(name)] == ':' || s[len(name)] == '@') {
+			return rewritten + s[len(name):], true
+		}
+	}
+	return "", false
+}
+
+// getRegistryChartRef returns the reference to the chart of the app in the registry of the
+// environment, where PlatformPusher loads the charts from an offline bundle. It returns ""
+// if the environment has no registry or the chart is available locally at chartPath.
+func (a *AppDeploy) getRegistryChartRef(ctx BosunContext, chartPath string) string {
+	registry := getEnvironmentRegistry(ctx)
+	if registry == nil || a.AppConfig.Chart == "" {
+		return ""
+	}
+	if a.AppConfig.ChartPath != "" {
+		if stat, err := os.Stat(chartPath); err == nil && stat.IsDir() {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s/%s", registry.ChartRepository(), helm.ChartHandle(a.AppConfig.Chart).GetChartName())
+}
@@ -0,0 +1,127 @@
+package bosun
+
+import (
+	"github.com/naveego/bosun/pkg/environment"
+	"github.com/naveego/bosun/pkg/values"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("AppDeploy registry", func() {
+
+	var dir string
+	var ctx BosunContext
+	var sut *AppDeploy
+	registry := environment.RegistryConfig{Host: "registry.local:5000", Project: "offline"}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "bosun-app-deploy-registry")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(dir, "charts", "api"), 0700)).To(Succeed())
+
+		ctx = NewTestBosunContext()
+
+		appConfig := &AppConfig{
+			Chart:     "helm.n5o.black/api",
+			ChartPath: "charts/api",
+			Images: []AppImageConfig{
+				{ImageName: "api", Repository: "docker.n5o.black"},
+				{ImageName: "api-migrations", Repository: "docker.n5o.black", ProjectName: "jobs"},
+			},
+		}
+		appConfig.Name = "api"
+		appConfig.SetFromPath(filepath.Join(dir, "bosun.yaml"))
+		sut = &AppDeploy{
+			Name:        "api",
+			AppConfig:   appConfig,
+			AppManifest: &AppManifest{AppConfig: appConfig},
+		}
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	It("rewrites references to the images of the app", func() {
+		resolved := &values.PersistableValues{
+			Values: values.Values{
+				"image": values.Values{"repository": "docker.n5o.black/private/api", "tag": "1.2.3"},
+				"migrations": map[interface{}]interface{}{
+					"image": "docker.n5o.black/jobs/api-migrations:1.2.3",
+				},
+				"sidecars": []interface{}{
+					"docker.n5o.black/private/api@sha256:abc",
+					map[string]interface{}{"repository": "docker.n5o.black/private/api"},
+				},
+				"mongo":   values.Values{"repository": "mongo", "tag": "3.6"},
+				"similar": "docker.n5o.black/private/api-gateway:1.0.0",
+			},
+			Attribution: values.Values{
+				"image": values.Values{"repository": "chart values file", "tag": "bosun file"},
+			},
+		}
+
+		paths := sut.rewriteImagesForRegistry(registry, resolved)
+
+		Expect(paths).To(ConsistOf(
+			"image.repository",
+			"migrations.image",
+			"sidecars[0]",
+			"sidecars[1].repository",
+		))
+		Expect(resolved.Values).To(Equal(values.Values{
+			"image": values.Values{"repository": "registry.local:5000/offline/private/api", "tag": "1.2.3"},
+			"migrations": map[interface{}]interface{}{
+				"image": "registry.local:5000/offline/jobs/api-migrations:1.2.3",
+			},
+			"sidecars": []interface{}{
+				"registry.local:5000/offline/private/api@sha256:abc",
+				map[string]interface{}{"repository": "registry.local:5000/offline/private/api"},
+			},
+			"mongo":   values.Values{"repository": "mongo", "tag": "3.6"},
+			"similar": "docker.n5o.black/private/api-gateway:1.0.0",
+		}))
+		Expect(resolved.Attribution.GetAtPath("image.repository")).To(Equal(registryAttribution))
+		Expect(resolved.Attribution.GetAtPath("image.tag")).To(Equal("bosun file"))
+	})
+
+	It("does nothing if the app has no images", func() {
+		sut.AppConfig.Images = nil
+		resolved := &values.PersistableValues{
+			Values: values.Values{"image": values.Values{"repository": "docker.n5o.black/private/api"}},
+		}
+		Expect(sut.rewriteImagesForRegistry(registry, resolved)).To(BeEmpty())
+		Expect(resolved.Values).To(Equal(values.Values{"image": values.Values{"repository": "docker.n5o.black/private/api"}}))
+	})
+
+	It("uses the local chart if the environment has no registry", func() {
+		Expect(sut.Chart(ctx)).To(Equal(filepath.Join(dir, "charts", "api")))
+	})
+
+	It("uses the local chart if it exists", func() {
+		ctx._env.Registry = &registry
+		Expect(sut.Chart(ctx)).To(Equal(filepath.Join(dir, "charts", "api")))
+	})
+
+	It("pulls the chart from the registry if it isn't available locally", func() {
+		ctx._env.Registry = &registry
+		Expect(os.RemoveAll(filepath.Join(dir, "charts"))).To(Succeed())
+		Expect(sut.Chart(ctx)).To(Equal("oci://registry.local:5000/offline/charts/api"))
+	})
+
+	It("pulls the chart from the registry if the app has no chart path", func() {
+		ctx._env.Registry = &registry
+		sut.AppConfig.ChartPath = ""
+		Expect(sut.Chart(ctx)).To(Equal("oci://registry.local:5000/offline/charts/api"))
+	})
+
+	It("ignores a registry without a host", func() {
+		ctx._env.Registry = &environment.RegistryConfig{Project: "offline"}
+		sut.AppConfig.ChartPath = ""
+		Expect(sut.Chart(ctx)).To(Equal(dir))
+	})
+})
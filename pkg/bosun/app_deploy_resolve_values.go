@@ -9,85 +9,46 @@ import (
 
 // GetResolvedValues handles loading and merging all values needed for the
 // deployment of the app, including reading the default helm chart values,
-// loading any values files, and resolving any dynamic values.
+// loading any values files, and resolving any dynamic values. If the environment
+// has a registry, references to the app's images are rewritten to use it.
 func (a *AppDeploy) GetResolvedValues(ctx BosunContext) (*values.PersistableValues, error) {
 
-	matchArgs := ctx.GetMatchMapArgs()
-	bosunValues := values.Values{}
-	for k, v := range matchArgs {
-		bosunValues[k] = v
-	}
-
-	resolvedValues := values.NewValueSet().WithValues(
-		values.ValueSet{
-			Source: "bosun context",
-			Static: bosunValues,
-		})
-
-	// Make environment values available
-	if err := resolvedValues.Static.AddEnvAsPath(core.EnvPrefix, core.EnvAppVersion, a.AppManifest.Version); err != nil {
-		return nil, err
-	}
-	if err := resolvedValues.Static.AddEnvAsPath(core.EnvPrefix, core.EnvAppBranch, a.AppManifest.Branch); err != nil {
-		return nil, err
-	}
-	if err := resolvedValues.Static.AddEnvAsPath(core.EnvPrefix, core.EnvAppCommit, a.AppManifest.Hashes.Commit); err != nil {
+	resolvedValues, err := a.getContextValues(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	if chartValues, err := a.AppManifest.AppConfig.LoadChartValues(); err != nil {
-		return nil, errors.Wrapf(err, "load chart values")
-	} else {
-		resolvedValues = resolvedValues.WithValues(chartValues.WithSource("chart values file"))
-	}
-
-	if appConfigValues, err := ResolveValues(a.AppConfig, ctx); err != nil {
-		return nil, errors.Wrapf(err, "load value set from app config")
-	} else {
-		resolvedValues = resolvedValues.WithValues(appConfigValues.WithDefaultSource("bosun file"))
+	layers, err := a.getValuesLayers(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, v := range a.AppDeploySettings.ValueSets {
-		resolvedValues = resolvedValues.WithValues(v.WithDefaultSource("app deploy settings"))
+	resolved, err := a.resolveValuesLayers(ctx, resolvedValues, layers)
+	if err != nil {
+		return nil, err
 	}
 
-	if platformValues, err := ResolveValues(ctx.GetPlatform(), ctx); err != nil {
-		return nil, errors.Wrapf(err, "resolve platform values")
-	} else {
-		resolvedValues = resolvedValues.WithValues(platformValues.WithSource("platform overrides"))
+	if registry := getEnvironmentRegistry(ctx); registry != nil {
+		for _, path := range a.rewriteImagesForRegistry(*registry, resolved) {
+			ctx.Log().Debugf("Rewrote image at %q to use registry %s.", path, registry.Repository())
+		}
 	}
 
-	env := ctx.Environment()
-	if environmentValues, err := ResolveValues(env, ctx); err != nil {
-		return nil, errors.Wrapf(err, "resolve environment values")
-	} else {
-		resolvedValues = resolvedValues.WithValues(environmentValues.WithDefaultSource(fmt.Sprintf("%s environment", env.Name)))
-	}
+	return resolved, nil
+}
 
-	if environmentAppValues, err := ResolveValues(env.GetAppValueSetCollectionProvider(a.Name), ctx); err != nil {
-		return nil, errors.Wrapf(err, "resolve environment app values")
-	} else {
-		resolvedValues = resolvedValues.WithValues(environmentAppValues.WithDefaultSource(fmt.Sprintf("%s environment app value overrides", env.Name)))
-	}
+// resolveValuesLayers merges the layers over the context values, then applies
+// parameter overrides, dynamic values and value mappings.
+func (a *AppDeploy) resolveValuesLayers(ctx BosunContext, resolvedValues values.ValueSet, layers []valuesLayer) (*values.PersistableValues, error) {
 
-	cluster := env.Cluster()
-	if clusterValues, err := ResolveValues(cluster, ctx); err != nil {
-		return nil, errors.Wrapf(err, "resolve cluster values")
-	} else {
-		resolvedValues = resolvedValues.WithValues(clusterValues.WithDefaultSource(fmt.Sprintf("%s cluster", cluster.Name)))
-	}
+	var err error
 
-	stack := env.Stack()
-	if stackValues, err := ResolveValues(stack, ctx); err != nil {
-		return nil, errors.Wrapf(err, "resolve stack app values")
-	} else {
-		resolvedValues = resolvedValues.WithValues(stackValues.WithDefaultSource(fmt.Sprintf("%s stack overrides", cluster.Name)))
-	}
-
-	if stackAppValues, err := ResolveValues(stack.GetAppValueSetCollectionProvider(a.Name), ctx); err != nil {
-		return nil, errors.Wrapf(err, "resolve stack app values")
-	} else {
-		resolvedValues = resolvedValues.WithValues(stackAppValues.WithDefaultSource(fmt.Sprintf("%s stack app overrides", cluster.Name)))
+	for _, layer := range layers {
+		layerValues, resolveErr := layer.resolve(ctx)
+		if resolveErr != nil {
+			return nil, errors.Wrapf(resolveErr, "resolve %s values", layer.name)
+		}
+		resolvedValues = resolvedValues.WithValues(layerValues)
 	}
 
 	// ApplyToValues any overrides from parameters passed to this invocation of bosun.
@@ -100,7 +61,7 @@ func (a *AppDeploy) GetResolvedValues(ctx BosunContext) (*values.PersistableValu
 	}
 
 	// resolve dynamic values
-	resolvedValues, err := resolvedValues.WithDynamicValuesResolved(ctx)
+	resolvedValues, err = resolvedValues.WithDynamicValuesResolved(ctx)
 	if err != nil {
 		return nil, errors.Wrapf(err, "resolve dynamic values")
 	}
@@ -122,4 +83,133 @@ func (a *AppDeploy) GetResolvedValues(ctx BosunContext) (*values.PersistableValu
 	// fmt.Println()
 
 	return r, nil
-}
\ No newline at end of file
+}
+
+// getContextValues returns the values from the bosun context, which all other values are merged over.
+func (a *AppDeploy) getContextValues(ctx BosunContext) (values.ValueSet, error) {
+
+	matchArgs := ctx.GetMatchMapArgs()
+	bosunValues := values.Values{}
+	for k, v := range matchArgs {
+		bosunValues[k] = v
+	}
+
+	contextValues := values.NewValueSet().WithValues(
+		values.ValueSet{
+			Source: "bosun context",
+			Static: bosunValues,
+		})
+
+	// Make environment values available
+	if err := contextValues.Static.AddEnvAsPath(core.EnvPrefix, core.EnvAppVersion, a.AppManifest.Version); err != nil {
+		return contextValues, err
+	}
+	if err := contextValues.Static.AddEnvAsPath(core.EnvPrefix, core.EnvAppBranch, a.AppManifest.Branch); err != nil {
+		return contextValues, err
+	}
+	if err := contextValues.Static.AddEnvAsPath(core.EnvPrefix, core.EnvAppCommit, a.AppManifest.Hashes.Commit); err != nil {
+		return contextValues, err
+	}
+
+	return contextValues, nil
+}
+
+// valuesLayer is one of the layers of values which are merged, in order,
+// to produce the values used to deploy an app.
+type valuesLayer struct {
+	name string
+	// The collection the values are extracted from using the context, if the layer has one.
+	collection values.ValueSetCollectionProvider
+	// The value sets in the layer, if it doesn't have a collection.
+	valueSets values.ValueSets
+	// The source to attribute the values to.
+	source string
+	// If true, the source replaces any source already set on the values.
+	overrideSource bool
+}
+
+func (l valuesLayer) withSource(v values.ValueSet) values.ValueSet {
+	if l.overrideSource {
+		return v.WithSource(l.source)
+	}
+	return v.WithDefaultSource(l.source)
+}
+
+func (l valuesLayer) resolve(ctx BosunContext) (values.ValueSet, error) {
+	if l.collection != nil {
+		resolved, err := ResolveValues(l.collection, ctx)
+		if err != nil {
+			return resolved, err
+		}
+		return l.withSource(resolved), nil
+	}
+
+	out := values.NewValueSet()
+	for _, v := range l.valueSets {
+		out = out.WithValues(l.withSource(v))
+	}
+	return out, nil
+}
+
+// getValuesLayers returns the layers of values for the app, from lowest to highest precedence.
+func (a *AppDeploy) getValuesLayers(ctx BosunContext) ([]valuesLayer, error) {
+
+	chartValues, err := a.AppManifest.AppConfig.LoadChartValues()
+	if err != nil {
+		return nil, errors.Wrapf(err, "load chart values")
+	}
+
+	env := ctx.Environment()
+	cluster := env.Cluster()
+	stack := env.Stack()
+
+	return []valuesLayer{
+		{
+			name:           "chart",
+			valueSets:      values.ValueSets{chartValues},
+			source:         "chart values file",
+			overrideSource: true,
+		},
+		{
+			name:       "app config",
+			collection: a.AppConfig,
+			source:     "bosun file",
+		},
+		{
+			name:      "app deploy settings",
+			valueSets: a.AppDeploySettings.ValueSets,
+			source:    "app deploy settings",
+		},
+		{
+			name:           "platform",
+			collection:     ctx.GetPlatform(),
+			source:         "platform overrides",
+			overrideSource: true,
+		},
+		{
+			name:       "environment",
+			collection: env,
+			source:     fmt.Sprintf("%s environment", env.Name),
+		},
+		{
+			name:       "environment app",
+			collection: env.GetAppValueSetCollectionProvider(a.Name),
+			source:     fmt.Sprintf("%s environment app value overrides", env.Name),
+		},
+		{
+			name:       "cluster",
+			collection: cluster,
+			source:     fmt.Sprintf("%s cluster", cluster.Name),
+		},
+		{
+			name:       "stack",
+			collection: stack,
+			source:     fmt.Sprintf("%s stack overrides", cluster.Name),
+		},
+		{
+			name:       "stack app",
+			collection: stack.GetAppValueSetCollectionProvider(a.Name),
+			source:     fmt.Sprintf("%s stack app overrides", cluster.Name),
+		},
+	}, nil
+}
@@ -0,0 +1,229 @@
+package bosun
+
+import (
+	"fmt"
+	"github.com/naveego/bosun/pkg/filter"
+	"github.com/naveego/bosun/pkg/values"
+	"github.com/naveego/bosun/pkg/yaml"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+)
+
+const (
+	ValueStepStatic    = "static"
+	ValueStepFile      = "file"
+	ValueStepDynamic   = "dynamic"
+	ValueStepParameter = "parameter"
+	ValueStepMapping   = "mapping"
+)
+
+// ValueExplanationStep is a place where the value at a path was set or overridden.
+type ValueExplanationStep struct {
+	Layer    string      `yaml:"layer" json:"layer"`
+	Kind     string      `yaml:"kind" json:"kind"`
+	ValueSet string      `yaml:"valueSet,omitempty" json:"valueSet,omitempty"`
+	Roles    string      `yaml:"roles,omitempty" json:"roles,omitempty"`
+	Filters  string      `yaml:"filters,omitempty" json:"filters,omitempty"`
+	Source   string      `yaml:"source,omitempty" json:"source,omitempty"`
+	Value    interface{} `yaml:"value" json:"value"`
+}
+
+// ValueExplanation lists every step which set the value at Path while resolving the values
+// for an app, in the order the steps were applied.
+type ValueExplanation struct {
+	Path       string                 `yaml:"path" json:"path"`
+	Steps      []ValueExplanationStep `yaml:"steps" json:"steps"`
+	FinalValue interface{}            `yaml:"finalValue" json:"finalValue"`
+}
+
+func (v ValueExplanation) Headers() []string {
+	return []string{"#", "Layer", "Kind", "Value Set", "Roles", "Filters", "Source", "Value"}
+}
+
+func (v ValueExplanation) Rows() [][]string {
+	var out [][]string
+	for i, step := range v.Steps {
+		out = append(out, []string{
+			fmt.Sprint(i + 1),
+			step.Layer,
+			step.Kind,
+			step.ValueSet,
+			step.Roles,
+			step.Filters,
+			step.Source,
+			formatExplainedValue(step.Value),
+		})
+	}
+	out = append(out, []string{"", "final", "", "", "", "", "", formatExplainedValue(v.FinalValue)})
+	return out
+}
+
+// ExplainValue finds every layer of values which set or overrode the value at path
+// while resolving the values for the app, mirroring the order used by GetResolvedValues.
+// Steps are returned in the order they are applied, so the last step determines the value.
+func (a *AppDeploy) ExplainValue(ctx BosunContext, path string) (*ValueExplanation, error) {
+
+	contextValues, err := a.getContextValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := a.getValuesLayers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.explainValuesLayers(ctx, path, contextValues, layers)
+}
+
+func (a *AppDeploy) explainValuesLayers(ctx BosunContext, path string, contextValues values.ValueSet, layers []valuesLayer) (*ValueExplanation, error) {
+
+	out := &ValueExplanation{
+		Path: path,
+	}
+	out.addStatic("bosun context", ValueStepStatic, contextValues, contextValues.Source)
+
+	var dynamicSteps []ValueExplanationStep
+
+	for _, layer := range layers {
+		valueSets := layer.valueSets
+
+		if layer.collection != nil {
+			collection := layer.collection.GetValueSetCollection()
+			args := values.ExtractValueSetArgs{ExactMatch: ctx.GetMatchMapArgs()}
+			valueSets = append(values.ValueSets{collection.DefaultValues}, collection.MatchValueSets(args)...)
+
+			// Files are merged under all the static values in the layer, see ResolveValues.
+			extracted := collection.ExtractValueSet(args)
+			for _, file := range extracted.Files {
+				resolvedFile := ctx.ResolvePath(file, "VALUE_SET", extracted.Name)
+				fileValues, readErr := values.ReadValuesFile(resolvedFile)
+				if readErr != nil {
+					return nil, errors.Errorf("reading values file %q: %s", resolvedFile, readErr)
+				}
+				for _, valueSet := range valueSets {
+					for _, valueSetFile := range valueSet.Files {
+						if valueSetFile == file {
+							valueSet.Static = fileValues
+							out.addStatic(layer.name, ValueStepFile, valueSet, resolvedFile)
+						}
+					}
+				}
+			}
+		}
+
+		for _, valueSet := range valueSets {
+			out.addStatic(layer.name, ValueStepStatic, valueSet, getExplainedSource(layer, valueSet, path))
+
+			for key, dynamicValue := range valueSet.Dynamic {
+				if dynamicValue.Disabled || !pathsOverlap(key, path) {
+					continue
+				}
+				dynamicSteps = append(dynamicSteps, makeExplanationStep(layer.name, ValueStepDynamic, valueSet, layer.source, dynamicValue.String()))
+			}
+		}
+	}
+
+	for key, value := range ctx.GetParameters().ValueOverrides {
+		if pathsOverlap(key, path) {
+			out.Steps = append(out.Steps, ValueExplanationStep{
+				Layer:  "command line",
+				Kind:   ValueStepParameter,
+				Source: fmt.Sprintf("--set %s=%s", key, value),
+				Value:  value,
+			})
+		}
+	}
+
+	// dynamic values are resolved after all static values have been merged, so they take precedence
+	out.Steps = append(out.Steps, dynamicSteps...)
+
+	for from, to := range a.AppManifest.AppConfig.ValueMappings {
+		if pathsOverlap(to, path) {
+			out.Steps = append(out.Steps, ValueExplanationStep{
+				Layer:  "app config",
+				Kind:   ValueStepMapping,
+				Source: fmt.Sprintf("valueMappings: %s => %s", from, to),
+			})
+		}
+	}
+
+	resolved, err := a.resolveValuesLayers(ctx, contextValues, layers)
+	if err != nil {
+		return nil, err
+	}
+	out.FinalValue, _ = resolved.Values.GetAtPath(path)
+
+	return out, nil
+}
+
+func (v *ValueExplanation) addStatic(layer string, kind string, valueSet values.ValueSet, source string) {
+	value, err := valueSet.Static.GetAtPath(v.Path)
+	if err != nil || value == nil {
+		return
+	}
+	v.Steps = append(v.Steps, makeExplanationStep(layer, kind, valueSet, source, value))
+}
+
+func makeExplanationStep(layer string, kind string, valueSet values.ValueSet, source string, value interface{}) ValueExplanationStep {
+	step := ValueExplanationStep{
+		Layer:    layer,
+		Kind:     kind,
+		ValueSet: valueSet.Name,
+		Source:   source,
+		Value:    value,
+		Filters:  formatMatchMapConfig(valueSet.ExactMatchFilters),
+	}
+	var roles []string
+	for _, role := range valueSet.Roles {
+		roles = append(roles, string(role))
+	}
+	step.Roles = strings.Join(roles, ",")
+	return step
+}
+
+// getExplainedSource returns the most specific description available of where a value set came from.
+func getExplainedSource(layer valuesLayer, valueSet values.ValueSet, path string) string {
+	if attribution, err := valueSet.StaticAttributions.GetAtPath(path); err == nil {
+		if s, ok := attribution.(string); ok && s != "" {
+			return s
+		}
+	}
+	if valueSet.FromPath != "" {
+		return valueSet.FromPath
+	}
+	if valueSet.Source != "" && !layer.overrideSource {
+		return valueSet.Source
+	}
+	return layer.source
+}
+
+// pathsOverlap returns true if setting the value at one path could set the value at the other.
+func pathsOverlap(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+}
+
+func formatMatchMapConfig(m filter.MatchMapConfig) string {
+	var parts []string
+	for key, matchers := range m {
+		var matcherValues []string
+		for _, matcher := range matchers {
+			matcherValues = append(matcherValues, string(matcher))
+		}
+		parts = append(parts, fmt.Sprintf("%s=%s", key, strings.Join(matcherValues, "|")))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func formatExplainedValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	y, _ := yaml.MarshalString(value)
+	return strings.TrimSpace(y)
+}
@@ -0,0 +1,101 @@
+package bosun
+
+import (
+	"github.com/naveego/bosun/pkg/core"
+	"github.com/naveego/bosun/pkg/filter"
+	"github.com/naveego/bosun/pkg/values"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AppDeploy values", func() {
+
+	var ctx BosunContext
+	var sut *AppDeploy
+	var layers []valuesLayer
+
+	imageTag := func(tag string) values.Values {
+		return values.Values{"image": values.Values{"tag": tag}}
+	}
+
+	BeforeEach(func() {
+		ctx = NewTestBosunContext()
+		ctx.exactMatchArgs = filter.MatchMapArgs{core.KeyEnvironmentRole: "blue"}
+
+		appConfig := &AppConfig{
+			Values: values.ValueSetCollection{
+				DefaultValues: values.ValueSet{Static: imageTag("app")},
+				ValueSets: values.ValueSets{
+					{ConfigShared: core.ConfigShared{Name: "blue"}, Roles: []core.EnvironmentRole{"blue"}, Static: imageTag("app-blue")},
+					{ConfigShared: core.ConfigShared{Name: "red"}, Roles: []core.EnvironmentRole{"red"}, Static: imageTag("app-red")},
+				},
+			},
+		}
+		sut = &AppDeploy{
+			Name:        "api",
+			AppConfig:   appConfig,
+			AppManifest: &AppManifest{AppConfig: appConfig},
+		}
+
+		layers = []valuesLayer{
+			{
+				name:           "chart",
+				valueSets:      values.ValueSets{{Static: values.Values{"image": values.Values{"tag": "chart", "repository": "api"}}}},
+				source:         "chart values file",
+				overrideSource: true,
+			},
+			{
+				name:       "app config",
+				collection: appConfig,
+				source:     "bosun file",
+			},
+			{
+				name:      "environment",
+				valueSets: values.ValueSets{{Static: values.Values{"replicas": 2}}},
+				source:    "blue environment",
+			},
+			{
+				name:      "stack",
+				valueSets: values.ValueSets{{ConfigShared: core.ConfigShared{Name: "pinned"}, Static: imageTag("stack")}},
+				source:    "blue stack overrides",
+			},
+		}
+	})
+
+	It("merges layers in order so later layers override earlier ones", func() {
+		resolved, err := sut.resolveValuesLayers(ctx, values.NewValueSet(), layers)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resolved.Values.GetAtPath("image.tag")).To(Equal("stack"))
+		Expect(resolved.Values.GetAtPath("image.repository")).To(Equal("api"))
+		Expect(resolved.Values.GetAtPath("replicas")).To(Equal(2))
+	})
+
+	It("explains every layer which set an overridden key, in the order they were applied", func() {
+		explanation, err := sut.explainValuesLayers(ctx, "image.tag", values.NewValueSet(), layers)
+		Expect(err).ToNot(HaveOccurred())
+
+		var actual [][]interface{}
+		for _, step := range explanation.Steps {
+			actual = append(actual, []interface{}{step.Layer, step.ValueSet, step.Roles, step.Source, step.Value})
+		}
+		Expect(actual).To(Equal([][]interface{}{
+			{"chart", "", "", "chart values file", "chart"},
+			{"app config", "", "", "bosun file", "app"},
+			{"app config", "blue", "blue", "bosun file", "app-blue"},
+			{"stack", "pinned", "", "blue stack overrides", "stack"},
+		}))
+		Expect(explanation.FinalValue).To(Equal("stack"))
+	})
+
+	It("explains a parameter override after the static layers", func() {
+		ctx.Bosun.params.ValueOverrides = map[string]string{"image.tag": "cli"}
+
+		explanation, err := sut.explainValuesLayers(ctx, "image.tag", values.NewValueSet(), layers)
+		Expect(err).ToNot(HaveOccurred())
+
+		last := explanation.Steps[len(explanation.Steps)-1]
+		Expect(last.Kind).To(Equal(ValueStepParameter))
+		Expect(last.Source).To(Equal("--set image.tag=cli"))
+		Expect(explanation.FinalValue).To(Equal("cli"))
+	})
+})
@@ -0,0 +1,64 @@
+package bosun
+
+import (
+	"github.com/naveego/bosun/pkg/environment"
+	"github.com/naveego/bosun/pkg/util/stringsn"
+	"github.com/naveego/bosun/pkg/values"
+	"github.com/naveego/bosun/pkg/yaml"
+	"github.com/pkg/errors"
+)
+
+// GetSecretPathReferences returns the secret paths passed to resolveSecretPath in the app's
+// values, including values loaded from files and values overridden by the current environment.
+func (a *App) GetSecretPathReferences(ctx BosunContext) ([]string, error) {
+	ctx = ctx.WithApp(a)
+
+	valueSets := append(values.ValueSets{a.Values.DefaultValues}, a.Values.ValueSets...)
+
+	envOverrides := ctx.Environment().GetAppValueSetCollectionProvider(a.Name).GetValueSetCollection()
+	valueSets = append(valueSets, envOverrides.DefaultValues)
+	valueSets = append(valueSets, envOverrides.ValueSets...)
+
+	var out []string
+	for _, valueSet := range valueSets {
+		loaded, err := valueSet.WithFilesLoaded(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load files for value set %q of app %q", valueSet.Name, a.Name)
+		}
+
+		y, err := yaml.MarshalString(loaded)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range environment.FindSecretPathReferences(y) {
+			if !stringsn.Contains(out, path) {
+				out = append(out, path)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// ReferencesSecret returns true if the app's values reference any of the secrets.
+func (a *App) ReferencesSecret(ctx BosunContext, secretPaths ...*environment.SecretPath) (bool, error) {
+	references, err := a.GetSecretPathReferences(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, reference := range references {
+		referencedPath, parseErr := environment.ParseSecretPath(reference)
+		if parseErr != nil {
+			continue
+		}
+		for _, secretPath := range secretPaths {
+			if referencedPath.Matches(secretPath) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
@@ -346,7 +346,7 @@ func (b *Bosun) GetOrAddAppForPath(path string) (*App, error) {
 	_, err = b.GetApp(app.Name)
 	if err != nil {
 		ctx := b.NewContext()
-		ctx.Log().Infof("Adding app %s at path %s to workspace...", app.Name)
+		ctx.Log().Infof("Adding app %s at path %s to workspace...", app.Name, path)
 
 		b.AddImport(path)
 
@@ -391,6 +391,28 @@ func (b *Bosun) UseStack(stack brns.StackBrn) error {
 	return err
 }
 
+// BuildEnvironment builds the environment for the default stack of the default cluster
+// in config, so that apps can be resolved against it, without changing the current environment.
+func (b *Bosun) BuildEnvironment(config *environment.Config) (*environment.Environment, error) {
+	clusterConfig, err := config.GetDefaultClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	stack := brns.NewStack(config.Name, clusterConfig.Name, kube.DefaultStackName)
+	env, err := config.Builder(b.NewContextWithoutEnvironment()).WithBrn(stack).Build()
+
+	// building the environment activates its cluster, so switch back to the current one
+	if b.env != nil && b.env.HasCluster() {
+		core.SetInternalBrn(brns.NewStack(b.env.Name, b.env.Cluster().Name, b.env.Stack().Name))
+		if activateErr := b.env.Cluster().Activate(); activateErr != nil && err == nil {
+			err = activateErr
+		}
+	}
+
+	return env, err
+}
+
 func (b *Bosun) GetEnvironmentAndCluster(stack brns.StackBrn) (*environment.Config, *kube.ClusterConfig, error) {
 
 	var env *environment.Config
@@ -585,6 +607,12 @@ func (b *Bosun) GetWorkspace() *Workspace {
 	return b.ws
 }
 
+// GetCommitRules returns the rules for parsing commits in a repo with the given branching,
+// using the workspace rules for anything the branching doesn't set.
+func (b *Bosun) GetCommitRules(branching git.BranchSpec) *git.CommitRules {
+	return branching.CommitRules.WithDefaultsFrom(b.ws.CommitRules)
+}
+
 func (b *Bosun) GetMergedConfig() File {
 	return *b.file
 }
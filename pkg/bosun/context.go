@@ -289,6 +289,8 @@ func (c BosunContext) LogLine(skip int, format string, args ...interface{}) {
 var useMinikubeForDockerOnce = new(sync.Once)
 var dockerEnv []string
 
+// GetMinikubeDockerEnv returns the environment variables for using the docker agent in minikube.
+// They are not set in the process environment, which is shared by apps deployed concurrently.
 func (c BosunContext) GetMinikubeDockerEnv() []string {
 	useMinikubeForDockerOnce.Do(func() {
 		defer func() {
@@ -311,8 +313,7 @@ func (c BosunContext) GetMinikubeDockerEnv() []string {
 		}
 		envs := regexp.MustCompile(`([A-Z_]+)="([^"])"`).FindAllStringSubmatch(envblob, -1)
 		for _, env := range envs {
-			log.Debugf("Setting env %s=%s", env[0], env[1])
-			_ = os.Setenv(env[0], env[1])
+			log.Debugf("Using env %s=%s", env[0], env[1])
 			dockerEnv = append(dockerEnv, fmt.Sprintf("%s=%s", env[0], env[1]))
 		}
 		log.Info("Minikube docker agent configured.")
@@ -395,6 +396,10 @@ func (c BosunContext) GetStringValue(key string, defaultValue ...string) string
 		return c.Environment().Name
 	case core.KeyCluster:
 		return c.GetClusterName()
+	case core.KeyNamespace:
+		if c.appRelease != nil {
+			return c.appRelease.getNamespaceName()
+		}
 	}
 
 	if out, ok := c.contextValues[key].(string); ok {
@@ -0,0 +1,138 @@
+package bosun
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/naveego/bosun/pkg/util"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+const (
+	DependencyCompatible   = "OK"
+	DependencyIncompatible = "INCOMPATIBLE"
+	DependencyInvalidRange = "INVALID RANGE"
+	DependencyNotIncluded  = "NOT INCLUDED"
+)
+
+// DependencyCompatibility records whether the version of a dependency
+// satisfies the range required by the app which depends on it.
+type DependencyCompatibility struct {
+	App               string
+	AppVersion        string
+	Dependency        string
+	Range             string
+	DependencyVersion string
+	Status            string
+}
+
+// Message describes the compatibility of the dependency from the point of view of the app.
+func (c DependencyCompatibility) Message() string {
+	switch c.Status {
+	case DependencyInvalidRange:
+		return fmt.Sprintf("has invalid range %q for dependency %s", c.Range, c.Dependency)
+	case DependencyNotIncluded:
+		return fmt.Sprintf("requires %s %s but it is not included", c.Dependency, c.Range)
+	case DependencyIncompatible:
+		return fmt.Sprintf("requires %s %s but found %s", c.Dependency, c.Range, c.DependencyVersion)
+	default:
+		return fmt.Sprintf("requires %s %s and found %s", c.Dependency, c.Range, c.DependencyVersion)
+	}
+}
+
+// DependencyCompatibilityMatrix is the compatibility of every dependency with a range
+// in a set of apps, like the apps in a deployment plan or release.
+type DependencyCompatibilityMatrix []DependencyCompatibility
+
+// CheckDependencyCompatibility checks each app in manifests against the ranges of its dependencies.
+// Dependencies which are not in manifests can't be checked, and are reported as not included.
+func CheckDependencyCompatibility(manifests map[string]*AppManifest) DependencyCompatibilityMatrix {
+	var out DependencyCompatibilityMatrix
+
+	for _, name := range util.SortedKeys(manifests) {
+		manifest := manifests[name]
+		if manifest == nil || manifest.AppConfig == nil {
+			continue
+		}
+		for _, dep := range manifest.AppConfig.DependsOn {
+			if dep.Range == "" {
+				continue
+			}
+			row := DependencyCompatibility{
+				App:        manifest.Name,
+				AppVersion: manifest.Version.String(),
+				Dependency: dep.Name,
+				Range:      dep.Range,
+			}
+
+			depManifest, ok := manifests[dep.Name]
+			if ok && depManifest != nil {
+				row.DependencyVersion = depManifest.Version.String()
+			}
+
+			r, err := dep.GetRange()
+			switch {
+			case err != nil:
+				row.Status = DependencyInvalidRange
+			case !ok || depManifest == nil:
+				row.Status = DependencyNotIncluded
+			case r(depManifest.Version):
+				row.Status = DependencyCompatible
+			default:
+				row.Status = DependencyIncompatible
+			}
+			out = append(out, row)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].App != out[j].App {
+			return out[i].App < out[j].App
+		}
+		return out[i].Dependency < out[j].Dependency
+	})
+
+	return out
+}
+
+// Failures returns the rows which are incompatible or have an invalid range.
+func (m DependencyCompatibilityMatrix) Failures() DependencyCompatibilityMatrix {
+	var out DependencyCompatibilityMatrix
+	for _, row := range m {
+		if row.Status == DependencyIncompatible || row.Status == DependencyInvalidRange {
+			out = append(out, row)
+		}
+	}
+	return out
+}
+
+// Err returns an error containing the whole matrix if any dependency is incompatible.
+func (m DependencyCompatibilityMatrix) Err() error {
+	failures := m.Failures()
+	if len(failures) == 0 {
+		return nil
+	}
+	return errors.Errorf("%d app dependencies are not compatible with the versions being deployed:\n%s", len(failures), m.String())
+}
+
+func (m DependencyCompatibilityMatrix) String() string {
+	w := new(bytes.Buffer)
+	t := tablewriter.NewWriter(w)
+	t.SetHeader([]string{"App", "App Version", "Depends On", "Range", "Dependency Version", "Status"})
+	t.SetAutoWrapText(false)
+	for _, row := range m {
+		t.Append([]string{row.App, row.AppVersion, row.Dependency, row.Range, row.DependencyVersion, row.Status})
+	}
+	t.Render()
+	return w.String()
+}
+
+// CheckDependencyCompatibility checks the apps in the plan against the ranges of their dependencies.
+func (d *DeploymentPlan) CheckDependencyCompatibility() DependencyCompatibilityMatrix {
+	manifests := map[string]*AppManifest{}
+	for _, app := range d.Apps {
+		manifests[app.Name] = app.Manifest
+	}
+	return CheckDependencyCompatibility(manifests)
+}
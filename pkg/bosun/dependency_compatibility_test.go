@@ -0,0 +1,90 @@
+package bosun_test
+
+import (
+	. "github.com/naveego/bosun/pkg/bosun"
+	"github.com/naveego/bosun/pkg/semver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DependencyCompatibility", func() {
+
+	manifest := func(name string, version string, deps ...Dependency) *AppManifest {
+		appConfig := &AppConfig{DependsOn: deps}
+		appConfig.Name = name
+		return &AppManifest{
+			AppMetadata: &AppMetadata{Name: name, Version: semver.New(version)},
+			AppConfig:   appConfig,
+		}
+	}
+
+	check := func(depRange string, manifests ...*AppManifest) DependencyCompatibilityMatrix {
+		all := map[string]*AppManifest{
+			"api": manifest("api", "1.4.0", Dependency{Name: "auth", Range: depRange}, Dependency{Name: "logging"}),
+		}
+		for _, m := range manifests {
+			all[m.Name] = m
+		}
+		return CheckDependencyCompatibility(all)
+	}
+
+	It("reports a dependency whose version is in the range as compatible", func() {
+		matrix := check(">=2.3.0 <3.0.0", manifest("auth", "2.5.1"))
+		Expect(matrix).To(Equal(DependencyCompatibilityMatrix{{
+			App:               "api",
+			AppVersion:        "1.4.0",
+			Dependency:        "auth",
+			Range:             ">=2.3.0 <3.0.0",
+			DependencyVersion: "2.5.1",
+			Status:            DependencyCompatible,
+		}}))
+		Expect(matrix.Failures()).To(BeEmpty())
+		Expect(matrix.Err()).ToNot(HaveOccurred())
+	})
+
+	It("reports a dependency whose version is outside the range as incompatible", func() {
+		matrix := check(">=2.3.0 <3.0.0", manifest("auth", "3.0.0"))
+		Expect(matrix).To(HaveLen(1))
+		Expect(matrix[0].Status).To(Equal(DependencyIncompatible))
+		Expect(matrix[0].Message()).To(Equal("requires auth >=2.3.0 <3.0.0 but found 3.0.0"))
+		Expect(matrix.Failures()).To(Equal(matrix))
+
+		err := matrix.Err()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("1 app dependencies are not compatible"))
+		Expect(err.Error()).To(ContainSubstring(DependencyIncompatible))
+	})
+
+	It("reports an invalid range as a failure", func() {
+		matrix := check("not a range", manifest("auth", "2.5.1"))
+		Expect(matrix).To(HaveLen(1))
+		Expect(matrix[0].Status).To(Equal(DependencyInvalidRange))
+		Expect(matrix[0].Message()).To(Equal(`has invalid range "not a range" for dependency auth`))
+		Expect(matrix.Failures()).To(HaveLen(1))
+		Expect(matrix.Err()).To(HaveOccurred())
+	})
+
+	It("reports a dependency which is not included without failing", func() {
+		matrix := check(">=2.3.0")
+		Expect(matrix).To(HaveLen(1))
+		Expect(matrix[0].Status).To(Equal(DependencyNotIncluded))
+		Expect(matrix[0].DependencyVersion).To(BeEmpty())
+		Expect(matrix.Failures()).To(BeEmpty())
+		Expect(matrix.Err()).ToNot(HaveOccurred())
+	})
+
+	It("reports an invalid range for a dependency which is not included", func() {
+		matrix := check("not a range")
+		Expect(matrix[0].Status).To(Equal(DependencyInvalidRange))
+	})
+
+	It("checks the apps in a deployment plan", func() {
+		plan := &DeploymentPlan{Apps: []*AppDeploymentPlan{
+			{Name: "api", Manifest: manifest("api", "1.4.0", Dependency{Name: "auth", Range: ">=2.3.0"})},
+			{Name: "auth", Manifest: manifest("auth", "2.2.0")},
+		}}
+		matrix := plan.CheckDependencyCompatibility()
+		Expect(matrix).To(HaveLen(1))
+		Expect(matrix[0].Status).To(Equal(DependencyIncompatible))
+	})
+})
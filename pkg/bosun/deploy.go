@@ -2,16 +2,22 @@ package bosun
 
 import (
 	"fmt"
+	"github.com/naveego/bosun/pkg/actions"
 	"github.com/naveego/bosun/pkg/core"
 	"github.com/naveego/bosun/pkg/environment"
 	"github.com/naveego/bosun/pkg/filter"
 	"github.com/naveego/bosun/pkg/git"
 	"github.com/naveego/bosun/pkg/kube"
+	"github.com/naveego/bosun/pkg/util/multierr"
+	"github.com/naveego/bosun/pkg/util/worker"
 	"github.com/naveego/bosun/pkg/values"
 	"github.com/naveego/bosun/pkg/workspace"
 	"github.com/pkg/errors"
+	"os"
+	"os/user"
 	"regexp"
 	"sort"
+	"sync"
 	"time"
 )
 
@@ -81,8 +87,17 @@ type DeploySettings struct {
 	Filter             *filter.Chain // If set, only apps which match the filter will be deployed.
 	IgnoreDependencies bool
 	ForceDeployApps    map[string]bool
-	AfterDeploy        func(app *AppDeploy, err error)
-	// if set, called after a deploy
+	// The maximum number of apps to deploy at the same time; values less than 1 mean one at a time.
+	Concurrency int
+	// if set, called after each app is deployed (or fails to deploy); calls are never concurrent
+	AfterDeploy func(app *AppDeploy, err error)
+	// If true, when any app fails to deploy all the apps which were deployed are rolled back
+	// to the helm revisions they were at before the deploy started.
+	RollbackOnFailure bool
+	// if set, called after each app is rolled back (or fails to roll back)
+	AfterRollback func(app *AppDeploy, revision string, err error)
+	// Hash of the release the deployment plan was based on, recorded in the stack history.
+	ReleaseHash string
 }
 
 func (d DeploySettings) WithValueSets(valueSets ...values.ValueSet) DeploySettings {
@@ -163,7 +178,7 @@ func NewDeploy(ctx BosunContext, settings DeploySettings) (*Deploy, error) {
 				if appManifest, ok := appManifests[appName]; ok {
 					appsToDeploy = append(appsToDeploy, appManifest)
 				} else {
-					return nil, errors.Errorf("requested app %q not found among app manifests known by this release")
+					return nil, errors.Errorf("requested app %q not found among app manifests known by this release", appName)
 				}
 			}
 		}
@@ -451,78 +466,352 @@ func (a AppDeployMap) GetAppsSortedByName() AppReleasesSortedByName {
 // 	return nil
 // }
 
-func (d *Deploy) Deploy(ctx BosunContext) error {
+// deployRun holds the state shared between the apps deployed by a single call to Deploy.
+type deployRun struct {
+	// guards everything below, the stack state and the AfterDeploy callback
+	mu *sync.Mutex
+	// apps which have been deployed (or attempted), in the order they completed
+	rollbackPoints []appRollbackPoint
+	failed         bool
+}
 
-	for _, app := range d.AppDeploys {
+// appRollbackPoint captures the state of an app before it was deployed.
+type appRollbackPoint struct {
+	app *AppDeploy
+	// the helm revision which was deployed before, empty if the app was not installed
+	revision string
+	// the stack app entry from before the deploy, if there was one
+	stackApp *kube.StackApp
+}
 
-		appCtx := ctx.WithAppDeploy(app).WithMatchMapArgs(app.MatchArgs).(BosunContext)
+func (d *Deploy) Deploy(ctx BosunContext) error {
 
-		// appCtx = appCtx.WithLogField("namespace", app.Namespace).(BosunContext)
+	run := &deployRun{mu: new(sync.Mutex)}
 
-		app.DesiredState.Status = workspace.StatusDeployed
-		if app.DesiredState.Routing == "" {
-			app.DesiredState.Routing = workspace.RoutingCluster
+	err := d.deployApps(ctx, run, d.deployApp)
+
+	if err != nil && d.RollbackOnFailure && !d.isPreviewOnly() {
+		rollbackErr := d.rollback(ctx, ctx.Stack(), run)
+		if rollbackErr != nil {
+			return errors.Errorf("%s\n\nrollback also failed:\n%s", err, rollbackErr)
 		}
+		return errors.Wrap(err, "deploy failed and was rolled back")
+	}
 
-		app.DesiredState.Force = appCtx.GetParameters().Force
+	return err
+}
 
-		stack := ctx.Stack()
+// deployApps calls deployApp for every app in dependency order. Apps which don't depend on each other
+// may be deployed concurrently, up to the configured concurrency.
+func (d *Deploy) deployApps(ctx BosunContext, run *deployRun, deployApp func(BosunContext, *AppDeploy, *deployRun) error) error {
 
-		err := app.Reconcile(appCtx)
+	graph := worker.NewDependencyGraph()
+	appDeploysByName := map[string][]*AppDeploy{}
+	for _, app := range d.AppDeploys {
+		if _, ok := appDeploysByName[app.Name]; !ok {
+			var dependsOn []string
+			for _, dep := range app.AppConfig.DependsOn {
+				dependsOn = append(dependsOn, dep.Name)
+			}
+			graph.Add(app.Name, dependsOn...)
+		}
+		appDeploysByName[app.Name] = append(appDeploysByName[app.Name], app)
+	}
 
-		if err != nil {
-			return err
+	results := graph.Run(d.Concurrency, func(name string) error {
+		for _, app := range appDeploysByName[name] {
+			err := deployApp(ctx, app, run)
+			if err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+
+	errs := multierr.New()
 
-		if d.DiffOnly || d.DumpValuesOnly || d.RenderOnly {
+	for _, name := range graph.Keys() {
+		err := results[name]
+		if err == nil {
 			continue
 		}
 
-		stackApp := app.StackApp
-		if stackApp == nil {
-			stackApp = &kube.StackApp{
-				Name:       app.Name,
-				Version:    app.AppManifest.Version.String(),
-				Provider:   app.AppConfig.ProviderInfo,
-				Repo:       app.AppConfig.RepoName,
-				Branch:     app.AppManifest.Branch,
-				Commit:     app.AppManifest.Hashes.Commit,
-				DeployedAt: time.Now(),
-				StoryKey:   "",
+		if _, skipped := err.(worker.DependencyFailedError); skipped {
+			ctx.Log().WithField("app", name).Warnf("Skipped deploy: %s", err)
+			if d.AfterDeploy != nil && !d.isPreviewOnly() {
+				for _, app := range appDeploysByName[name] {
+					d.AfterDeploy(app, err)
+				}
 			}
+		}
 
-			if app.AppManifest.PinnedReleaseVersion != nil {
-				stackApp.Release = app.AppManifest.PinnedReleaseVersion.String()
-			}
+		errs.Collect(errors.Wrapf(err, "deploy %q", name))
+	}
 
-			platform, _ := ctx.Bosun.GetCurrentPlatform()
-			if platform != nil {
-				g, gitErr := git.NewGitWrapper(platform.FromPath)
-				if gitErr == nil {
-					stackApp.DevopsBranch = g.Branch()
-				}
-			}
+	return errs.ToError()
+}
+
+func (d *Deploy) isPreviewOnly() bool {
+	return d.DiffOnly || d.DumpValuesOnly || d.RenderOnly
+}
+
+func (d *Deploy) deployApp(ctx BosunContext, app *AppDeploy, run *deployRun) error {
+
+	appCtx := ctx.WithAppDeploy(app).WithMatchMapArgs(app.MatchArgs).(BosunContext)
+
+	// appCtx = appCtx.WithLogField("namespace", app.Namespace).(BosunContext)
+
+	app.DesiredState.Status = workspace.StatusDeployed
+	if app.DesiredState.Routing == "" {
+		app.DesiredState.Routing = workspace.RoutingCluster
+	}
+
+	app.DesiredState.Force = appCtx.GetParameters().Force
+
+	stack := ctx.Stack()
+
+	if d.isPreviewOnly() {
+		return app.Reconcile(appCtx)
+	}
+
+	previousStackApp, err := d.getStackApp(stack, run, app.Name)
+	if err != nil {
+		return errors.Wrap(err, "get current stack state")
+	}
+
+	if d.RollbackOnFailure {
+		run.mu.Lock()
+		failed := run.failed
+		run.mu.Unlock()
+		if failed {
+			return errors.New("not deployed because another app failed and the deploy will be rolled back")
 		}
 
-		err = stack.UpdateApp(*stackApp)
-		if err != nil {
-			ctx.Log().WithError(err).Warnf("Could not update stack app %+v", *stackApp)
+		rollbackPoint := appRollbackPoint{app: app, stackApp: previousStackApp}
+		release, releaseErr := app.GetHelmRelease(app.AppManifest.Name, app.Namespace)
+		if releaseErr != nil {
+			return errors.Wrap(releaseErr, "capture state for rollback")
+		}
+		if release != nil {
+			rollbackPoint.revision = release.Revision
+		}
+		defer func() {
+			run.mu.Lock()
+			run.rollbackPoints = append(run.rollbackPoints, rollbackPoint)
+			run.mu.Unlock()
+		}()
+	}
+
+	err = app.Reconcile(appCtx)
+
+	stackApp := app.StackApp
+	if stackApp == nil {
+		stackApp = d.makeStackApp(ctx, app)
+	}
+
+	if err == nil {
+		run.mu.Lock()
+		updateErr := stack.UpdateApp(*stackApp)
+		run.mu.Unlock()
+		if updateErr != nil {
+			ctx.Log().WithError(updateErr).Warnf("Could not update stack app %+v", *stackApp)
 		}
 
 		if d.Recycle {
 			err = app.Recycle(ctx)
+		}
+	}
+
+	historyEntry := d.makeStackHistoryEntry(previousStackApp, *stackApp, err)
+
+	run.mu.Lock()
+	if err != nil {
+		run.failed = true
+	}
+	if historyErr := stack.RecordHistory(historyEntry); historyErr != nil {
+		ctx.Log().WithError(historyErr).Warn("Could not record deployment in stack history.")
+	}
+	if d.AfterDeploy != nil {
+		d.AfterDeploy(app, err)
+	}
+	run.mu.Unlock()
+
+	return err
+}
+
+func (d *Deploy) makeStackApp(ctx BosunContext, app *AppDeploy) *kube.StackApp {
+	stackApp := &kube.StackApp{
+		Name:       app.Name,
+		Version:    app.AppManifest.Version.String(),
+		Provider:   app.AppConfig.ProviderInfo,
+		Repo:       app.AppConfig.RepoName,
+		Branch:     app.AppManifest.Branch,
+		Commit:     app.AppManifest.Hashes.Commit,
+		DeployedAt: time.Now(),
+		StoryKey:   "",
+	}
+
+	if app.AppManifest.PinnedReleaseVersion != nil {
+		stackApp.Release = app.AppManifest.PinnedReleaseVersion.String()
+	}
+
+	platform, _ := ctx.Bosun.GetCurrentPlatform()
+	if platform != nil {
+		g, gitErr := git.NewGitWrapper(platform.FromPath)
+		if gitErr == nil {
+			stackApp.DevopsBranch = g.Branch()
+		}
+	}
+
+	return stackApp
+}
+
+// getStackApp returns the app as currently recorded in the stack state, or nil if it isn't there.
+func (d *Deploy) getStackApp(stack *kube.Stack, run *deployRun, name string) (*kube.StackApp, error) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	state, err := stack.GetState(false)
+	if err != nil {
+		return nil, err
+	}
+	if stackApp, ok := state.DeployedApps[name]; ok {
+		return &stackApp, nil
+	}
+	return nil, nil
+}
+
+func (d *Deploy) makeStackHistoryEntry(previous *kube.StackApp, current kube.StackApp, err error) kube.StackHistoryEntry {
+	entry := kube.StackHistoryEntry{
+		App:       current.Name,
+		Timestamp: time.Now(),
+		User:      getCurrentUserName(),
+		Outcome:   kube.StackHistoryOutcomeDeployed,
+		ReleaseHash:  d.ReleaseHash,
+		Previous:  previous,
+		Current:   current,
+	}
+	if err != nil {
+		entry.Outcome = kube.StackHistoryOutcomeFailed
+		entry.Error = err.Error()
+	}
+	return entry
+}
+
+func getCurrentUserName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// RollbackRevisionUninstall is reported as the rollback revision of apps which were
+// uninstalled during a rollback because they were not installed before the deploy.
+const RollbackRevisionUninstall = "uninstall"
+
+// deployStack is the part of the stack which records the apps deployed to it.
+type deployStack interface {
+	UpdateApp(updates ...kube.StackApp) error
+	RemoveApp(names ...string) error
+	RecordHistory(entries ...kube.StackHistoryEntry) error
+}
+
+// uninstall deletes an app which was installed by a deploy which is being rolled back.
+// Like every other app which is rolled back, the BeforeRollback actions of the app are run.
+// The delete actions are not run, because the app is being returned to the state it was
+// in before the deploy rather than being deleted.
+func (d *Deploy) uninstall(ctx BosunContext, stack deployStack, app *AppDeploy) error {
+	log := ctx.Log()
+	log.Warn("App was not installed before this deploy, uninstalling it...")
+
+	if err := app.ExecuteActionsForSchedule(ctx, actions.ActionBeforeRollback); err != nil {
+		return err
+	}
+
+	if ctx.GetParameters().DryRun {
+		log.Info("Not uninstalling during a dry run.")
+		return nil
+	}
+
+	err := app.deleteRelease(ctx)
+	if err != nil {
+		log.WithError(err).Error("Uninstall failed.")
+		return err
+	}
+	log.Info("Uninstalled.")
+
+	if removeErr := stack.RemoveApp(app.Name); removeErr != nil {
+		log.WithError(removeErr).Warn("Could not remove app from stack.")
+	}
+
+	uninstalledFrom := app.StackApp
+	if uninstalledFrom == nil {
+		uninstalledFrom = d.makeStackApp(ctx, app)
+	}
+	historyEntry := d.makeStackHistoryEntry(uninstalledFrom, kube.StackApp{Name: app.Name}, nil)
+	historyEntry.Outcome = kube.StackHistoryOutcomeUninstalled
+	if historyErr := stack.RecordHistory(historyEntry); historyErr != nil {
+		log.WithError(historyErr).Warn("Could not record uninstall in stack history.")
+	}
+	return nil
+}
+
+// rollback rolls back every app touched by the run, in the reverse of the order they were deployed.
+// During a dry run the helm rollbacks are dry runs and the stack is not changed.
+func (d *Deploy) rollback(ctx BosunContext, stack deployStack, run *deployRun) error {
+
+	errs := multierr.New()
+	dryRun := ctx.GetParameters().DryRun
+
+	ctx.Log().Warnf("Deploy failed, rolling back %d apps...", len(run.rollbackPoints))
+
+	for i := len(run.rollbackPoints) - 1; i >= 0; i-- {
+		rollbackPoint := run.rollbackPoints[i]
+		app := rollbackPoint.app
+		appCtx := ctx.WithAppDeploy(app).WithMatchMapArgs(app.MatchArgs).(BosunContext)
+		log := appCtx.Log()
+
+		if rollbackPoint.revision == "" {
+			err := d.uninstall(appCtx, stack, app)
+			if d.AfterRollback != nil {
+				d.AfterRollback(app, RollbackRevisionUninstall, err)
+			}
 			if err != nil {
-				return err
+				errs.Collect(errors.Wrapf(err, "uninstall %q", app.Name))
 			}
+			continue
 		}
 
-		if d.AfterDeploy != nil {
-			d.AfterDeploy(app, err)
+		log.Infof("Rolling back to revision %s...", rollbackPoint.revision)
+
+		err := app.RollbackToRevision(appCtx, rollbackPoint.revision)
+		if err != nil {
+			log.WithError(err).Error("Rollback failed.")
+			errs.Collect(errors.Wrapf(err, "rollback %q", app.Name))
+		} else {
+			log.Infof("Rolled back to revision %s.", rollbackPoint.revision)
+			if rollbackPoint.stackApp != nil && !dryRun {
+				if updateErr := stack.UpdateApp(*rollbackPoint.stackApp); updateErr != nil {
+					log.WithError(updateErr).Warnf("Could not restore stack app %+v", *rollbackPoint.stackApp)
+				}
+
+				rolledBackFrom := app.StackApp
+				if rolledBackFrom == nil {
+					rolledBackFrom = d.makeStackApp(ctx, app)
+				}
+				historyEntry := d.makeStackHistoryEntry(rolledBackFrom, *rollbackPoint.stackApp, nil)
+				historyEntry.Outcome = kube.StackHistoryOutcomeRolledBack
+				if historyErr := stack.RecordHistory(historyEntry); historyErr != nil {
+					log.WithError(historyErr).Warn("Could not record rollback in stack history.")
+				}
+			}
 		}
 
+		if d.AfterRollback != nil {
+			d.AfterRollback(app, rollbackPoint.revision, err)
+		}
 	}
 
-	return nil
+	return errs.ToError()
 }
 
 //
@@ -0,0 +1,275 @@
+package bosun
+
+import (
+	"fmt"
+	"github.com/naveego/bosun/pkg/actions"
+	"github.com/naveego/bosun/pkg/core"
+	"github.com/naveego/bosun/pkg/filter"
+	"github.com/naveego/bosun/pkg/kube"
+	"github.com/naveego/bosun/pkg/util/worker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeDeployStack records the changes a deploy makes to the stack.
+type fakeDeployStack struct {
+	updated []kube.StackApp
+	removed []string
+	history []kube.StackHistoryEntry
+}
+
+func (f *fakeDeployStack) UpdateApp(updates ...kube.StackApp) error {
+	f.updated = append(f.updated, updates...)
+	return nil
+}
+
+func (f *fakeDeployStack) RemoveApp(names ...string) error {
+	f.removed = append(f.removed, names...)
+	return nil
+}
+
+func (f *fakeDeployStack) RecordHistory(entries ...kube.StackHistoryEntry) error {
+	f.history = append(f.history, entries...)
+	return nil
+}
+
+var _ = Describe("Deploy", func() {
+
+	var ctx BosunContext
+	var mu sync.Mutex
+	var deployed []string
+	var inFlight, maxInFlight int
+
+	appDeploy := func(name string, dependsOn ...string) *AppDeploy {
+		appConfig := &AppConfig{}
+		appConfig.Name = name
+		for _, dep := range dependsOn {
+			appConfig.DependsOn = append(appConfig.DependsOn, Dependency{Name: dep})
+		}
+		return &AppDeploy{Name: name, AppConfig: appConfig}
+	}
+
+	// deployApp records the apps it deploys and fails the apps in failures.
+	deployApp := func(failures ...string) func(BosunContext, *AppDeploy, *deployRun) error {
+		return func(_ BosunContext, app *AppDeploy, _ *deployRun) error {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			inFlight--
+			deployed = append(deployed, app.Name)
+			for _, failure := range failures {
+				if app.Name == failure {
+					return errors.New("deploy failed")
+				}
+			}
+			return nil
+		}
+	}
+
+	newDeploy := func(concurrency int, apps ...*AppDeploy) *Deploy {
+		return &Deploy{
+			DeploySettings: &DeploySettings{Concurrency: concurrency},
+			AppDeploys:     apps,
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = NewTestBosunContext()
+		deployed = nil
+		inFlight, maxInFlight = 0, 0
+	})
+
+	It("deploys apps after the apps they depend on", func() {
+		sut := newDeploy(1, appDeploy("web", "api"), appDeploy("api", "db"), appDeploy("db"), appDeploy("cache"))
+		Expect(sut.deployApps(ctx, &deployRun{mu: new(sync.Mutex)}, deployApp())).To(Succeed())
+		Expect(deployed).To(Equal([]string{"db", "api", "web", "cache"}))
+		Expect(maxInFlight).To(Equal(1))
+	})
+
+	It("deploys independent apps concurrently", func() {
+		sut := newDeploy(3, appDeploy("web", "api", "cache"), appDeploy("api"), appDeploy("cache"))
+		Expect(sut.deployApps(ctx, &deployRun{mu: new(sync.Mutex)}, deployApp())).To(Succeed())
+		Expect(maxInFlight).To(Equal(2))
+		Expect(deployed).To(HaveLen(3))
+		Expect(deployed[2]).To(Equal("web"))
+	})
+
+	It("deploys every instance of an app one at a time", func() {
+		sut := newDeploy(3, appDeploy("api"), appDeploy("api"), appDeploy("api"))
+		Expect(sut.deployApps(ctx, &deployRun{mu: new(sync.Mutex)}, deployApp())).To(Succeed())
+		Expect(deployed).To(Equal([]string{"api", "api", "api"}))
+		Expect(maxInFlight).To(Equal(1))
+	})
+
+	It("skips the apps which depend on a failed app but deploys the others", func() {
+		var afterDeploy []string
+		sut := newDeploy(2, appDeploy("web", "api"), appDeploy("api"), appDeploy("cache"))
+		sut.AfterDeploy = func(app *AppDeploy, err error) {
+			_, skipped := err.(worker.DependencyFailedError)
+			Expect(skipped).To(BeTrue())
+			afterDeploy = append(afterDeploy, app.Name)
+		}
+
+		err := sut.deployApps(ctx, &deployRun{mu: new(sync.Mutex)}, deployApp("api"))
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`deploy "api": deploy failed`))
+		Expect(err.Error()).To(ContainSubstring(`deploy "web": "web" was not run because its dependency "api" failed`))
+		Expect(deployed).To(ConsistOf("api", "cache"))
+		Expect(afterDeploy).To(Equal([]string{"web"}))
+	})
+})
+
+var _ = Describe("Deploy rollback", func() {
+
+	var dir string
+	var logPath string
+	var originalPath string
+	var ctx BosunContext
+	var stack *fakeDeployStack
+	var rolledBack []string
+	var sut *Deploy
+	var run *deployRun
+
+	writeExe := func(name string, script string) {
+		content := fmt.Sprintf("#!/bin/sh\necho \"%s $*\" >> %q\n%s\n", name, logPath, script)
+		Expect(ioutil.WriteFile(filepath.Join(dir, "bin", name), []byte(content), 0700)).To(Succeed())
+	}
+
+	calls := func() []string {
+		b, _ := ioutil.ReadFile(logPath)
+		return strings.Split(strings.TrimSpace(string(b)), "\n")
+	}
+
+	record := func(schedule actions.ActionSchedule, app string) *actions.AppAction {
+		script := actions.ScriptAction(fmt.Sprintf("echo %s %s >> %q", schedule, app, logPath))
+		return &actions.AppAction{
+			ConfigShared: core.ConfigShared{Name: string(schedule)},
+			When:         actions.ActionSchedules{schedule},
+			Script:       &script,
+		}
+	}
+
+	rollbackPoint := func(name string, revision string) appRollbackPoint {
+		appConfig := &AppConfig{
+			Actions: []*actions.AppAction{
+				record(actions.ActionBeforeRollback, name),
+				record(actions.ActionBeforeDelete, name),
+				record(actions.ActionAfterDelete, name),
+			},
+		}
+		appConfig.Name = name
+		bosunFile := filepath.Join(dir, name+".yaml")
+		Expect(ioutil.WriteFile(bosunFile, []byte("name: "+name+"\n"), 0600)).To(Succeed())
+		appConfig.SetFromPath(bosunFile)
+		app := &AppDeploy{
+			Name:        name,
+			FromPath:    bosunFile,
+			AppConfig:   appConfig,
+			AppManifest: &AppManifest{AppMetadata: &AppMetadata{Name: name}, AppConfig: appConfig},
+			StackApp:    &kube.StackApp{Name: name, Version: "2.0.0"},
+		}
+		point := appRollbackPoint{app: app, revision: revision}
+		if revision != "" {
+			point.stackApp = &kube.StackApp{Name: name, Version: "1.0.0"}
+		}
+		return point
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "bosun-deploy-rollback")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.Mkdir(filepath.Join(dir, "bin"), 0700)).To(Succeed())
+		logPath = filepath.Join(dir, "calls.log")
+		writeExe("helm", "")
+		originalPath = os.Getenv("PATH")
+		Expect(os.Setenv("PATH", filepath.Join(dir, "bin")+string(os.PathListSeparator)+originalPath)).To(Succeed())
+
+		ctx = NewTestBosunContext()
+		ctx.Bosun.params.NoEnvironment = true
+		ctx.exactMatchArgs = filter.MatchMapArgs{}
+
+		stack = &fakeDeployStack{}
+		rolledBack = nil
+		sut = &Deploy{DeploySettings: &DeploySettings{
+			AfterRollback: func(app *AppDeploy, revision string, err error) {
+				Expect(err).ToNot(HaveOccurred())
+				rolledBack = append(rolledBack, app.Name+"@"+revision)
+			},
+		}}
+		run = &deployRun{mu: new(sync.Mutex), rollbackPoints: []appRollbackPoint{
+			rollbackPoint("api", "3"),
+			rollbackPoint("web", ""),
+		}}
+	})
+
+	AfterEach(func() {
+		_ = os.Setenv("PATH", originalPath)
+		_ = os.RemoveAll(dir)
+	})
+
+	It("rolls back apps in reverse order and uninstalls apps which were not installed before", func() {
+		Expect(sut.rollback(ctx, stack, run)).To(Succeed())
+
+		Expect(calls()).To(Equal([]string{
+			"BeforeRollback web",
+			"helm delete web --namespace default",
+			"BeforeRollback api",
+			"helm rollback api 3 --namespace default",
+		}))
+		Expect(rolledBack).To(Equal([]string{"web@" + RollbackRevisionUninstall, "api@3"}))
+
+		Expect(stack.removed).To(Equal([]string{"web"}))
+		Expect(stack.updated).To(Equal([]kube.StackApp{{Name: "api", Version: "1.0.0"}}))
+		Expect(stack.history).To(HaveLen(2))
+		Expect(stack.history[0].App).To(Equal("web"))
+		Expect(stack.history[0].Outcome).To(Equal(kube.StackHistoryOutcomeUninstalled))
+		Expect(stack.history[0].Previous.Version).To(Equal("2.0.0"))
+		Expect(stack.history[1].App).To(Equal("api"))
+		Expect(stack.history[1].Outcome).To(Equal(kube.StackHistoryOutcomeRolledBack))
+		Expect(stack.history[1].Current.Version).To(Equal("1.0.0"))
+	})
+
+	It("doesn't uninstall apps or change the stack during a dry run", func() {
+		ctx.Bosun.params.DryRun = true
+
+		Expect(sut.rollback(ctx, stack, run)).To(Succeed())
+
+		log := calls()
+		Expect(log).ToNot(ContainElement(HavePrefix("helm delete")))
+		Expect(log).To(ContainElement("helm rollback api 3 --namespace default --dry-run"))
+		Expect(rolledBack).To(Equal([]string{"web@" + RollbackRevisionUninstall, "api@3"}))
+		Expect(stack.removed).To(BeEmpty())
+		Expect(stack.updated).To(BeEmpty())
+		Expect(stack.history).To(BeEmpty())
+	})
+
+	It("collects the apps which could not be rolled back", func() {
+		writeExe("helm", "exit 1")
+		sut.AfterRollback = nil
+
+		err := sut.rollback(ctx, stack, run)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`uninstall "web"`))
+		Expect(err.Error()).To(ContainSubstring(`rollback "api"`))
+		Expect(stack.removed).To(BeEmpty())
+		Expect(stack.updated).To(BeEmpty())
+	})
+})
@@ -25,6 +25,7 @@ type DeploymentPlan struct {
 	DirectoryPath            string                   `yaml:"-"`
 	ProviderPriority         []string                 `yaml:"providerPriority"`
 	SkipDependencyValidation bool                     `yaml:"skipDependencyValidation"`
+	SkipCompatibilityCheck   bool                     `yaml:"skipCompatibilityCheck,omitempty"`
 	ValueOverrides           values.ValueSet          `yaml:"valueOverrides"`
 	AppDeploymentProgress    []*AppDeploymentProgress `yaml:"deployedApps"`
 	Apps                     []*AppDeploymentPlan     `yaml:"apps"`
@@ -38,6 +39,12 @@ type AppDeploymentProgress struct {
 	Hash      string    `yaml:"hash"`
 	Timestamp time.Time `yaml:"timestamp"`
 	Error     string    `yaml:"error,omitempty"`
+	// Hash of the values the app was deployed with, used to detect drift.
+	ValuesHash string `yaml:"valuesHash,omitempty"`
+	// The helm revision the app was rolled back to after a failed deploy, if any,
+	// or "uninstall" if the app was not installed before the deploy and was removed.
+	RolledBackToRevision string `yaml:"rolledBackToRevision,omitempty"`
+	RollbackError        string `yaml:"rollbackError,omitempty"`
 }
 
 type AppDeploymentProgressReport struct {
@@ -223,7 +230,10 @@ func (d *DeploymentPlan) RecordProgress(app *AppDeploy, stack brns.StackBrn, e e
 		if progress.AppName == app.Name && progress.Stack == stack.String() {
 			progress.Timestamp = time.Now()
 			progress.Hash = app.AppManifest.Hashes.Summarize()
+			progress.ValuesHash = app.valuesHash
 			progress.Error = errMessage
+			progress.RolledBackToRevision = ""
+			progress.RollbackError = ""
 			return
 		}
 	}
@@ -231,17 +241,53 @@ func (d *DeploymentPlan) RecordProgress(app *AppDeploy, stack brns.StackBrn, e e
 	d.AppDeploymentProgress = append(d.AppDeploymentProgress, &AppDeploymentProgress{
 		AppName:   app.Name,
 		Stack:     stack.String(),
-		Hash:      app.AppManifest.Hashes.Summarize(),
-		Timestamp: time.Now(),
-		Error:     errMessage,
+		Hash:       app.AppManifest.Hashes.Summarize(),
+		ValuesHash: app.valuesHash,
+		Timestamp:  time.Now(),
+		Error:      errMessage,
 	})
 }
 
+// RecordRollback records that an app deployed from this plan was rolled back after
+// a failed deploy. The app will be deployed again the next time the plan is executed.
+func (d *DeploymentPlan) RecordRollback(app *AppDeploy, stack brns.StackBrn, revision string, e error) {
+
+	rollbackError := ""
+	if e != nil {
+		rollbackError = e.Error()
+	}
+
+	var progress *AppDeploymentProgress
+	for _, p := range d.AppDeploymentProgress {
+		if p.AppName == app.Name && p.Stack == stack.String() {
+			progress = p
+			break
+		}
+	}
+
+	if progress == nil {
+		progress = &AppDeploymentProgress{
+			AppName: app.Name,
+			Stack:   stack.String(),
+			Hash:    app.AppManifest.Hashes.Summarize(),
+		}
+		d.AppDeploymentProgress = append(d.AppDeploymentProgress, progress)
+	}
+
+	progress.Timestamp = time.Now()
+	progress.RolledBackToRevision = revision
+	progress.RollbackError = rollbackError
+	if progress.Error == "" {
+		progress.Error = "rolled back because another app in the plan failed to deploy"
+	}
+}
+
 func (d *DeploymentPlan) FindDeploymentPlanProgress(app *AppManifest, stack brns.StackBrn, ) *AppDeploymentProgress {
 	for _, progress := range d.AppDeploymentProgress {
 		if progress.AppName == app.Name &&
 			progress.Stack == stack.String() &&
-			progress.Hash == app.Hashes.Summarize() {
+			progress.Hash == app.Hashes.Summarize() &&
+			progress.Error == "" {
 			return progress
 		}
 	}
@@ -24,6 +24,8 @@ type CreateDeploymentPlanRequest struct {
 	AutomaticDependencies bool
 	ReleaseVersion        *semver.Version
 	BasedOnHash           string
+	// SkipCompatibilityCheck skips checking the apps against the version ranges of their dependencies.
+	SkipCompatibilityCheck bool
 }
 
 func NewDeploymentPlanCreator(bosun *Bosun, platform *Platform) DeploymentPlanCreator {
@@ -82,6 +84,7 @@ func (d DeploymentPlanCreator) CreateDeploymentPlan(req CreateDeploymentPlanRequ
 		DirectoryPath:            dir,
 		ProviderPriority:         req.ProviderPriority,
 		SkipDependencyValidation: req.IgnoreDependencies,
+		SkipCompatibilityCheck:   req.SkipCompatibilityCheck,
 		DeployApps:               map[string]bool{},
 		ReleaseVersion:           req.ReleaseVersion,
 		BasedOnHash:              req.BasedOnHash,
@@ -152,5 +155,11 @@ func (d DeploymentPlanCreator) CreateDeploymentPlan(req CreateDeploymentPlanRequ
 		plan.DeployApps[appPlan.Name] = true
 	}
 
+	if !req.SkipCompatibilityCheck {
+		if err = plan.CheckDependencyCompatibility().Err(); err != nil {
+			return nil, err
+		}
+	}
+
 	return plan, nil
 }
@@ -0,0 +1,183 @@
+package bosun
+
+import (
+	"fmt"
+	"github.com/naveego/bosun/pkg/command"
+	"github.com/naveego/bosun/pkg/core"
+	"github.com/naveego/bosun/pkg/helm"
+	"github.com/naveego/bosun/pkg/util/stringsn"
+	"github.com/naveego/bosun/pkg/values"
+	"github.com/naveego/bosun/pkg/yaml"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+type DetectDriftRequest struct {
+	Plan        *DeploymentPlan
+	IncludeApps []string
+}
+
+// DetectDrift compares the apps deployed from a plan with what is actually
+// running in the current stack. In addition to the checks made by
+// GetDeploymentProgressReportForStack, an app is out of sync if the commit
+// recorded in the stack doesn't match the plan, or if the live helm release
+// is missing, failed, on a different chart version, or has different values
+// than the ones it was deployed with (for example after a manual `helm upgrade`).
+func (d DeploymentPlanExecutor) DetectDrift(req DetectDriftRequest) (AddDeploymentProgressReports, error) {
+
+	ctx := d.Bosun.NewContext()
+	env := ctx.Environment()
+	stack := ctx.Stack()
+
+	stackState, err := stack.GetState(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "get stack state")
+	}
+
+	var out AddDeploymentProgressReports
+
+	for _, report := range req.Plan.GetDeploymentProgressReportForStack(env, stack) {
+
+		if len(req.IncludeApps) > 0 && !stringsn.Contains(req.IncludeApps, report.Plan.Name) {
+			continue
+		}
+
+		appLog := ctx.Log().WithField("app", report.Plan.Name)
+
+		if env.IsAppDisabled(report.Plan.Name) || stack.IsAppDisabled(report.Plan.Name) {
+			// Disabled apps are expected to differ from the plan.
+			report.OutOfSync = false
+			out = append(out, report)
+			continue
+		}
+
+		if report.Progress.Timestamp.IsZero() {
+			out = append(out, report)
+			continue
+		}
+
+		var reasons []string
+		if report.OutOfSync {
+			reasons = append(reasons, report.Status)
+		}
+		if report.Progress.Error != "" {
+			reasons = append(reasons, "last deploy failed")
+		}
+
+		manifest := report.Plan.Manifest
+
+		if stackApp, ok := stackState.DeployedApps[report.Plan.Name]; !ok {
+			reasons = append(reasons, "not recorded in stack")
+		} else if stackApp.Commit != manifest.Hashes.Commit {
+			reasons = append(reasons, fmt.Sprintf("stack has commit %q but plan has %q", stackApp.Commit, manifest.Hashes.Commit))
+		}
+
+		namespaces, namespaceErr := d.getNamespacesForApp(ctx, report.Plan.Name)
+		if namespaceErr != nil {
+			return nil, namespaceErr
+		}
+
+		for _, namespace := range namespaces {
+			appLog.Debugf("Checking live release in namespace %q...", namespace)
+			liveReasons, liveErr := getHelmReleaseDrift(manifest, report.Progress, namespace)
+			if liveErr != nil {
+				return nil, errors.Wrapf(liveErr, "check live release of %q in namespace %q", report.Plan.Name, namespace)
+			}
+			reasons = append(reasons, liveReasons...)
+		}
+
+		if len(reasons) > 0 {
+			report.OutOfSync = true
+			report.Status = strings.Join(reasons, "; ")
+		} else {
+			report.OutOfSync = false
+			report.Status = "In sync"
+		}
+
+		out = append(out, report)
+	}
+
+	return out, nil
+}
+
+// OutOfSync returns the reports for apps which are out of sync.
+func (a AddDeploymentProgressReports) OutOfSync() AddDeploymentProgressReports {
+	var out AddDeploymentProgressReports
+	for _, r := range a {
+		if r.OutOfSync {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (d DeploymentPlanExecutor) getNamespacesForApp(ctx BosunContext, appName string) ([]string, error) {
+	namespaceRoles := core.NamespaceRoles{core.NamespaceRoleDefault}
+	for _, platformAppConfig := range d.Platform.GetApps(ctx) {
+		if platformAppConfig.Name == appName && len(platformAppConfig.NamespaceRoles) > 0 {
+			namespaceRoles = platformAppConfig.NamespaceRoles
+		}
+	}
+
+	var out []string
+	for _, role := range namespaceRoles {
+		namespace, err := ctx.Stack().GetNamespace(role)
+		if err != nil {
+			return nil, errors.Wrapf(err, "mapping namespace for %q", appName)
+		}
+		if !stringsn.Contains(out, namespace.Name) {
+			out = append(out, namespace.Name)
+		}
+	}
+	return out, nil
+}
+
+func getHelmReleaseDrift(manifest *AppManifest, progress AppDeploymentProgress, namespace string) ([]string, error) {
+
+	probe := &AppDeploy{}
+	releases, err := probe.GetHelmList(fmt.Sprintf(`^%s$`, manifest.Name), namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(releases) == 0 {
+		return []string{fmt.Sprintf("no helm release in namespace %q", namespace)}, nil
+	}
+
+	release := releases[0]
+
+	var reasons []string
+
+	if !strings.EqualFold(release.Status, "deployed") {
+		reasons = append(reasons, fmt.Sprintf("helm release status is %q", release.Status))
+	}
+
+	// helm reports the chart as name-version, without the repo the chart came from
+	expectedChart := fmt.Sprintf("%s-%s", helm.ChartHandle(manifest.AppConfig.Chart).GetChartName(), manifest.Version)
+	if manifest.AppConfig.Chart != "" && release.Chart != expectedChart {
+		reasons = append(reasons, fmt.Sprintf("helm release chart is %q but plan has %q", release.Chart, expectedChart))
+	}
+
+	if progress.ValuesHash != "" {
+		out, getErr := command.NewShellExe("helm", "get", "values", manifest.Name, "--namespace", namespace, "--output", "yaml").RunOut()
+		if getErr != nil {
+			return nil, errors.Wrap(getErr, "get helm release values")
+		}
+
+		liveValues := values.Values{}
+		if err = yaml.UnmarshalString(out, &liveValues); err != nil {
+			return nil, errors.Wrap(err, "parse helm release values")
+		}
+
+		liveHash, hashErr := liveValues.Hash()
+		if hashErr != nil {
+			return nil, hashErr
+		}
+
+		if liveHash != progress.ValuesHash {
+			reasons = append(reasons, "helm release values differ from the values deployed by the plan")
+		}
+	}
+
+	return reasons, nil
+}
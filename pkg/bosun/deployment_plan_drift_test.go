@@ -0,0 +1,97 @@
+package bosun
+
+import (
+	"fmt"
+	"github.com/naveego/bosun/pkg/semver"
+	"github.com/naveego/bosun/pkg/values"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("DeploymentPlan drift", func() {
+
+	var dir string
+	var originalPath string
+
+	deployedValues := values.Values{"replicaCount": 2, "image": values.Values{"tag": "1.2.3"}}
+
+	// fakeHelm makes helm list the release and return the values.
+	fakeHelm := func(release string, liveValues string) {
+		script := fmt.Sprintf("#!/bin/sh\nif [ \"$1\" = \"list\" ]; then\ncat <<'EOF'\n%s\nEOF\nelse\ncat <<'EOF'\n%s\nEOF\nfi\n", release, liveValues)
+		Expect(ioutil.WriteFile(filepath.Join(dir, "bin", "helm"), []byte(script), 0700)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "bosun-deployment-plan-drift")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.Mkdir(filepath.Join(dir, "bin"), 0700)).To(Succeed())
+		originalPath = os.Getenv("PATH")
+		Expect(os.Setenv("PATH", filepath.Join(dir, "bin")+string(os.PathListSeparator)+originalPath)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		_ = os.Setenv("PATH", originalPath)
+		_ = os.RemoveAll(dir)
+	})
+
+	DescribeTable("getHelmReleaseDrift",
+		func(release string, liveValues string, expected []string) {
+			fakeHelm(release, liveValues)
+
+			appConfig := &AppConfig{Chart: "helm.n5o.black/api"}
+			appConfig.Name = "api"
+			manifest := &AppManifest{
+				AppMetadata: &AppMetadata{Name: "api", Version: semver.MustParse("1.2.3")},
+				AppConfig:   appConfig,
+			}
+			valuesHash, err := deployedValues.Hash()
+			Expect(err).ToNot(HaveOccurred())
+
+			reasons, err := getHelmReleaseDrift(manifest, AppDeploymentProgress{ValuesHash: valuesHash}, "default")
+
+			Expect(err).ToNot(HaveOccurred())
+			if expected == nil {
+				Expect(reasons).To(BeEmpty())
+			} else {
+				Expect(reasons).To(Equal(expected))
+			}
+		},
+		Entry("is in sync when the release matches the plan",
+			"- name: api\n  status: deployed\n  chart: api-1.2.3",
+			"image:\n  tag: 1.2.3\nreplicaCount: 2",
+			nil),
+		Entry("is in sync when the live values are in a different order",
+			"- name: api\n  status: deployed\n  chart: api-1.2.3",
+			"replicaCount: 2\nimage:\n  tag: 1.2.3",
+			nil),
+		Entry("reports a missing release",
+			"",
+			"",
+			[]string{`no helm release in namespace "default"`}),
+		Entry("reports a failed release",
+			"- name: api\n  status: failed\n  chart: api-1.2.3",
+			"image:\n  tag: 1.2.3\nreplicaCount: 2",
+			[]string{`helm release status is "failed"`}),
+		Entry("reports a release on a different chart version",
+			"- name: api\n  status: deployed\n  chart: api-1.2.2",
+			"image:\n  tag: 1.2.3\nreplicaCount: 2",
+			[]string{`helm release chart is "api-1.2.2" but plan has "api-1.2.3"`}),
+		Entry("reports values which were changed after the deploy",
+			"- name: api\n  status: deployed\n  chart: api-1.2.3",
+			"image:\n  tag: 1.2.3\nreplicaCount: 5",
+			[]string{"helm release values differ from the values deployed by the plan"}),
+		Entry("reports every difference",
+			"- name: api\n  status: pending-upgrade\n  chart: api-1.0.0",
+			"image:\n  tag: 1.0.0",
+			[]string{
+				`helm release status is "pending-upgrade"`,
+				`helm release chart is "api-1.0.0" but plan has "api-1.2.3"`,
+				"helm release values differ from the values deployed by the plan",
+			}),
+	)
+})
@@ -30,6 +30,10 @@ type ExecuteDeploymentPlanRequest struct {
 	DiffOnly       bool
 	UseSudo        bool
 	RenderOnly     bool
+	// The maximum number of apps to deploy at the same time.
+	Concurrency int
+	// If true, all apps deployed by this execution are rolled back if any app fails.
+	RollbackOnFailure bool
 }
 
 type ExecuteDeploymentPlanResponse struct {
@@ -87,6 +91,9 @@ func (d DeploymentPlanExecutor) Execute(req ExecuteDeploymentPlanRequest) (Execu
 		AppDeploySettings:  map[string]AppDeploySettings{},
 		ValueSets:          append([]values.ValueSet{deploymentPlan.ValueOverrides}, req.ValueSets...),
 		IgnoreDependencies: true,
+		Concurrency:        req.Concurrency,
+		RollbackOnFailure:  req.RollbackOnFailure,
+		ReleaseHash:           deploymentPlan.BasedOnHash,
 	}
 
 	env := ctx.Environment()
@@ -98,11 +105,29 @@ func (d DeploymentPlanExecutor) Execute(req ExecuteDeploymentPlanRequest) (Execu
 			afterLog := ctx.Log().WithFields(logrus.Fields{
 				"app":       app.Name,
 			})
-			afterLog.Info("App deployed, saving progress in plan file.")
+			if err != nil {
+				afterLog.WithError(err).Warn("App deploy failed, saving progress in plan file.")
+			} else {
+				afterLog.Info("App deployed, saving progress in plan file.")
+			}
 			deploymentPlan.RecordProgress(app, ctx.Stack().Brn, err)
 			saveErr := deploymentPlan.SavePlanFileOnly()
 			if saveErr != nil {
-				afterLog.WithError(saveErr).Error("Progress save failed: %s")
+				afterLog.WithError(saveErr).Error("Progress save failed.")
+			}
+		}
+
+		deploySettings.AfterRollback = func(app *AppDeploy, revision string, err error) {
+
+			afterLog := ctx.Log().WithFields(logrus.Fields{
+				"app":      app.Name,
+				"revision": revision,
+			})
+			afterLog.Info("App rolled back, saving progress in plan file.")
+			deploymentPlan.RecordRollback(app, ctx.Stack().Brn, revision, err)
+			saveErr := deploymentPlan.SavePlanFileOnly()
+			if saveErr != nil {
+				afterLog.WithError(saveErr).Error("Progress save failed.")
 			}
 		}
 
@@ -244,5 +269,20 @@ func (d DeploymentPlanExecutor) validateDeploymentPlan(req ExecuteDeploymentPlan
 
 	_ = t.Wait()
 
+	if !plan.SkipCompatibilityCheck {
+		matrix := plan.CheckDependencyCompatibility()
+		failures := matrix.Failures()
+		if len(failures) > 0 {
+			ctx.Log().Errorf("Apps in plan are not compatible with their dependencies:\n%s", matrix)
+		}
+		for _, failure := range failures {
+			message := failure.Message()
+			if existing, ok := response[failure.App]; ok {
+				message = existing + "; " + message
+			}
+			response[failure.App] = message
+		}
+	}
+
 	return response, nil
 }
@@ -1,14 +1,22 @@
 package bosun
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/naveego/bosun/pkg/core"
 	"github.com/naveego/bosun/pkg/mongo"
 	"github.com/naveego/bosun/pkg/script"
+	"github.com/naveego/bosun/pkg/sqldb"
+	"github.com/naveego/bosun/pkg/util/worker"
 	"github.com/naveego/bosun/pkg/values"
 	"github.com/pkg/errors"
 	"github.com/rs/xid"
+	"github.com/sirupsen/logrus"
+	"io"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,6 +24,7 @@ type E2ESuiteConfig struct {
 	core.ConfigShared `yaml:",inline"`
 	E2EBookendScripts `yaml:",inline"`
 	MongoConnections  map[string]mongo.Connection `yaml:"mongoConnections,omitempty"`
+	SQLConnections    map[string]sqldb.Connection `yaml:"sqlConnections,omitempty"`
 	TestFiles         []string                    `yaml:"tests"`
 	Tests             []*E2ETestConfig            `yaml:"-"`
 }
@@ -26,12 +35,19 @@ type E2ETestConfig struct {
 	Dependencies      []*Dependency          `yaml:"dependencies,omitempty" json:"dependencies,omitempty"`
 	Variables         map[string]interface{} `yaml:"variables,omitempty" json:"variables"`
 	Steps             []script.ScriptStep    `yaml:"steps,omitempty" json:"steps,omitempty"`
+	// Independent means the test doesn't depend on or interfere with other tests,
+	// so it can be run at the same time as other independent tests.
+	Independent bool `yaml:"independent,omitempty" json:"independent,omitempty"`
 }
 
 type E2EContext struct {
 	SkipSetup    bool
 	SkipTeardown bool
 	Tests        []string
+	// KeepGoing means the remaining tests are still run, in order, after a test fails.
+	KeepGoing bool
+	// Parallelism is the number of independent tests which can be run at the same time.
+	Parallelism int
 }
 
 const e2eContextKey = "e2e.context"
@@ -72,7 +88,8 @@ type E2EStepConfig struct {
 
 type E2ESuite struct {
 	E2ESuiteConfig
-	PreparedConnections []mongo.PreparedConnection
+	PreparedConnections    []mongo.PreparedConnection
+	PreparedSQLConnections []sqldb.PreparedConnection
 }
 
 type E2EBookendScripts struct {
@@ -174,6 +191,9 @@ func (s *E2ESuite) Run(ctx BosunContext, tests ...string) ([]*E2EResult, error)
 		for _, p := range s.PreparedConnections {
 			p.CleanUp()
 		}
+		for _, p := range s.PreparedSQLConnections {
+			p.CleanUp()
+		}
 	}()
 
 	// populate context with mongo connections needed by scripts
@@ -188,6 +208,16 @@ func (s *E2ESuite) Run(ctx BosunContext, tests ...string) ([]*E2EResult, error)
 		run.Ctx = run.Ctx.WithValue(k, v).(BosunContext)
 	}
 
+	// populate context with sql connections needed by scripts
+	for k, v := range s.SQLConnections {
+		p, err := v.Prepare(ctx.Log())
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not prepare suite sql connection %q", k)
+		}
+		s.PreparedSQLConnections = append(s.PreparedSQLConnections, p)
+		run.Ctx = run.Ctx.WithValue(k, v).(BosunContext)
+	}
+
 	// get the configs which should be in this run
 	if len(tests) == 0 {
 		run.Configs = s.Tests
@@ -243,20 +273,116 @@ func (r *E2ERun) Execute() error {
 		}
 	}()
 
-	// execute all the tests
-	// right now we stop running if a test fails, we may want to change that in the future
-	for _, test := range r.Tests {
-		result, err := test.Execute(r.Ctx)
-		if err != nil {
-			return errors.Wrapf(err, "test %q errored", test.Name)
+	e2eCtx := GetE2EContext(r.Ctx)
+	parallelism := e2eCtx.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	// Independent tests can run at the same time as each other, but other tests
+	// run only after every test before them has finished, and before any test after them.
+	// The graph only orders the tests: whether a test is run after an earlier test failed
+	// is decided by KeepGoing.
+	graph := worker.NewDependencyGraph()
+	var barrier []string
+	var sinceBarrier []string
+	for i, test := range r.Tests {
+		key := strconv.Itoa(i)
+		if test.Independent && parallelism > 1 {
+			graph.After(key, barrier...)
+			sinceBarrier = append(sinceBarrier, key)
+			continue
+		}
+		graph.After(key, append(barrier, sinceBarrier...)...)
+		barrier = []string{key}
+		sinceBarrier = nil
+	}
+
+	results := make([]*E2EResult, len(r.Tests))
+	testErrs := make([]error, len(r.Tests))
+	mu := new(sync.Mutex)
+	failed := false
+
+	graphErrs := graph.Run(parallelism, func(key string) error {
+		i, _ := strconv.Atoi(key)
+		test := r.Tests[i]
+
+		mu.Lock()
+		skip := failed && !e2eCtx.KeepGoing
+		mu.Unlock()
+		if skip {
+			return errE2EEarlierTestFailed
+		}
+
+		ctx := r.Ctx
+		if test.Independent && parallelism > 1 {
+			ctx = r.getIndependentTestContext(i)
 		}
 
+		result, err := test.Execute(ctx)
+
+		mu.Lock()
+		defer mu.Unlock()
+		results[i] = result
+		if err != nil {
+			failed = true
+			testErrs[i] = errors.Wrapf(err, "test %q errored", test.Name)
+			return testErrs[i]
+		}
+		if result == nil || !result.Passed {
+			failed = true
+			return errors.Errorf("test %q failed", test.Name)
+		}
+		return nil
+	})
+
+	var errs []string
+	for i, test := range r.Tests {
+		result := results[i]
+		if result == nil {
+			reason := r.getSkipReason(graphErrs, strconv.Itoa(i))
+			r.Ctx.Log().WithField("test", test.Name).Warnf("Skipped test because %s.", reason)
+			result = test.getSkippedResult("skipped because " + reason)
+		}
 		r.Results = append(r.Results, result)
+		if testErrs[i] != nil {
+			errs = append(errs, testErrs[i].Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
 	}
 
 	return nil
 }
 
+var errE2EEarlierTestFailed = errors.New("an earlier test failed (use --keep-going to run the remaining tests)")
+
+// getSkipReason describes why the test with the key was not run, using the errors recorded by the dependency graph.
+func (r *E2ERun) getSkipReason(graphErrs map[string]error, key string) string {
+	if err := graphErrs[key]; err != nil {
+		return err.Error()
+	}
+	return "it was not run"
+}
+
+// getIndependentTestContext returns a context with its own copy of the run's values,
+// with a runID which is unique to the test, so that tests running at the same time
+// don't share values or resources named using the runID.
+func (r *E2ERun) getIndependentTestContext(index int) BosunContext {
+	testValues := values.Values{}
+	if releaseValues := r.Ctx.GetReleaseValues(); releaseValues != nil {
+		testValues = releaseValues.Values.Clone()
+	}
+	testValues.MustSetAtPath("e2e.runID", fmt.Sprintf("%s-%d", r.ID, index))
+	testValues.MustSetAtPath("e2e.suiteRunID", r.ID)
+
+	return r.Ctx.WithPersistableValues(&values.PersistableValues{
+		Values: testValues,
+	}).(BosunContext)
+}
+
 type E2ETest struct {
 	E2ETestConfig
 }
@@ -271,14 +397,20 @@ type E2EResult struct {
 	Name   string           `yaml:"name" json:"name"`
 	Steps  []*E2EStepResult `yaml:"steps" json:"steps"`
 	Passed bool             `yaml:"passed" json:"passed"`
-	Error  string           `yaml:"error,omitempty" json:"error,omitempty"`
-	Timed  `yaml:",inline"`
+	// Skipped is true if the test was not run because another test failed. Error contains the reason.
+	Skipped bool   `yaml:"skipped,omitempty" json:"skipped,omitempty"`
+	Error   string `yaml:"error,omitempty" json:"error,omitempty"`
+	Timed   `yaml:",inline"`
 }
 
 type E2EStepResult struct {
 	Name   string `yaml:"name" json:"name"`
 	Passed bool   `yaml:"passed" json:"passed"`
-	Error  string `yaml:"error,omitempty" json:"error,omitempty"`
+	// Skipped is true if the step was not run because an earlier step failed.
+	Skipped bool   `yaml:"skipped,omitempty" json:"skipped,omitempty"`
+	Error   string `yaml:"error,omitempty" json:"error,omitempty"`
+	// Output is the log output written while the step was running.
+	Output string `yaml:"output,omitempty" json:"output,omitempty"`
 	Timed  `yaml:",inline"`
 }
 
@@ -291,6 +423,27 @@ func (t *Timed) StopTimer() {
 	t.Elapsed = elapsed.String()
 }
 
+// getSkippedResult returns the result of a test which was not run, with a skipped
+// result for each of its steps so that the set of steps is the same in every run.
+func (e *E2ETest) getSkippedResult(reason string) *E2EResult {
+	result := &E2EResult{
+		Name:    e.Name,
+		Skipped: true,
+		Error:   reason,
+	}
+	for i, step := range e.Steps {
+		stepResult := &E2EStepResult{
+			Name:    step.Name,
+			Skipped: true,
+		}
+		if stepResult.Name == "" {
+			stepResult.Name = fmt.Sprint(i)
+		}
+		result.Steps = append(result.Steps, stepResult)
+	}
+	return result
+}
+
 func (e *E2ETest) Execute(ctx BosunContext) (*E2EResult, error) {
 	ctx = ctx.WithDir(e.FromPath).WithLog(ctx.Log().WithField("test", e.Name))
 
@@ -309,6 +462,7 @@ func (e *E2ETest) Execute(ctx BosunContext) (*E2EResult, error) {
 
 	if err := e.Setup(ctx); err != nil {
 		result.Passed = false
+		result.Error = err.Error()
 		return result, errors.Wrap(err, "test setup")
 	}
 
@@ -321,22 +475,72 @@ func (e *E2ETest) Execute(ctx BosunContext) (*E2EResult, error) {
 		if stepResult.Name == "" {
 			stepResult.Name = fmt.Sprint(i)
 		}
+		result.Steps = append(result.Steps, stepResult)
 
-		stepResult.StartTimer()
+		// steps after a failed step are still reported so that the set of tests is the same in every run
+		if !result.Passed {
+			stepResult.Passed = false
+			stepResult.Skipped = true
+			continue
+		}
 
-		err := step.Execute(ctx, i)
-		stepResult.StopTimer()
-		result.Steps = append(result.Steps, stepResult)
+		stepCtx, output := withCapturedLog(ctx)
 
+		stepResult.StartTimer()
+
+		err := step.Execute(stepCtx, i)
 		if err != nil {
-			ctx.Log().WithError(err).Error("Step failed.")
+			stepCtx.Log().WithError(err).Error("Step failed.")
 			result.Passed = false
 			result.Error = err.Error()
 			stepResult.Passed = false
 			stepResult.Error = err.Error()
-			break
 		}
+
+		stepResult.StopTimer()
+		stepResult.Output = output.String()
 	}
 
 	return result, nil
 }
+
+// withCapturedLog returns a context whose log output is also written, without colors, to the returned buffer.
+func withCapturedLog(ctx BosunContext) (BosunContext, *bytes.Buffer) {
+	entry := ctx.Log()
+	buffer := new(bytes.Buffer)
+
+	logger := logrus.New()
+	logger.Out = entry.Logger.Out
+	logger.Formatter = entry.Logger.Formatter
+	logger.Level = entry.Logger.Level
+	for level, hooks := range entry.Logger.Hooks {
+		logger.Hooks[level] = append(logger.Hooks[level], hooks...)
+	}
+	logger.AddHook(&logCaptureHook{
+		out:       buffer,
+		formatter: &logrus.TextFormatter{DisableColors: true},
+	})
+
+	return ctx.WithLog(logger.WithFields(entry.Data)), buffer
+}
+
+type logCaptureHook struct {
+	mu        sync.Mutex
+	out       io.Writer
+	formatter logrus.Formatter
+}
+
+func (l *logCaptureHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (l *logCaptureHook) Fire(entry *logrus.Entry) error {
+	b, err := l.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.out.Write(b)
+	return err
+}
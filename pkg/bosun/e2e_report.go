@@ -0,0 +1,211 @@
+package bosun
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	E2EReportFormatJUnit = "junit"
+	E2EReportFormatJSON  = "json"
+)
+
+// E2EReport contains the results of running an E2E suite, in a form which can be written
+// to a file for consumption by CI systems.
+type E2EReport struct {
+	Suite   string       `yaml:"suite" json:"suite"`
+	Passed  bool         `yaml:"passed" json:"passed"`
+	Error   string       `yaml:"error,omitempty" json:"error,omitempty"`
+	Results []*E2EResult `yaml:"results" json:"results"`
+}
+
+// NewE2EReport creates a report from the results of running a suite.
+// If runErr is not nil the report is marked as failed.
+func NewE2EReport(suiteName string, results []*E2EResult, runErr error) E2EReport {
+	report := E2EReport{
+		Suite:   suiteName,
+		Passed:  runErr == nil,
+		Results: results,
+	}
+	if runErr != nil {
+		report.Error = runErr.Error()
+	}
+	for _, result := range results {
+		report.Passed = report.Passed && result.Passed
+	}
+	return report
+}
+
+// WriteFile writes the report to path in the format, which must be "junit" or "json".
+func (r E2EReport) WriteFile(format string, path string) error {
+
+	var write func(w io.Writer) error
+	switch format {
+	case E2EReportFormatJUnit:
+		write = r.WriteJUnit
+	case E2EReportFormatJSON:
+		write = r.WriteJSON
+	default:
+		return errors.Errorf("unsupported report format %q (supported formats are %s and %s)", format, E2EReportFormatJUnit, E2EReportFormatJSON)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0770); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "create report file")
+	}
+	defer f.Close()
+
+	return write(f)
+}
+
+func (r E2EReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteJUnit writes the report as JUnit XML. Each test in the suite is a <testsuite>
+// and each step in a test is a <testcase>, named after the step, so that the names
+// are the same from one run to the next.
+func (r E2EReport) WriteJUnit(w io.Writer) error {
+
+	out := junitTestSuites{
+		Name: r.Suite,
+	}
+
+	for _, result := range r.Results {
+		suite := junitTestSuite{
+			Name:      fmt.Sprintf("%s.%s", r.Suite, result.Name),
+			Time:      formatJUnitTime(result.Timed),
+			Timestamp: result.StartedAt.Format("2006-01-02T15:04:05"),
+		}
+
+		// a test which was skipped or failed before running any steps is reported as a single case
+		if len(result.Steps) == 0 && result.Skipped {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      "setup",
+				ClassName: suite.Name,
+				Time:      formatJUnitTime(result.Timed),
+				Skipped:   &junitSkipped{Message: result.Error},
+			})
+		} else if len(result.Steps) == 0 && !result.Passed {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      "setup",
+				ClassName: suite.Name,
+				Time:      formatJUnitTime(result.Timed),
+				Failure: &junitFailure{
+					Message: firstLine(result.Error),
+					Type:    "error",
+					Text:    result.Error,
+				},
+			})
+		}
+
+		for _, step := range result.Steps {
+			testCase := junitTestCase{
+				Name:      step.Name,
+				ClassName: suite.Name,
+				Time:      formatJUnitTime(step.Timed),
+				SystemOut: step.Output,
+			}
+			switch {
+			case step.Skipped && result.Skipped:
+				testCase.Skipped = &junitSkipped{Message: result.Error}
+			case step.Skipped:
+				testCase.Skipped = &junitSkipped{Message: "skipped because an earlier step failed"}
+			case !step.Passed:
+				testCase.Failure = &junitFailure{
+					Message: firstLine(step.Error),
+					Type:    "failure",
+					Text:    step.Error,
+				}
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+
+		for _, testCase := range suite.TestCases {
+			suite.Tests++
+			if testCase.Failure != nil {
+				suite.Failures++
+			}
+			if testCase.Skipped != nil {
+				suite.Skipped++
+			}
+		}
+
+		out.Tests += suite.Tests
+		out.Failures += suite.Failures
+		out.Skipped += suite.Skipped
+		out.Suites = append(out.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return errors.Wrap(err, "encode junit report")
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+func formatJUnitTime(t Timed) string {
+	if t.StartedAt.IsZero() || t.EndedAt.IsZero() {
+		return "0"
+	}
+	return fmt.Sprintf("%.3f", t.EndedAt.Sub(t.StartedAt).Seconds())
+}
+
+func firstLine(s string) string {
+	return strings.SplitN(s, "\n", 2)[0]
+}
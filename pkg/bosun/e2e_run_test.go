@@ -0,0 +1,141 @@
+package bosun
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/naveego/bosun/pkg/command"
+	"github.com/naveego/bosun/pkg/core"
+	"github.com/naveego/bosun/pkg/script"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var _ = Describe("E2ERun", func() {
+
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "bosun-e2e")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	// ran returns the names of the tests which were run, in the order they were run.
+	ran := func() []string {
+		b, _ := ioutil.ReadFile(filepath.Join(dir, "ran.log"))
+		return strings.Fields(string(b))
+	}
+
+	newTest := func(name string, independent bool, passes bool) *E2ETest {
+		cmd := fmt.Sprintf("echo %s >> %q", name, filepath.Join(dir, "ran.log"))
+		if !passes {
+			cmd += " && false"
+		}
+		config := E2ETestConfig{
+			ConfigShared: core.ConfigShared{Name: name},
+			Independent:  independent,
+			Steps: []script.ScriptStep{{
+				ConfigShared: core.ConfigShared{Name: "check", FromPath: filepath.Join(dir, name+".yaml")},
+				Cmd:          &command.Command{Command: []string{"sh", "-c", cmd}},
+			}},
+		}
+		config.FromPath = filepath.Join(dir, name+".yaml")
+		return NewE2ETest(config)
+	}
+
+	execute := func(e2eCtx E2EContext, tests ...*E2ETest) (*E2ERun, error) {
+		ctx := NewTestBosunContext()
+		ctx.Bosun.params.NoEnvironment = true
+		run := &E2ERun{
+			ID:    "test",
+			Ctx:   WithE2EContext(ctx, e2eCtx),
+			Suite: &E2ESuite{},
+			Tests: tests,
+		}
+		err := run.Execute()
+		return run, err
+	}
+
+	summarize := func(run *E2ERun) map[string]string {
+		out := map[string]string{}
+		for _, result := range run.Results {
+			switch {
+			case result.Passed:
+				out[result.Name] = "passed"
+			case result.Skipped:
+				out[result.Name] = "skipped"
+			default:
+				out[result.Name] = "failed"
+			}
+		}
+		return out
+	}
+
+	It("records every test after a failure as skipped", func() {
+		run, err := execute(E2EContext{},
+			newTest("a", false, false),
+			newTest("b", false, true),
+			newTest("c", true, true))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summarize(run)).To(Equal(map[string]string{"a": "failed", "b": "skipped", "c": "skipped"}))
+
+		Expect(run.Results[1].Error).To(ContainSubstring("--keep-going"))
+		Expect(run.Results[2].Error).To(ContainSubstring("--keep-going"))
+		Expect(ran()).To(Equal([]string{"a"}))
+
+		skipped := run.Results[1]
+		Expect(skipped.Steps).To(HaveLen(1))
+		Expect(skipped.Steps[0].Name).To(Equal("check"))
+		Expect(skipped.Steps[0].Skipped).To(BeTrue())
+	})
+
+	It("runs every test when keeping going", func() {
+		run, err := execute(E2EContext{KeepGoing: true, Parallelism: 2},
+			newTest("a", false, false),
+			newTest("b", true, true),
+			newTest("c", true, false),
+			newTest("d", false, true),
+			newTest("e", true, true))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summarize(run)).To(Equal(map[string]string{
+			"a": "failed",
+			"b": "passed",
+			"c": "failed",
+			"d": "passed",
+			"e": "passed",
+		}))
+		Expect(ran()).To(HaveLen(5))
+		Expect(ran()[0]).To(Equal("a"))
+		Expect(ran()[3]).To(Equal("d"))
+	})
+
+	It("runs the tests after a failed test in order when keeping going", func() {
+		run, err := execute(E2EContext{KeepGoing: true},
+			newTest("a", false, false),
+			newTest("b", false, true),
+			newTest("c", false, true))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summarize(run)).To(Equal(map[string]string{"a": "failed", "b": "passed", "c": "passed"}))
+		Expect(ran()).To(Equal([]string{"a", "b", "c"}))
+	})
+
+	It("reports skipped tests as skipped in junit", func() {
+		run, err := execute(E2EContext{},
+			newTest("a", false, false),
+			newTest("b", true, true))
+		Expect(err).ToNot(HaveOccurred())
+
+		var buf bytes.Buffer
+		Expect(NewE2EReport("suite", run.Results, nil).WriteJUnit(&buf)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring(`<testsuites name="suite" tests="2" failures="1" skipped="1">`))
+		Expect(buf.String()).To(MatchRegexp(`<testsuite name="suite.b" tests="1" failures="0" skipped="1"[^>]*>\s*<testcase name="check" classname="suite.b" time="0">\s*<skipped message="skipped because an earlier test failed`))
+	})
+})
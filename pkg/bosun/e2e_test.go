@@ -2,12 +2,12 @@ package bosun_test
 
 import (
 	"fmt"
-	"github.com/naveego/bosun/pkg"
 	. "github.com/naveego/bosun/pkg/bosun"
 	"github.com/naveego/bosun/pkg/core"
 	"github.com/naveego/bosun/pkg/yaml"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"os"
 	"path/filepath"
 )
 
@@ -31,12 +31,15 @@ var _ = Describe("E2E", func() {
 	})
 
 	It("should load tests from yaml", func() {
+		if os.Getenv("VAULT_ADDR") == "" {
+			Skip("loading tests renders templates, which requires vault (VAULT_ADDR is not set)")
+		}
 		type container struct {
 			TestSuites []*E2ESuiteConfig `yaml:"testSuites"`
 		}
 		var c container
 		suitePath := filepath.Join(IntegrationTestDir, "testdata/e2e/simple-http-test/suite.yaml")
-		Expect(pkg.LoadYaml(suitePath, &c)).To(Succeed())
+		Expect(yaml.LoadYaml(suitePath, &c)).To(Succeed())
 		Expect(c.TestSuites).ToNot(BeEmpty())
 
 		config := c.TestSuites[0]
@@ -0,0 +1,218 @@
+package bosun
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/naveego/bosun/pkg/command"
+	"github.com/naveego/bosun/pkg/signing"
+	"github.com/naveego/bosun/pkg/util"
+	"github.com/naveego/bosun/pkg/yaml"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// BundleManifestFileName is the file in a bundle which lists the digest of every other file in the bundle.
+	BundleManifestFileName = "bundle-manifest.yaml"
+	// BundleSignatureFileName is the file in a bundle which contains the detached signature of the bundle manifest.
+	BundleSignatureFileName = "bundle-manifest.sig"
+
+	bundleDigestAlgorithm = "sha256"
+)
+
+// BundleSigningConfig configures the keys used to sign platform bundles and verify them before they are pushed.
+// Relative paths are relative to the workspace file.
+type BundleSigningConfig struct {
+	// PrivateKeyPath is the path to a PEM encoded ed25519 private key, or a cosign private key.
+	PrivateKeyPath string `yaml:"privateKeyPath,omitempty" json:"privateKeyPath,omitempty"`
+	// PublicKeyPath is the path to the PEM encoded public key used to verify bundles.
+	PublicKeyPath string `yaml:"publicKeyPath,omitempty" json:"publicKeyPath,omitempty"`
+	// Password is the password of an encrypted cosign private key.
+	// If it is not set the COSIGN_PASSWORD environment variable is used.
+	Password *command.CommandValue `yaml:"password,omitempty" json:"password,omitempty"`
+}
+
+// BundleManifest lists the digest of every file in a platform bundle, so that a
+// signature of the manifest proves the provenance of the whole bundle.
+type BundleManifest struct {
+	Platform  string    `yaml:"platform" json:"platform"`
+	CreatedAt time.Time `yaml:"createdAt" json:"createdAt"`
+	Algorithm string    `yaml:"algorithm" json:"algorithm"`
+	// Digests maps the slash separated path of each file, relative to the root of the bundle, to its digest.
+	Digests map[string]string `yaml:"digests" json:"digests"`
+}
+
+// CreateBundleManifest computes the digest of every file in dir.
+func CreateBundleManifest(dir string, platform string) (*BundleManifest, error) {
+	digests, err := digestBundleFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &BundleManifest{
+		Platform:  platform,
+		CreatedAt: time.Now().UTC(),
+		Algorithm: bundleDigestAlgorithm,
+		Digests:   digests,
+	}, nil
+}
+
+// Save writes the manifest to dir. If signer is not nil the signature of the manifest is written next to it.
+func (m *BundleManifest) Save(dir string, signer signing.Signer) error {
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err, "serialize bundle manifest")
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, BundleManifestFileName), data, 0600)
+	if err != nil {
+		return errors.Wrap(err, "write bundle manifest")
+	}
+	if signer == nil {
+		return nil
+	}
+	signature, err := signer.Sign(data)
+	if err != nil {
+		return errors.Wrap(err, "sign bundle manifest")
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, BundleSignatureFileName), signature, 0600)
+	return errors.Wrap(err, "write bundle signature")
+}
+
+// VerifyBundle checks that the manifest in dir was signed by the key of verifier and that
+// every file in dir matches the digest in the manifest. Files which are missing from the
+// manifest or from dir are reported as tampering.
+func VerifyBundle(dir string, verifier signing.Verifier) (*BundleManifest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, BundleManifestFileName))
+	if err != nil {
+		return nil, errors.Wrap(err, "bundle has no manifest")
+	}
+	signature, err := ioutil.ReadFile(filepath.Join(dir, BundleSignatureFileName))
+	if err != nil {
+		return nil, errors.Wrap(err, "bundle is not signed")
+	}
+	if verifier == nil {
+		return nil, errors.New("no public key was provided to verify the bundle signature")
+	}
+	if err = verifier.Verify(data, signature); err != nil {
+		return nil, errors.Wrap(err, "bundle manifest signature is not valid")
+	}
+
+	var manifest BundleManifest
+	if err = yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.Wrap(err, "parse bundle manifest")
+	}
+	if manifest.Algorithm != bundleDigestAlgorithm {
+		return nil, errors.Errorf("unsupported digest algorithm %q", manifest.Algorithm)
+	}
+
+	actual, err := digestBundleFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, path := range util.SortedKeys(manifest.Digests) {
+		digest, ok := actual[path]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: missing", path))
+		} else if digest != manifest.Digests[path] {
+			problems = append(problems, fmt.Sprintf("%s: digest is %s but manifest has %s", path, digest, manifest.Digests[path]))
+		}
+	}
+	for _, path := range util.SortedKeys(actual) {
+		if _, ok := manifest.Digests[path]; !ok {
+			problems = append(problems, fmt.Sprintf("%s: not in manifest", path))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.Errorf("bundle does not match its manifest:\n%s", strings.Join(problems, "\n"))
+	}
+
+	return &manifest, nil
+}
+
+// digestBundleFiles returns the digest of every file in dir except the manifest and signature.
+func digestBundleFiles(dir string) (map[string]string, error) {
+	digests := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == BundleManifestFileName || rel == BundleSignatureFileName {
+			return nil
+		}
+		digest, err := digestFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "digest %q", rel)
+		}
+		digests[rel] = digest
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return digests, nil
+}
+
+func digestFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return bundleDigestAlgorithm + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// GetBundleSigner returns the signer configured in the workspace, or nil if no private key is configured.
+func (b *Bosun) GetBundleSigner() (signing.Signer, error) {
+	config := b.ws.BundleSigning
+	if config == nil || config.PrivateKeyPath == "" {
+		return nil, nil
+	}
+	var password []byte
+	if config.Password != nil {
+		value, err := config.Password.Resolve(b.NewContext().WithDir(filepath.Dir(b.ws.Path)))
+		if err != nil {
+			return nil, errors.Wrap(err, "resolve bundle signing key password")
+		}
+		password = []byte(value)
+	}
+	return signing.LoadSigner(b.resolveWorkspacePath(config.PrivateKeyPath), password)
+}
+
+// GetBundleVerifier returns a verifier for the public key at publicKeyPath, or for the public key
+// configured in the workspace if publicKeyPath is empty. It returns nil if no public key is configured.
+func (b *Bosun) GetBundleVerifier(publicKeyPath string) (signing.Verifier, error) {
+	if publicKeyPath == "" {
+		if b.ws.BundleSigning == nil || b.ws.BundleSigning.PublicKeyPath == "" {
+			return nil, nil
+		}
+		publicKeyPath = b.resolveWorkspacePath(b.ws.BundleSigning.PublicKeyPath)
+	}
+	return signing.LoadVerifier(publicKeyPath)
+}
+
+func (b *Bosun) resolveWorkspacePath(path string) string {
+	path = os.ExpandEnv(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(b.ws.Path), path)
+}
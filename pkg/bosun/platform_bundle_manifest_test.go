@@ -0,0 +1,101 @@
+package bosun_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/naveego/bosun/pkg/bosun"
+	"github.com/naveego/bosun/pkg/signing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BundleManifest", func() {
+
+	var dir string
+	var signer signing.Signer
+
+	writeFile := func(path string, content string) {
+		path = filepath.Join(dir, path)
+		Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(content), 0600)).To(Succeed())
+	}
+
+	newSigner := func() signing.Signer {
+		privateKey, _, err := signing.GenerateKeyPair()
+		Expect(err).ToNot(HaveOccurred())
+		s, err := signing.ParseSigner(privateKey, nil)
+		Expect(err).ToNot(HaveOccurred())
+		return s
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "bosun-bundle")
+		Expect(err).ToNot(HaveOccurred())
+
+		writeFile("platform.yaml", "name: test\n")
+		writeFile("releases/current/manifest.yaml", "version: 1.0.0\n")
+		writeFile("apps/api/values.yaml", "replicas: 2\n")
+
+		signer = newSigner()
+		manifest, err := CreateBundleManifest(dir, "test")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Save(dir, signer)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	It("verifies a signed bundle", func() {
+		manifest, err := VerifyBundle(dir, signer.Verifier())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Platform).To(Equal("test"))
+		Expect(manifest.Digests).To(HaveLen(3))
+		Expect(manifest.Digests).To(HaveKey("apps/api/values.yaml"))
+	})
+
+	It("rejects a bundle with a modified file", func() {
+		writeFile("apps/api/values.yaml", "replicas: 20\n")
+		_, err := VerifyBundle(dir, signer.Verifier())
+		Expect(err).To(MatchError(ContainSubstring("apps/api/values.yaml: digest is")))
+	})
+
+	It("rejects a bundle with an added file", func() {
+		writeFile("apps/api/extra.yaml", "replicas: 2\n")
+		_, err := VerifyBundle(dir, signer.Verifier())
+		Expect(err).To(MatchError(ContainSubstring("apps/api/extra.yaml: not in manifest")))
+	})
+
+	It("rejects a bundle with a removed file", func() {
+		Expect(os.Remove(filepath.Join(dir, "releases/current/manifest.yaml"))).To(Succeed())
+		_, err := VerifyBundle(dir, signer.Verifier())
+		Expect(err).To(MatchError(ContainSubstring("releases/current/manifest.yaml: missing")))
+	})
+
+	It("rejects a bundle signed with a different key", func() {
+		_, err := VerifyBundle(dir, newSigner().Verifier())
+		Expect(err).To(MatchError(ContainSubstring("bundle manifest signature is not valid")))
+	})
+
+	It("rejects a bundle whose manifest was modified after it was signed", func() {
+		data, err := ioutil.ReadFile(filepath.Join(dir, BundleManifestFileName))
+		Expect(err).ToNot(HaveOccurred())
+		writeFile(BundleManifestFileName, string(data)+"\n# edited\n")
+		_, err = VerifyBundle(dir, signer.Verifier())
+		Expect(err).To(MatchError(ContainSubstring("bundle manifest signature is not valid")))
+	})
+
+	It("rejects an unsigned bundle", func() {
+		Expect(os.Remove(filepath.Join(dir, BundleSignatureFileName))).To(Succeed())
+		_, err := VerifyBundle(dir, signer.Verifier())
+		Expect(err).To(MatchError(ContainSubstring("bundle is not signed")))
+	})
+
+	It("rejects a bundle when no public key is provided", func() {
+		_, err := VerifyBundle(dir, nil)
+		Expect(err).To(MatchError(ContainSubstring("no public key")))
+	})
+})
@@ -0,0 +1,214 @@
+package bosun
+
+import (
+	"github.com/naveego/bosun/pkg/command"
+	"github.com/naveego/bosun/pkg/helm"
+	"github.com/naveego/bosun/pkg/util"
+	"github.com/naveego/bosun/pkg/yaml"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OfflineBundleIndexFileName is the file in an offline bundle which lists the images and charts in the bundle.
+const OfflineBundleIndexFileName = "offline.yaml"
+
+// OfflineBundleIndex lists the images and charts exported into an offline platform bundle,
+// so that they can be loaded into a registry which the target cluster can reach.
+type OfflineBundleIndex struct {
+	Images []OfflineBundleImage `yaml:"images" json:"images"`
+	Charts []OfflineBundleChart `yaml:"charts" json:"charts"`
+}
+
+// OfflineBundleImage is an image saved as a tarball in an offline bundle.
+type OfflineBundleImage struct {
+	App string `yaml:"app" json:"app"`
+	// Name is the full name and tag of the image in the registry it was exported from.
+	Name string `yaml:"name" json:"name"`
+	// Path is the slash separated path to the tarball, relative to the root of the bundle.
+	Path string `yaml:"path" json:"path"`
+}
+
+// OfflineBundleChart is a packaged chart in an offline bundle.
+type OfflineBundleChart struct {
+	App string `yaml:"app" json:"app"`
+	// Path is the slash separated path to the packaged chart, relative to the root of the bundle.
+	Path string `yaml:"path" json:"path"`
+}
+
+// exportOfflineContent saves every image referenced by the current release, and the chart of
+// every app in the release, into bundleDir, and writes an index of them.
+func (d PlatformBundler) exportOfflineContent(bundleDir string) error {
+	ctx := d.b.NewContext()
+	sudo := ctx.GetParameters().Sudo
+
+	release, err := d.p.GetCurrentRelease()
+	if err != nil {
+		return errors.Wrap(err, "get current release")
+	}
+	appManifests, err := release.GetAppManifests()
+	if err != nil {
+		return err
+	}
+
+	index := OfflineBundleIndex{}
+
+	for _, appName := range util.SortedKeys(appManifests) {
+		appManifest := appManifests[appName]
+		log := d.log.WithField("app", appName)
+
+		tag := appManifest.GetTagBasedOnVersionAndBranch()
+		imageDir := filepath.Join(bundleDir, "images", appName)
+		for _, imageConfig := range appManifest.AppConfig.GetImages() {
+			imageName := imageConfig.GetFullNameWithTag(tag)
+			imagePath := filepath.Join(imageDir, offlineImageFileName(imageName))
+			log.Infof("Exporting image %s...", imageName)
+			if err = saveImage(imageName, imagePath, sudo); err != nil {
+				return errors.Wrapf(err, "export image %q for app %q", imageName, appName)
+			}
+			index.Images = append(index.Images, OfflineBundleImage{
+				App:  appName,
+				Name: imageName,
+				Path: filepath.ToSlash(filepath.Join("images", appName, filepath.Base(imagePath))),
+			})
+		}
+
+		chartDir := filepath.Join(bundleDir, "charts", appName)
+		chartPath, err := exportChart(appManifest, chartDir)
+		if err != nil {
+			return errors.Wrapf(err, "export chart for app %q", appName)
+		}
+		if chartPath == "" {
+			log.Info("App has no chart.")
+			continue
+		}
+		log.Infof("Exported chart %s.", filepath.Base(chartPath))
+		index.Charts = append(index.Charts, OfflineBundleChart{
+			App:  appName,
+			Path: filepath.ToSlash(filepath.Join("charts", appName, filepath.Base(chartPath))),
+		})
+	}
+
+	d.log.Infof("Exported %d images and %d charts.", len(index.Images), len(index.Charts))
+
+	return yaml.SaveYaml(filepath.Join(bundleDir, OfflineBundleIndexFileName), index)
+}
+
+// offlineImageFileName returns a file name for an image tarball which is unique to the image name and tag.
+func offlineImageFileName(imageName string) string {
+	return strings.NewReplacer("/", "_", ":", "_").Replace(imageName) + ".tar"
+}
+
+func saveImage(imageName string, path string, sudo bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if _, err := command.NewShellExe("docker", "pull", imageName).Sudo(sudo).RunOutLog(); err != nil {
+		return err
+	}
+	_, err := command.NewShellExe("docker", "save", "--output", path, imageName).Sudo(sudo).RunOutLog()
+	return err
+}
+
+// exportChart packages the chart of the app into dir, or pulls it from its chart repo if the
+// chart is not in the manifest. It returns the path to the packaged chart, or "" if the app has no chart.
+func exportChart(appManifest *AppManifest, dir string) (string, error) {
+	appConfig := appManifest.AppConfig
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	if appConfig.ChartPath != "" {
+		chartSrcPath := filepath.Join(filepath.Dir(appConfig.FromPath), appConfig.ChartPath)
+		if stat, err := os.Stat(chartSrcPath); err == nil && stat.IsDir() {
+			if _, err = command.NewShellExe("helm", "package", chartSrcPath, "--destination", dir).RunOutLog(); err != nil {
+				return "", errors.Wrapf(err, "package chart at %q", chartSrcPath)
+			}
+			return findPackagedChart(dir)
+		}
+	}
+
+	chart := helm.ChartHandle(appConfig.Chart)
+	if chart == "" {
+		return "", nil
+	}
+	if !chart.HasRepo() {
+		return "", errors.Errorf("chart %q is not in the manifest and does not include a chart repo", chart)
+	}
+
+	args := []string{"pull", chart.String(), "--destination", dir}
+	if !appManifest.Version.Empty() {
+		args = append(args, "--version", appManifest.Version.String())
+	}
+	if _, err := command.NewShellExe("helm", args...).RunOutLog(); err != nil {
+		return "", errors.Wrapf(err, "pull chart %q", chart)
+	}
+	return findPackagedChart(dir)
+}
+
+func findPackagedChart(dir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.tgz"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) != 1 {
+		return "", errors.Errorf("expected one packaged chart in %q, found %d", dir, len(matches))
+	}
+	return matches[0], nil
+}
+
+// loadOfflineContent pushes the images and charts in an offline bundle to the registry of the current environment.
+// It does nothing if the bundle is not an offline bundle.
+func (p *PlatformPusher) loadOfflineContent(req PlatformPushRequest) error {
+	indexPath := filepath.Join(req.BundleDir, OfflineBundleIndexFileName)
+	if _, err := os.Stat(indexPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	var index OfflineBundleIndex
+	if err := yaml.LoadYaml(indexPath, &index); err != nil {
+		return errors.Wrap(err, "read offline bundle index")
+	}
+
+	env := p.b.GetCurrentEnvironment()
+	registry := env.Registry
+	if registry == nil || registry.Host == "" {
+		p.log.Warnf("Bundle contains %d images and %d charts but environment %q has no registry configured, assuming they are already available to the cluster.", len(index.Images), len(index.Charts), env.Name)
+		return nil
+	}
+
+	sudo := p.b.NewContext().GetParameters().Sudo
+
+	for _, image := range index.Images {
+		targetName := registry.ImageName(image.Name)
+		log := p.log.WithField("app", image.App).WithField("image", targetName)
+		log.Info("Loading image into registry...")
+		imagePath := filepath.Join(req.BundleDir, filepath.FromSlash(image.Path))
+		if _, err := command.NewShellExe("docker", "load", "--input", imagePath).Sudo(sudo).RunOutLog(); err != nil {
+			return errors.Wrapf(err, "load image %q", image.Name)
+		}
+		if _, err := command.NewShellExe("docker", "tag", image.Name, targetName).Sudo(sudo).RunOutLog(); err != nil {
+			return errors.Wrapf(err, "tag image %q as %q", image.Name, targetName)
+		}
+		if _, err := command.NewShellExe("docker", "push", targetName).Sudo(sudo).RunOutLog(); err != nil {
+			return errors.Wrapf(err, "push image %q", targetName)
+		}
+	}
+
+	chartRepo := registry.ChartRepository()
+	for _, chart := range index.Charts {
+		p.log.WithField("app", chart.App).Infof("Pushing chart to %s...", chartRepo)
+		args := []string{"push", filepath.Join(req.BundleDir, filepath.FromSlash(chart.Path)), chartRepo}
+		if registry.Insecure {
+			args = append(args, "--insecure-skip-tls-verify")
+		}
+		if _, err := command.NewShellExe("helm", args...).RunOutLog(); err != nil {
+			return errors.Wrapf(err, "push chart %q", chart.Path)
+		}
+	}
+
+	p.log.Infof("Loaded %d images and %d charts into registry %s.", len(index.Images), len(index.Charts), registry.Repository())
+
+	return nil
+}
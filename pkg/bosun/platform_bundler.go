@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"fmt"
 	"github.com/mattn/go-zglob"
+	"github.com/naveego/bosun/pkg/signing"
 	"github.com/otiai10/copy"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -23,11 +24,17 @@ type PlatformBundler struct {
 
 type BundlePlatformRequest struct {
 	Dir          string
+	// SigningKeyPath is the private key used to sign the bundle, overriding the key configured in the workspace.
+	SigningKeyPath string
+	// Offline exports every image and chart used by the release into the bundle,
+	// so that it can be pushed to a cluster which cannot reach the original registries.
+	Offline bool
 }
 
 type BundlePlatformResult struct {
 	Request *BundlePlatformRequest
 	OutPath string
+	Signed  bool
 }
 
 func NewPlatformBundler(bosun *Bosun, platform *Platform) PlatformBundler {
@@ -38,8 +45,7 @@ func NewPlatformBundler(bosun *Bosun, platform *Platform) PlatformBundler {
 }
 
 
-func (d PlatformBundler) Execute() (BundlePlatformResult, error) {
-	req := BundlePlatformRequest{}
+func (d PlatformBundler) Execute(req BundlePlatformRequest) (BundlePlatformResult, error) {
 
 	result := BundlePlatformResult{
 		Request: &req,
@@ -53,6 +59,20 @@ func (d PlatformBundler) Execute() (BundlePlatformResult, error) {
 
 	d.log = d.b.NewContext().Log()
 
+	var signer signing.Signer
+	var err error
+	if req.SigningKeyPath != "" {
+		signer, err = signing.LoadSigner(req.SigningKeyPath, nil)
+	} else {
+		signer, err = d.b.GetBundleSigner()
+	}
+	if err != nil {
+		return result, errors.Wrap(err, "load bundle signing key")
+	}
+	if signer == nil {
+		d.log.Warn("No bundle signing key is configured in the workspace (bundleSigning.privateKeyPath), the bundle will not be signed and pushing it will require an override.")
+	}
+
 	fromDir := filepath.Dir(d.p.FromPath)
 	releaseSrcDir := filepath.Join(fromDir, "releases", "current")
 	appsSrcDir := filepath.Join(fromDir, "apps")
@@ -66,7 +86,7 @@ func (d PlatformBundler) Execute() (BundlePlatformResult, error) {
 	_ = os.RemoveAll(tmpDir)
 
 	// copy charts
-	err := os.MkdirAll(releaseSrcDir, 0700)
+	err = os.MkdirAll(releaseSrcDir, 0700)
 	if err != nil {
 		return result, fmt.Errorf("could not create charts folder '%s': %w", releaseSrcDir, err)
 	}
@@ -94,7 +114,7 @@ func (d PlatformBundler) Execute() (BundlePlatformResult, error) {
 	}
 	pBytes, err := yaml.Marshal(platforms)
 	if err != nil {
-		return result, fmt.Errorf("could not serialize platform: %w")
+		return result, fmt.Errorf("could not serialize platform: %w", err)
 	}
 
 	err = ioutil.WriteFile(filepath.Join(tmpDir, "platform.yaml"), pBytes, 0700)
@@ -102,6 +122,23 @@ func (d PlatformBundler) Execute() (BundlePlatformResult, error) {
 		return result, fmt.Errorf("could not copy '%s' to '%s': %w", d.p.FromPath, tmpDir, err)
 	}
 
+	if req.Offline {
+		err = d.exportOfflineContent(tmpDir)
+		if err != nil {
+			return result, errors.Wrap(err, "export images and charts")
+		}
+	}
+
+	manifest, err := CreateBundleManifest(tmpDir, d.p.Name)
+	if err != nil {
+		return result, errors.Wrap(err, "create bundle manifest")
+	}
+	err = manifest.Save(tmpDir, signer)
+	if err != nil {
+		return result, err
+	}
+	result.Signed = signer != nil
+
 	outPath := filepath.Join(req.Dir, "bundle.zip")
 
 	tmpFiles, err := zglob.Glob(filepath.Join(tmpDir, "**/*"))
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/naveego/bosun/pkg/kube"
 	"github.com/naveego/bosun/pkg/yaml"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"path/filepath"
 )
@@ -21,6 +22,12 @@ type PlatformPushRequest struct {
 	PushApp         string
 	EnvironmentPath string
 	Cluster         string
+	// PublicKeyPath is the public key used to verify the bundle, overriding the key configured in the workspace.
+	PublicKeyPath string
+	// AllowUnverified pushes the bundle even if it is unsigned or does not match its manifest.
+	AllowUnverified bool
+	// SkipOfflineContent skips loading the images and charts in an offline bundle into the environment's registry.
+	SkipOfflineContent bool
 }
 
 func NewPlatformPusher(bosun *Bosun, platform *Platform) PlatformPusher {
@@ -32,9 +39,19 @@ func NewPlatformPusher(bosun *Bosun, platform *Platform) PlatformPusher {
 
 func (p *PlatformPusher) Push(req PlatformPushRequest) error {
 
+	p.log = p.b.NewContext().Log()
+
+	err := p.verifyBundle(req)
+	if err != nil {
+		if !req.AllowUnverified {
+			return errors.Wrap(err, "bundle could not be verified")
+		}
+		p.log.WithError(err).Warn("Bundle could not be verified, pushing anyway because verification was overridden.")
+	}
+
 	relManifestFile := filepath.Join(req.ManifestDir, "manifest.yaml")
 	var relManifest ReleaseManifest
-	err := yaml.LoadYaml(relManifestFile, &relManifest)
+	err = yaml.LoadYaml(relManifestFile, &relManifest)
 	if err != nil {
 		return fmt.Errorf("could not read release manifest file '%s': %w", relManifestFile, err)
 	}
@@ -67,6 +84,13 @@ func (p *PlatformPusher) Push(req PlatformPushRequest) error {
 	stopPF := kube.PortForward("vault-dev-0", env.VaultNamespace, 8200)
 	defer stopPF()
 
+	if !req.SkipOfflineContent {
+		err = p.loadOfflineContent(req)
+		if err != nil {
+			return errors.Wrap(err, "load images and charts from bundle")
+		}
+	}
+
 	creator := NewDeploymentPlanCreator(p.b, p.p)
 	plan, err := creator.CreateDeploymentPlan(planReq)
 	if err != nil {
@@ -80,3 +104,17 @@ func (p *PlatformPusher) Push(req PlatformPushRequest) error {
 
 	return err
 }
+
+// verifyBundle checks the signature of the bundle manifest and the digests of the files in the bundle.
+func (p *PlatformPusher) verifyBundle(req PlatformPushRequest) error {
+	verifier, err := p.b.GetBundleVerifier(req.PublicKeyPath)
+	if err != nil {
+		return err
+	}
+	manifest, err := VerifyBundle(req.BundleDir, verifier)
+	if err != nil {
+		return err
+	}
+	p.log.Infof("Verified bundle of platform %q created at %s (%d files).", manifest.Platform, manifest.CreatedAt, len(manifest.Digests))
+	return nil
+}
@@ -541,7 +541,7 @@ func (r *ReleaseCommitter) ExecuteStep(i int, step ReleaseCommitterPlanStep) err
 			log.Warnf("Encountered a problem merging: %s", err)
 			fmt.Println()
 
-			confirmed := cli.RequestConfirmFromUser("Merge for %s from %s to %s in %s failed, you'll need to complete the merge yourself: %s\nEnter 'y' when you have completed the merge in another terminal, 'n' to abort release commit", r.release.Version, step.Merge.FromBranch, step.Merge.ToBranch, r.release.Version, step.RepoPath, err)
+			confirmed := cli.RequestConfirmFromUser("Merge for %s from %s to %s in %s failed, you'll need to complete the merge yourself: %s\nEnter 'y' when you have completed the merge in another terminal, 'n' to abort release commit", r.release.Version, step.Merge.FromBranch, step.Merge.ToBranch, step.RepoPath, err)
 			if !confirmed {
 				_, err = g.Exec("merge", "--abort")
 				break
@@ -12,7 +12,7 @@ var _ = Describe("Platform", func() {
 	It("should round-trip version", func() {
 
 		sut := bosun.ReleaseMetadata{
-			Name:    "Deploy",
+			Branch:  "release/0.1.4-alpha",
 			Version: semver.New("0.1.4-alpha"),
 		}
 		y, err := yaml.Marshal(sut)
@@ -0,0 +1,282 @@
+package bosun
+
+import (
+	"github.com/naveego/bosun/pkg/git"
+	"github.com/naveego/bosun/pkg/issues"
+	"github.com/naveego/bosun/pkg/semver"
+	"github.com/naveego/bosun/pkg/util"
+	"github.com/pkg/errors"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	ReleaseNotesFormatMarkdown = "markdown"
+	ReleaseNotesFormatHTML     = "html"
+)
+
+// ReleaseNotes describe the changes to every app in a release since the previous release.
+type ReleaseNotes struct {
+	Release         *ReleaseMetadata
+	PreviousRelease *ReleaseMetadata
+	GeneratedAt     time.Time
+	// Apps are the apps which changed, were added, or could not be compared.
+	Apps []*AppReleaseNotes
+	// UnchangedApps are the names of the apps with the same commit as the previous release.
+	UnchangedApps []string
+}
+
+// AppReleaseNotes describe the changes to an app, grouped by the version bump each change requires.
+type AppReleaseNotes struct {
+	Name            string
+	Version         semver.Version
+	PreviousVersion *semver.Version
+	Commit          string
+	PreviousCommit  string
+	// New is true if the app was not in the previous release.
+	New         bool
+	VersionBump semver.Bump
+	Groups      []ReleaseNotesGroup
+	Stories     []*git.GitChangeStory
+	// Error explains why the changes could not be found.
+	Error string
+}
+
+// ReleaseNotesGroup is the changes which require the same version bump.
+type ReleaseNotesGroup struct {
+	Bump    semver.Bump
+	Title   string
+	Changes git.GitChanges
+}
+
+var releaseNotesGroupOrder = []ReleaseNotesGroup{
+	{Bump: semver.BumpMajor, Title: "Breaking Changes"},
+	{Bump: semver.BumpMinor, Title: "Features"},
+	{Bump: semver.BumpPatch, Title: "Fixes"},
+	{Bump: semver.BumpNone, Title: "Other Changes"},
+	{Bump: semver.Unknown, Title: "Unknown Changes"},
+}
+
+type ReleaseNotesRequest struct {
+	Release *ReleaseManifest
+	// PreviousRelease is compared to Release. If it is nil, every app is reported as new.
+	PreviousRelease *ReleaseManifest
+	// IssueService is used to link changes to stories. If it is nil, stories are not linked.
+	IssueService issues.IssueService
+}
+
+// GenerateReleaseNotes creates release notes by comparing the commit of each app in the release
+// to its commit in the previous release. The app repos must be cloned in the workspace.
+func GenerateReleaseNotes(ctx BosunContext, req ReleaseNotesRequest) (*ReleaseNotes, error) {
+	if req.Release == nil {
+		return nil, errors.New("release is required")
+	}
+
+	notes := &ReleaseNotes{
+		Release:     req.Release.ReleaseMetadata,
+		GeneratedAt: time.Now(),
+	}
+	var previousApps map[string]*AppMetadata
+	if req.PreviousRelease != nil {
+		notes.PreviousRelease = req.PreviousRelease.ReleaseMetadata
+		previousApps = req.PreviousRelease.AppMetadata
+	}
+
+	for _, name := range util.SortedKeys(req.Release.AppMetadata) {
+		appMetadata := req.Release.AppMetadata[name]
+		appNotes := &AppReleaseNotes{
+			Name:    name,
+			Version: appMetadata.Version,
+			Commit:  appMetadata.Hashes.Commit,
+		}
+
+		previous, ok := previousApps[name]
+		if !ok {
+			appNotes.New = true
+			notes.Apps = append(notes.Apps, appNotes)
+			continue
+		}
+		appNotes.PreviousVersion = &previous.Version
+		appNotes.PreviousCommit = previous.Hashes.Commit
+
+		if appNotes.Commit == appNotes.PreviousCommit {
+			notes.UnchangedApps = append(notes.UnchangedApps, name)
+			continue
+		}
+
+		log := ctx.Log().WithField("app", name)
+		log.Infof("Finding changes between %s and %s...", appNotes.PreviousCommit, appNotes.Commit)
+		if err := appNotes.loadChanges(ctx, req.IssueService); err != nil {
+			log.WithError(err).Warn("Could not find changes.")
+			appNotes.Error = err.Error()
+		}
+
+		notes.Apps = append(notes.Apps, appNotes)
+	}
+
+	return notes, nil
+}
+
+func (a *AppReleaseNotes) loadChanges(ctx BosunContext, svc issues.IssueService) error {
+	if a.Commit == "" || a.PreviousCommit == "" {
+		return errors.New("app does not have a commit hash in both releases (it may be stored in the platform repo)")
+	}
+
+	app, err := ctx.Bosun.GetApp(a.Name, WorkspaceProviderName)
+	if err != nil {
+		return errors.Wrap(err, "app must be in the workspace")
+	}
+	repoPath, err := git.GetRepoPath(app.FromPath)
+	if err != nil {
+		return errors.Wrap(err, "app repo must be cloned")
+	}
+	g, err := git.NewGitWrapper(repoPath)
+	if err != nil {
+		return err
+	}
+
+	changeLog, err := g.ChangeLog(a.Commit, a.PreviousCommit, svc, git.GitChangeLogOptions{
+		Description: true,
+		Rules:       ctx.Bosun.GetCommitRules(app.Branching),
+	})
+	if err != nil {
+		return errors.Wrap(err, "get change log (you may need to fetch the app repo)")
+	}
+
+	a.VersionBump = changeLog.VersionBump
+	separated := changeLog.Changes.GetSeparatedChanges()
+	for _, group := range releaseNotesGroupOrder {
+		if changes := separated[group.Bump]; len(changes) > 0 {
+			group.Changes = changes
+			a.Groups = append(a.Groups, group)
+		}
+	}
+
+	if svc != nil {
+		a.Stories, err = changeLog.Changes.MapToStories(svc)
+		if err != nil {
+			return errors.Wrap(err, "map changes to stories")
+		}
+	}
+
+	return nil
+}
+
+// Render writes the release notes using tmpl, or the default template for the format if tmpl is empty.
+// HTML templates are rendered using html/template, so values from commits are escaped.
+func (r *ReleaseNotes) Render(w io.Writer, format string, tmpl string) error {
+	switch format {
+	case ReleaseNotesFormatMarkdown:
+		if tmpl == "" {
+			tmpl = defaultReleaseNotesMarkdownTemplate
+		}
+		t, err := template.New("release-notes").Funcs(template.FuncMap(releaseNotesFuncs)).Parse(tmpl)
+		if err != nil {
+			return errors.Wrap(err, "parse template")
+		}
+		return t.Execute(w, r)
+	case ReleaseNotesFormatHTML:
+		if tmpl == "" {
+			tmpl = defaultReleaseNotesHTMLTemplate
+		}
+		t, err := htmltemplate.New("release-notes").Funcs(htmltemplate.FuncMap(releaseNotesFuncs)).Parse(tmpl)
+		if err != nil {
+			return errors.Wrap(err, "parse template")
+		}
+		return t.Execute(w, r)
+	default:
+		return errors.Errorf("unsupported format %q (supported formats are %s and %s)", format, ReleaseNotesFormatMarkdown, ReleaseNotesFormatHTML)
+	}
+}
+
+var releaseNotesFuncs = map[string]interface{}{
+	"join": strings.Join,
+	"trim": strings.TrimSpace,
+	// short returns the first 7 characters of a commit hash.
+	"short": func(commit string) string {
+		commit = strings.TrimSpace(commit)
+		if len(commit) > 7 {
+			return commit[:7]
+		}
+		return commit
+	},
+}
+
+const defaultReleaseNotesMarkdownTemplate = `# Release {{ .Release.Version }}
+{{ with .Release.Description }}
+{{ . }}
+{{ end }}
+{{- if .PreviousRelease }}
+Changes since release {{ .PreviousRelease.Version }}.
+{{ end }}
+{{- range .Apps }}
+## {{ .Name }} {{ .Version }}
+{{ if .New }}
+New in this release.
+{{ else if .Error }}
+Changes could not be found: {{ .Error }}
+{{ else }}
+Updated from {{ .PreviousVersion }} ({{ short .PreviousCommit }}..{{ short .Commit }}), recommended bump: {{ .VersionBump }}.
+{{ range .Groups }}
+### {{ .Title }}
+{{ range .Changes }}
+- {{ if .Title }}{{ .Title }}{{ else }}{{ trim .Body }}{{ end }}{{ if .Committer }} ({{ .Committer }}){{ end }}{{ if .IssueLink }} [{{ .Issue }}]({{ .IssueLink }}){{ end }}
+{{- end }}
+{{ end }}
+{{- if .Stories }}
+### Stories
+{{ range .Stories }}
+- [{{ .StoryRef }}]({{ .Link }}) {{ .StoryTitle }}
+{{- end }}
+{{ end }}
+{{- end }}
+{{- end }}
+{{- if .UnchangedApps }}
+## Unchanged Apps
+
+{{ join .UnchangedApps ", " }}
+{{ end -}}
+`
+
+const defaultReleaseNotesHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Release {{ .Release.Version }}</title>
+</head>
+<body>
+<h1>Release {{ .Release.Version }}</h1>
+{{ with .Release.Description }}<p>{{ . }}</p>{{ end }}
+{{ if .PreviousRelease }}<p>Changes since release {{ .PreviousRelease.Version }}.</p>{{ end }}
+{{ range .Apps }}
+<h2>{{ .Name }} {{ .Version }}</h2>
+{{ if .New }}
+<p>New in this release.</p>
+{{ else if .Error }}
+<p>Changes could not be found: {{ .Error }}</p>
+{{ else }}
+<p>Updated from {{ .PreviousVersion }} ({{ short .PreviousCommit }}..{{ short .Commit }}), recommended bump: {{ .VersionBump }}.</p>
+{{ range .Groups }}
+<h3>{{ .Title }}</h3>
+<ul>
+{{ range .Changes }}<li>{{ if .Title }}{{ .Title }}{{ else }}{{ trim .Body }}{{ end }}{{ if .Committer }} ({{ .Committer }}){{ end }}{{ if .IssueLink }} <a href="{{ .IssueLink }}">{{ .Issue }}</a>{{ end }}</li>
+{{ end }}</ul>
+{{ end }}
+{{ if .Stories }}
+<h3>Stories</h3>
+<ul>
+{{ range .Stories }}<li><a href="{{ .Link }}">{{ .StoryRef }}</a> {{ .StoryTitle }}</li>
+{{ end }}</ul>
+{{ end }}
+{{ end }}
+{{ end }}
+{{ if .UnchangedApps }}
+<h2>Unchanged Apps</h2>
+<p>{{ join .UnchangedApps ", " }}</p>
+{{ end }}
+</body>
+</html>
+`
@@ -0,0 +1,87 @@
+package bosun_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	. "github.com/naveego/bosun/pkg/bosun"
+	"github.com/naveego/bosun/pkg/git"
+	"github.com/naveego/bosun/pkg/issues"
+	"github.com/naveego/bosun/pkg/semver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReleaseNotes", func() {
+
+	var notes *ReleaseNotes
+
+	BeforeEach(func() {
+		previousVersion := semver.New("1.2.0")
+		issue := issues.NewIssueRef("naveego", "api", "42")
+		story := issues.NewIssueRef("naveego", "stories", "7")
+
+		notes = &ReleaseNotes{
+			Release:         &ReleaseMetadata{Version: semver.New("2.0.0"), Description: "The <big> release & more."},
+			PreviousRelease: &ReleaseMetadata{Version: semver.New("1.0.0")},
+			GeneratedAt:     time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			Apps: []*AppReleaseNotes{
+				{
+					Name:            "api",
+					Version:         semver.New("1.3.0"),
+					PreviousVersion: &previousVersion,
+					Commit:          "0123456789abcdef",
+					PreviousCommit:  "fedcba9876543210",
+					VersionBump:     semver.BumpMinor,
+					Groups: []ReleaseNotesGroup{
+						{Bump: semver.BumpMinor, Title: "Features", Changes: git.GitChanges{
+							{Title: "add <script>alert('x')</script> support", Committer: "Ana", Issue: &issue, IssueLink: "https://github.com/naveego/api/issues/42"},
+						}},
+						{Bump: semver.BumpPatch, Title: "Fixes", Changes: git.GitChanges{
+							{Body: "  fix the \"quoted\" & ampersand bug  "},
+						}},
+					},
+					Stories: []*git.GitChangeStory{
+						{StoryRef: story, StoryTitle: "Orders & <payments>", Link: "https://github.com/naveego/stories/issues/7"},
+					},
+				},
+				{Name: "auth", Version: semver.New("0.1.0"), New: true},
+				{Name: "search", Version: semver.New("3.0.0"), Error: "app repo must be cloned"},
+			},
+			UnchangedApps: []string{"logging", "web"},
+		}
+	})
+
+	expectGolden := func(format string, name string) string {
+		var buf bytes.Buffer
+		Expect(notes.Render(&buf, format, "")).To(Succeed())
+
+		expected, err := ioutil.ReadFile(filepath.Join("__testdata__", name))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buf.String()).To(Equal(string(expected)))
+		return buf.String()
+	}
+
+	It("renders the default markdown template", func() {
+		expectGolden(ReleaseNotesFormatMarkdown, "release-notes.golden.md")
+	})
+
+	It("renders the default html template, escaping values from commits", func() {
+		rendered := expectGolden(ReleaseNotesFormatHTML, "release-notes.golden.html")
+		Expect(rendered).ToNot(ContainSubstring("<script>"))
+		Expect(rendered).To(ContainSubstring("add &lt;script&gt;alert(&#39;x&#39;)&lt;/script&gt; support"))
+		Expect(rendered).To(ContainSubstring("Orders &amp; &lt;payments&gt;"))
+	})
+
+	It("renders a custom template", func() {
+		var buf bytes.Buffer
+		Expect(notes.Render(&buf, ReleaseNotesFormatHTML, `{{ range .Apps }}<b>{{ .Name }}</b>{{ end }} {{ .Release.Description }}`)).To(Succeed())
+		Expect(buf.String()).To(Equal(`<b>api</b><b>auth</b><b>search</b> The &lt;big&gt; release &amp; more.`))
+	})
+
+	It("rejects an unknown format", func() {
+		Expect(notes.Render(&bytes.Buffer{}, "pdf", "")).To(MatchError(ContainSubstring(`unsupported format "pdf"`)))
+	})
+})
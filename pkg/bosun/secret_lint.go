@@ -0,0 +1,236 @@
+package bosun
+
+import (
+	"fmt"
+	"github.com/naveego/bosun/pkg/core"
+	"github.com/naveego/bosun/pkg/environment"
+	"github.com/naveego/bosun/pkg/util"
+	"github.com/naveego/bosun/pkg/yaml"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// SecretLintMissing means a secret is referenced but not defined in the environment's secret groups.
+	SecretLintMissing = "Missing"
+	// SecretLintUnused means a secret is defined in the environment but never referenced.
+	SecretLintUnused = "Unused"
+	// SecretLintInconsistent means a secret is defined in some environments but not in this one.
+	SecretLintInconsistent = "Inconsistent"
+)
+
+type SecretLintFinding struct {
+	Kind        string `yaml:"kind" json:"kind"`
+	Environment string `yaml:"environment" json:"environment"`
+	SecretPath  string `yaml:"secretPath" json:"secretPath"`
+	Message     string `yaml:"message" json:"message"`
+}
+
+type SecretLintFindings []SecretLintFinding
+
+func (s SecretLintFindings) Headers() []string {
+	return []string{"Kind", "Environment", "Secret", "Message"}
+}
+
+func (s SecretLintFindings) Rows() [][]string {
+	var out [][]string
+	for _, f := range s {
+		out = append(out, []string{f.Kind, f.Environment, f.SecretPath, f.Message})
+	}
+	return out
+}
+
+// OfKind returns the findings of the provided kinds.
+func (s SecretLintFindings) OfKind(kinds ...string) SecretLintFindings {
+	var out SecretLintFindings
+	for _, f := range s {
+		for _, kind := range kinds {
+			if f.Kind == kind {
+				out = append(out, f)
+			}
+		}
+	}
+	return out
+}
+
+type SecretLintRequest struct {
+	Environments []*environment.Config
+	Apps         []*App
+}
+
+// LintSecrets finds the secret paths referenced by the apps, environments, and the stack templates
+// of the environments' clusters, and checks them against the secret groups of each environment.
+// Only the secret group files are read, so no secret values are decrypted and no cluster is needed.
+func (p *Platform) LintSecrets(req SecretLintRequest) (SecretLintFindings, error) {
+
+	clusters, err := p.GetClusters()
+	if err != nil {
+		return nil, err
+	}
+
+	var findings SecretLintFindings
+
+	// secret path => names of the environments which define it
+	definedIn := map[string][]string{}
+	defined := map[string]map[string]bool{}
+
+	for _, env := range req.Environments {
+
+		references := map[string][]secretReference{}
+		addReferences := func(source string, text string) {
+			for _, reference := range environment.FindSecretPathReferences(text) {
+				secretPath, parseErr := environment.ParseSecretPath(reference)
+				if parseErr != nil {
+					findings = append(findings, SecretLintFinding{
+						Kind:        SecretLintMissing,
+						Environment: env.Name,
+						SecretPath:  reference,
+						Message:     fmt.Sprintf("invalid secret path referenced by %s: %s", source, parseErr),
+					})
+					continue
+				}
+				secretPath.SecretName = strings.TrimSuffix(secretPath.SecretName, environment.PreviousSecretValueSuffix)
+				references[secretPath.String()] = append(references[secretPath.String()], secretReference{
+					source: source,
+					// a secret with generation parameters will be generated the first time it is resolved
+					generated: secretPath.Generation != nil,
+				})
+			}
+		}
+
+		for _, app := range req.Apps {
+			if env.IsAppDisabled(app.Name) {
+				continue
+			}
+			appValues := app.Values.ExtractValueSetByRoles(env.Role)
+			appValues, err = appValues.WithFilesLoaded(secretLintPathResolver{dir: app.Dir(), env: env})
+			if err != nil {
+				return nil, errors.Wrapf(err, "load values for app %q in environment %q", app.Name, env.Name)
+			}
+			y, _ := yaml.MarshalString(appValues)
+			addReferences(fmt.Sprintf("app %s", app.Name), y)
+		}
+
+		y, _ := yaml.MarshalString(env)
+		addReferences(fmt.Sprintf("environment %s", env.Name), y)
+
+		for _, cluster := range clusters {
+			if cluster.Environment != env.Name {
+				continue
+			}
+			y, _ = yaml.MarshalString(cluster)
+			addReferences(fmt.Sprintf("cluster %s", cluster.Name), y)
+		}
+
+		groups, groupsErr := env.GetSecretGroupConfigs()
+		if groupsErr != nil {
+			return nil, groupsErr
+		}
+
+		defined[env.Name] = map[string]bool{}
+		definedGroups := map[string]bool{}
+		for _, group := range groups {
+			definedGroups[group.Name] = true
+			for _, secret := range group.Secrets {
+				secretPath := (&environment.SecretPath{GroupName: group.Name, SecretName: secret.Name}).String()
+				defined[env.Name][secretPath] = true
+				definedIn[secretPath] = append(definedIn[secretPath], env.Name)
+				if _, ok := references[secretPath]; !ok {
+					findings = append(findings, SecretLintFinding{
+						Kind:        SecretLintUnused,
+						Environment: env.Name,
+						SecretPath:  secretPath,
+						Message:     "secret is not referenced by any app, environment, or stack template",
+					})
+				}
+			}
+		}
+
+		for secretPath, refs := range references {
+			if defined[env.Name][secretPath] {
+				continue
+			}
+			groupName := strings.Split(secretPath, "/")[0]
+			var sources []string
+			generated := true
+			for _, ref := range refs {
+				sources = append(sources, ref.source)
+				generated = generated && ref.generated
+			}
+			if generated && definedGroups[groupName] {
+				continue
+			}
+			findings = append(findings, SecretLintFinding{
+				Kind:        SecretLintMissing,
+				Environment: env.Name,
+				SecretPath:  secretPath,
+				Message:     fmt.Sprintf("secret is referenced by %s but is not defined", strings.Join(sources, ", ")),
+			})
+		}
+	}
+
+	for secretPath, envNames := range definedIn {
+		if len(envNames) == len(req.Environments) {
+			continue
+		}
+		for _, env := range req.Environments {
+			if defined[env.Name][secretPath] {
+				continue
+			}
+			findings = append(findings, SecretLintFinding{
+				Kind:        SecretLintInconsistent,
+				Environment: env.Name,
+				SecretPath:  secretPath,
+				Message:     fmt.Sprintf("secret is defined in %s but not in this environment", strings.Join(envNames, ", ")),
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Environment != findings[j].Environment {
+			return findings[i].Environment < findings[j].Environment
+		}
+		if findings[i].Kind != findings[j].Kind {
+			return findings[i].Kind < findings[j].Kind
+		}
+		return findings[i].SecretPath < findings[j].SecretPath
+	})
+
+	return findings, nil
+}
+
+type secretReference struct {
+	source    string
+	generated bool
+}
+
+// secretLintPathResolver resolves value set file paths the way BosunContext.ResolvePath does,
+// but for an environment which may not be the current one.
+type secretLintPathResolver struct {
+	dir string
+	env *environment.Config
+}
+
+func (s secretLintPathResolver) ResolvePath(path string, expansions ...string) string {
+	expansionMap := util.StringSliceToMap(expansions...)
+	path = os.Expand(path, func(name string) string {
+		switch name {
+		case "ENVIRONMENT", core.EnvEnvironment:
+			return s.env.Name
+		case core.EnvEnvironmentRole:
+			return string(s.env.Role)
+		default:
+			if v, ok := expansionMap[name]; ok {
+				return v
+			}
+			return name
+		}
+	})
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.dir, path)
+	}
+	return path
+}
@@ -0,0 +1,108 @@
+package bosun_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/naveego/bosun/pkg/bosun"
+	"github.com/naveego/bosun/pkg/core"
+	"github.com/naveego/bosun/pkg/environment"
+	"github.com/naveego/bosun/pkg/values"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LintSecrets", func() {
+
+	var dir string
+	var findings SecretLintFindings
+
+	writeFile := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(path, []byte(content), 0600)).To(Succeed())
+		return path
+	}
+
+	newEnvironment := func(name string, secretFile string) *environment.Config {
+		env := &environment.Config{
+			Role:                 core.EnvironmentRole(name),
+			Apps:                 map[string]values.ValueSetCollection{"api": {}},
+			SecretGroupFilePaths: map[string]string{"db": secretFile},
+		}
+		env.Name = name
+		env.SetFromPath(filepath.Join(dir, name+".bosun.yaml"))
+		return env
+	}
+
+	find := func(kind string, envName string, secretPath string) *SecretLintFinding {
+		for _, f := range findings {
+			if f.Kind == kind && f.Environment == envName && f.SecretPath == secretPath {
+				return &f
+			}
+		}
+		return nil
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "bosun-secret-lint")
+		Expect(err).ToNot(HaveOccurred())
+
+		writeFile("blue.secrets.yaml", `
+name: db
+secrets:
+  - name: password
+  - name: username
+  - name: legacy
+`)
+		writeFile("green.secrets.yaml", `
+name: db
+secrets:
+  - name: password
+`)
+
+		appValues := values.NewValueSetCollection()
+		appValues.DefaultValues.Static = values.Values{
+			"password": `{{ resolveSecretPath "db/password" }}`,
+			"username": `{{ resolveSecretPath "db/username" }}`,
+			"apiKey":   `{{ resolveSecretPath "api/key" }}`,
+		}
+		appConfig := &AppConfig{Values: appValues}
+		appConfig.Name = "api"
+		appConfig.SetFromPath(writeFile("api.bosun.yaml", "name: api\n"))
+
+		findings, err = (&Platform{}).LintSecrets(SecretLintRequest{
+			Environments: []*environment.Config{
+				newEnvironment("blue", "blue.secrets.yaml"),
+				newEnvironment("green", "green.secrets.yaml"),
+			},
+			Apps: []*App{NewApp(appConfig)},
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	It("reports a referenced secret which is not defined", func() {
+		Expect(find(SecretLintMissing, "blue", "api/key")).ToNot(BeNil())
+		Expect(find(SecretLintMissing, "green", "api/key")).ToNot(BeNil())
+		Expect(find(SecretLintMissing, "green", "api/key").Message).To(ContainSubstring("app api"))
+	})
+
+	It("reports a defined secret which is not referenced", func() {
+		Expect(find(SecretLintUnused, "blue", "db/legacy")).ToNot(BeNil())
+		Expect(find(SecretLintUnused, "blue", "db/password")).To(BeNil())
+	})
+
+	It("reports a secret which is defined in one environment but not another", func() {
+		Expect(find(SecretLintMissing, "green", "db/username")).ToNot(BeNil())
+		Expect(find(SecretLintInconsistent, "green", "db/username")).ToNot(BeNil())
+		Expect(find(SecretLintInconsistent, "green", "db/username").Message).To(ContainSubstring("blue"))
+		Expect(find(SecretLintMissing, "blue", "db/username")).To(BeNil())
+		Expect(find(SecretLintInconsistent, "blue", "db/username")).To(BeNil())
+		Expect(find(SecretLintInconsistent, "green", "db/password")).To(BeNil())
+	})
+})
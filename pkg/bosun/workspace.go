@@ -7,6 +7,7 @@ import (
 	"github.com/naveego/bosun/pkg/cli"
 	"github.com/naveego/bosun/pkg/command"
 	"github.com/naveego/bosun/pkg/core"
+	"github.com/naveego/bosun/pkg/git"
 	"github.com/naveego/bosun/pkg/kube"
 	"github.com/naveego/bosun/pkg/values"
 	"github.com/naveego/bosun/pkg/vcs"
@@ -41,6 +42,11 @@ type Workspace struct {
 	StoryHandlers          StoryHandlers                    `yaml:"storyHandlers"`
 	ClusterKubeconfigPaths map[string]string                `yaml:"clusterKubeconfigPaths"`
 	AppHints               []apps.AppHint                   `yaml:"appHints"`
+	// CommitRules configure how commits are parsed for change logs and version bumps
+	// in every app, unless the app's branching overrides them.
+	CommitRules *git.CommitRules `yaml:"commitRules,omitempty" json:"commitRules,omitempty"`
+	// BundleSigning configures the keys used to sign platform bundles and verify them before they are pushed.
+	BundleSigning *BundleSigningConfig `yaml:"bundleSigning,omitempty" json:"bundleSigning,omitempty"`
 }
 
 type StoryHandlers map[string]values.Values
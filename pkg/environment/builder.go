@@ -128,3 +128,12 @@ func (b Builder) WithBrn(brn brns.StackBrn) Builder {
 	b.stackName = brn.StackName
 	return b
 }
+
+// Options controls how New creates an environment.
+type Options struct{}
+
+// New creates an environment from config without activating a cluster or stack.
+// Use Config.Builder to create an environment which targets a stack.
+func New(config Config, options Options) (*Environment, error) {
+	return &Environment{Config: config}, nil
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/naveego/bosun/pkg/values"
 	"github.com/naveego/bosun/pkg/yaml"
 	"github.com/pkg/errors"
+	"path"
+	"strings"
 )
 
 type Config struct {
@@ -18,6 +20,9 @@ type Config struct {
 	DefaultCluster    string               `yaml:"defaultCluster,omitempty" json:"defaultCluster"`
 	Clusters          kube.ClusterConfigs  `yaml:"clusters,omitempty"`
 	PullSecrets       []kube.PullSecret    `yaml:"pullSecrets,omitempty"`
+	// Registry is the container registry that images and charts from offline platform bundles are loaded into.
+	// When it is set, apps deployed to the environment use their images and charts from this registry.
+	Registry *RegistryConfig `yaml:"registry,omitempty" json:"registry,omitempty"`
 	VaultNamespace    string               `yaml:"vaultNamespace,omitempty" json:"vaultNamespace,omitempty"`
 	// If true, commands which would cause modifications to be deployed will
 	// trigger a confirmation prompt.
@@ -81,6 +86,36 @@ func LoadConfig(path string) (*Config, error) {
 	return config, nil
 }
 
+// RegistryConfig identifies a container registry.
+type RegistryConfig struct {
+	// Host is the host (and optional port) of the registry, like "registry.example.com:5000".
+	Host string `yaml:"host" json:"host"`
+	// Project is prepended to the path of images and charts pushed to the registry, if set.
+	Project string `yaml:"project,omitempty" json:"project,omitempty"`
+	// Insecure allows pushing charts to a registry with an untrusted certificate.
+	Insecure bool `yaml:"insecure,omitempty" json:"insecure,omitempty"`
+}
+
+// ImageName returns the name of image in this registry, replacing the registry
+// host of image (if it has one) with the host and project of this registry.
+func (r RegistryConfig) ImageName(image string) string {
+	segs := strings.SplitN(image, "/", 2)
+	if len(segs) == 2 && (strings.ContainsAny(segs[0], ".:") || segs[0] == "localhost") {
+		image = segs[1]
+	}
+	return path.Join(r.Repository(), image)
+}
+
+// Repository returns the host and project of the registry.
+func (r RegistryConfig) Repository() string {
+	return path.Join(r.Host, r.Project)
+}
+
+// ChartRepository returns the OCI repository that charts are pushed to in the registry.
+func (r RegistryConfig) ChartRepository() string {
+	return "oci://" + path.Join(r.Repository(), "charts")
+}
+
 type Command struct {
 	FromPath string                `yaml:"fromPath,omitempty" json:"fromPath,omitempty"`
 	Name     string                `yaml:"name" json:"name"`
@@ -0,0 +1,32 @@
+package environment_test
+
+import (
+	. "github.com/naveego/bosun/pkg/environment"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RegistryConfig", func() {
+
+	registry := RegistryConfig{Host: "registry.local:5000", Project: "offline"}
+
+	DescribeTable("ImageName",
+		func(r RegistryConfig, image string, expected string) {
+			Expect(r.ImageName(image)).To(Equal(expected))
+		},
+		Entry("replaces a registry host", registry, "docker.n5o.black/private/api:1.2.3", "registry.local:5000/offline/private/api:1.2.3"),
+		Entry("replaces a registry host with a port", registry, "localhost:5000/api", "registry.local:5000/offline/api"),
+		Entry("replaces localhost", registry, "localhost/api", "registry.local:5000/offline/api"),
+		Entry("keeps the namespace of a docker hub image", registry, "bitnami/nats:2.1", "registry.local:5000/offline/bitnami/nats:2.1"),
+		Entry("prefixes an official image", registry, "mongo:3.6", "registry.local:5000/offline/mongo:3.6"),
+		Entry("keeps a digest", registry, "docker.n5o.black/private/api@sha256:abc", "registry.local:5000/offline/private/api@sha256:abc"),
+		Entry("works without a project", RegistryConfig{Host: "registry.local"}, "docker.n5o.black/private/api", "registry.local/private/api"),
+	)
+
+	It("returns the chart repository", func() {
+		Expect(registry.Repository()).To(Equal("registry.local:5000/offline"))
+		Expect(registry.ChartRepository()).To(Equal("oci://registry.local:5000/offline/charts"))
+		Expect(RegistryConfig{Host: "registry.local"}.ChartRepository()).To(Equal("oci://registry.local/charts"))
+	})
+})
@@ -51,7 +51,7 @@ func (e *Environment) GetValueSetCollection() values.ValueSetCollection {
 
 // IsAppDisabled returns true if the app is disabled for the environment.
 // Apps are assumed to be disabled for the environment unless they are in the app list and not marked as disabled
-func (e Environment) IsAppDisabled(appName string) bool {
+func (e Config) IsAppDisabled(appName string) bool {
 	v, ok := e.Apps[appName]
 	return !ok || v.Disabled
 }
@@ -207,7 +207,7 @@ func (e *Environment) Execute(ctx environmentvariables.Dependencies) error {
 	return nil
 }
 
-func (e *Environment) GetSecretGroupConfig(groupName string) (*SecretGroupConfig, error) {
+func (e *Config) GetSecretGroupConfig(groupName string) (*SecretGroupConfig, error) {
 
 	secretGroupFilePath, ok := e.SecretGroupFilePaths[groupName]
 	if !ok {
@@ -226,7 +226,7 @@ func (e *Environment) GetSecretGroupConfig(groupName string) (*SecretGroupConfig
 	return &secretGroupConfig, nil
 }
 
-func (e *Environment) GetSecretConfig(groupName string, secretName string) (*SecretConfig, error) {
+func (e *Config) GetSecretConfig(groupName string, secretName string) (*SecretConfig, error) {
 	group, err := e.GetSecretGroupConfig(groupName)
 	if err != nil {
 		return nil, err
@@ -308,6 +308,55 @@ func (e *Environment) AddSecretGroup(groupName string, keyConfig *SecretKeyConfi
 	return err
 }
 
+// MigrateSecretGroup moves the values of a secret group to a different backend, then saves the group.
+// If the group is being moved to the file backend, keyConfig must be provided unless the group already has a key.
+// The values are not removed from the previous backend.
+func (e *Environment) MigrateSecretGroup(groupName string, backend *SecretBackendConfig, keyConfig *SecretKeyConfig) error {
+	group, err := e.getSecretGroup(groupName)
+	if err != nil {
+		return err
+	}
+
+	config := group.config
+	from := config.GetBackendType()
+
+	config.Backend = backend
+	if config.GetBackendType() == SecretBackendFile {
+		config.Backend = nil
+		if keyConfig != nil {
+			config.Key = keyConfig
+		}
+		if config.Key == nil {
+			return errors.Errorf("a key config is required to migrate secret group %q to the %s backend", groupName, SecretBackendFile)
+		}
+	} else {
+		config.Key = nil
+		config.SecretValues = ""
+	}
+
+	group.values.Dirty = true
+	if err = group.Save(); err != nil {
+		return errors.Wrapf(err, "migrate secret group %q from %s backend to %s backend", groupName, from, config.GetBackendType())
+	}
+
+	// make sure the values can be read back from the new backend
+	migrated, err := e.GetSecretGroupConfig(groupName)
+	if err != nil {
+		return err
+	}
+	reloaded, err := NewSecretGroup(migrated)
+	if err != nil {
+		return errors.Wrap(err, "verify migrated secret group")
+	}
+	for name, value := range group.values.Values {
+		if reloaded.values.Values[name] != value {
+			return errors.Errorf("verify migrated secret group: value of secret %q was not migrated", name)
+		}
+	}
+
+	return nil
+}
+
 func (e *Environment) DeleteSecretGroup(groupName string) error {
 
 	if groupFilePath, ok := e.SecretGroupFilePaths[groupName]; ok {
@@ -343,6 +392,16 @@ func (e *Environment) AddOrUpdateSecretValue(groupName string, secretName string
 	return group.AddOrUpdateSecretValue(secretName, value)
 }
 
+// RotateSecrets generates new values for secrets in a group. See SecretGroup.RotateSecrets.
+func (e *Environment) RotateSecrets(groupName string, secretNames ...string) ([]string, error) {
+	group, err := e.getSecretGroup(groupName)
+	if err != nil {
+		return nil, err
+	}
+
+	return group.RotateSecrets(secretNames...)
+}
+
 func (e *Environment) ResolveSecretPath(secretPath string) (string, error) {
 
 	sp, err := ParseSecretPath(secretPath)
@@ -371,7 +430,7 @@ func (e *Environment) ValidateSecrets(secretPaths ...string) error {
 	return nil
 }
 
-func (e *Environment) GetSecretGroupConfigs() ([]SecretGroupConfig, error) {
+func (e *Config) GetSecretGroupConfigs() ([]SecretGroupConfig, error) {
 	var out []SecretGroupConfig
 	for name := range e.SecretGroupFilePaths {
 		group, err := e.GetSecretGroupConfig(name)
@@ -1,11 +1,38 @@
 package environment
 
+import (
+	"time"
+)
+
 type SecretConfig struct {
 	Name string `yaml:"name"`
 	Description string `yaml:"description"`
 	Generation *SecretGenerationConfig `yaml:"generation,omitempty"`
+	// RotatedAt is when the value of the secret was last generated, or zero if that is not known.
+	RotatedAt time.Time `yaml:"rotatedAt,omitempty"`
 }
 
 type SecretGenerationConfig struct {
 	Length int `yaml:"length"`
+	// RotateAfterDays is how old the secret can get before it should be rotated.
+	// If not set, the default used by `bosun secrets list` applies.
+	RotateAfterDays int `yaml:"rotateAfterDays,omitempty"`
+}
+
+// IsStale returns true if the secret is generated and has not been rotated within
+// its rotation period, or within defaultMaxAge if it doesn't specify one.
+// Secrets with no RotatedAt were generated before rotation was tracked, so their age
+// is unknown and they are reported as stale until they are rotated.
+func (s *SecretConfig) IsStale(now time.Time, defaultMaxAge time.Duration) bool {
+	if s.Generation == nil {
+		return false
+	}
+	if s.RotatedAt.IsZero() {
+		return true
+	}
+	maxAge := defaultMaxAge
+	if s.Generation.RotateAfterDays > 0 {
+		maxAge = time.Duration(s.Generation.RotateAfterDays) * 24 * time.Hour
+	}
+	return now.Sub(s.RotatedAt) > maxAge
 }
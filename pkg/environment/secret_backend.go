@@ -0,0 +1,63 @@
+package environment
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	SecretBackendFile       = "file"
+	SecretBackendVault      = "vault"
+	SecretBackendSops       = "sops"
+	SecretBackendKubernetes = "kubernetes"
+)
+
+// SecretBackends lists the supported secret backend types.
+var SecretBackends = []string{
+	SecretBackendFile,
+	SecretBackendVault,
+	SecretBackendSops,
+	SecretBackendKubernetes,
+}
+
+// SecretBackendConfig controls where the values of a secret group are stored.
+// The group config itself (the secret names, descriptions and generation settings)
+// is always stored in the group file.
+type SecretBackendConfig struct {
+	// Type is one of "file" (the default), "vault", "sops" or "kubernetes".
+	Type       string                         `yaml:"type"`
+	Vault      *VaultSecretBackendConfig      `yaml:"vault,omitempty"`
+	Sops       *SopsSecretBackendConfig       `yaml:"sops,omitempty"`
+	Kubernetes *KubernetesSecretBackendConfig `yaml:"kubernetes,omitempty"`
+}
+
+// SecretBackend loads and stores the values of a secret group.
+type SecretBackend interface {
+	Load(group *SecretGroupConfig) (*SecretValues, error)
+	Save(group *SecretGroupConfig, values *SecretValues) error
+}
+
+// GetBackendType returns the type of the backend the group's values are stored in.
+func (s *SecretGroupConfig) GetBackendType() string {
+	if s.Backend == nil || s.Backend.Type == "" {
+		return SecretBackendFile
+	}
+	return s.Backend.Type
+}
+
+func newSecretBackend(group *SecretGroupConfig) (SecretBackend, error) {
+	switch group.GetBackendType() {
+	case SecretBackendFile:
+		if group.Key == nil {
+			return nil, errors.Errorf("secret group %q is stored in a file but has no key config", group.Name)
+		}
+		return fileSecretBackend{}, nil
+	case SecretBackendVault:
+		return newVaultSecretBackend(group.Backend.Vault), nil
+	case SecretBackendSops:
+		return newSopsSecretBackend(group.Backend.Sops), nil
+	case SecretBackendKubernetes:
+		return newKubernetesSecretBackend(group.Backend.Kubernetes), nil
+	default:
+		return nil, errors.Errorf("unsupported secret backend %q (supported backends are %v)", group.Backend.Type, SecretBackends)
+	}
+}
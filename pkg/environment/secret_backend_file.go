@@ -0,0 +1,84 @@
+package environment
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"github.com/naveego/bosun/pkg/yaml"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// fileSecretBackend stores the secret values in the group file itself,
+// AES encrypted using the group's key.
+type fileSecretBackend struct{}
+
+func (fileSecretBackend) Load(group *SecretGroupConfig) (*SecretValues, error) {
+
+	key, nonce, err := group.Key.GetKeyComponents(group.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	hextext := group.SecretValues
+	if len(hextext) == 0 {
+		return &SecretValues{}, nil
+	}
+
+	ciphertext, err := hex.DecodeString(strings.Replace(string(hextext), "\n", "", -1))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid secrets file (should be hex encoded)")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	plaintext, err := aesgcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid secret data")
+	}
+
+	var values SecretValues
+	err = yaml.Unmarshal(plaintext, &values)
+	if err != nil {
+		return nil, errors.Wrapf(err, "")
+	}
+
+	return &values, nil
+}
+
+func (fileSecretBackend) Save(group *SecretGroupConfig, values *SecretValues) error {
+
+	// discard previous nonce because we are encrypting new info
+	group.Key.Nonce = ""
+
+	key, nonce, err := group.Key.GetKeyComponents(group.Name)
+	if err != nil {
+		return err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	plaintext, _ := yaml.Marshal(values)
+
+	ciphertext := aesgcm.Seal(nil, nonce, plaintext, nil)
+
+	group.SecretValues = hex.EncodeToString(ciphertext)
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package environment
+
+import (
+	"fmt"
+	"github.com/naveego/bosun/pkg/kube/kubeclient"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const SecretGroupLabel = "bosun.aunalytics.com/secret-group"
+
+// KubernetesSecretBackendConfig stores secret values in a Kubernetes Secret,
+// with one key per secret.
+type KubernetesSecretBackendConfig struct {
+	// Namespace the Secret is stored in. Defaults to "default".
+	Namespace string `yaml:"namespace,omitempty"`
+	// Name of the Secret. Defaults to "bosun-secrets-{group name}".
+	Name string `yaml:"name,omitempty"`
+	// KubeconfigPath is the kubeconfig to use, if KUBECONFIG should not be used.
+	KubeconfigPath string `yaml:"kubeconfigPath,omitempty"`
+	// Context is the kubeconfig context to use, if the current context should not be used.
+	Context string `yaml:"context,omitempty"`
+}
+
+type kubernetesSecretBackend struct {
+	config KubernetesSecretBackendConfig
+}
+
+func newKubernetesSecretBackend(config *KubernetesSecretBackendConfig) kubernetesSecretBackend {
+	b := kubernetesSecretBackend{}
+	if config != nil {
+		b.config = *config
+	}
+	if b.config.Namespace == "" {
+		b.config.Namespace = "default"
+	}
+	return b
+}
+
+func (k kubernetesSecretBackend) getName(group *SecretGroupConfig) string {
+	if k.config.Name != "" {
+		return k.config.Name
+	}
+	return fmt.Sprintf("bosun-secrets-%s", group.Name)
+}
+
+func (k kubernetesSecretBackend) Load(group *SecretGroupConfig) (*SecretValues, error) {
+	client, err := kubeclient.GetKubeClientWithContext(k.config.KubeconfigPath, k.config.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	name := k.getName(group)
+	secret, err := client.CoreV1().Secrets(k.config.Namespace).Get(name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return &SecretValues{}, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "get secret %q in namespace %q", name, k.config.Namespace)
+	}
+
+	values := &SecretValues{
+		Values: map[string]string{},
+	}
+	for key, value := range secret.Data {
+		values.Values[key] = string(value)
+	}
+
+	return values, nil
+}
+
+func (k kubernetesSecretBackend) Save(group *SecretGroupConfig, values *SecretValues) error {
+	client, err := kubeclient.GetKubeClientWithContext(k.config.KubeconfigPath, k.config.Context)
+	if err != nil {
+		return err
+	}
+
+	name := k.getName(group)
+	secrets := client.CoreV1().Secrets(k.config.Namespace)
+
+	data := map[string][]byte{}
+	for key, value := range values.Values {
+		data[key] = []byte(value)
+	}
+
+	secret, err := secrets.Get(name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		_, err = secrets.Create(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					SecretGroupLabel: group.Name,
+				},
+			},
+			Type: v1.SecretTypeOpaque,
+			Data: data,
+		})
+		return errors.Wrapf(err, "create secret %q in namespace %q", name, k.config.Namespace)
+	} else if err != nil {
+		return errors.Wrapf(err, "get secret %q in namespace %q", name, k.config.Namespace)
+	}
+
+	secret.Data = data
+	_, err = secrets.Update(secret)
+	return errors.Wrapf(err, "update secret %q in namespace %q", name, k.config.Namespace)
+}
@@ -0,0 +1,96 @@
+package environment
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/naveego/bosun/pkg/command"
+	"github.com/naveego/bosun/pkg/yaml"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SopsSecretBackendConfig stores secret values in a SOPS encrypted file.
+// The sops executable must be installed. Decryption uses the usual sops
+// configuration, such as SOPS_AGE_KEY_FILE.
+type SopsSecretBackendConfig struct {
+	// Path to the encrypted file, relative to the group file. Defaults to "{group name}.secrets.sops.yaml".
+	Path string `yaml:"path,omitempty"`
+	// AgeRecipients are the age public keys the file is encrypted for.
+	// If empty, the creation rules in .sops.yaml are used.
+	AgeRecipients []string `yaml:"ageRecipients,omitempty"`
+}
+
+type sopsSecretBackend struct {
+	config SopsSecretBackendConfig
+}
+
+func newSopsSecretBackend(config *SopsSecretBackendConfig) sopsSecretBackend {
+	b := sopsSecretBackend{}
+	if config != nil {
+		b.config = *config
+	}
+	return b
+}
+
+func (s sopsSecretBackend) getPath(group *SecretGroupConfig) string {
+	path := s.config.Path
+	if path == "" {
+		path = fmt.Sprintf("%s.secrets.sops.yaml", group.Name)
+	}
+	return group.ResolveRelative(path)
+}
+
+func (s sopsSecretBackend) Load(group *SecretGroupConfig) (*SecretValues, error) {
+	path := s.getPath(group)
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return &SecretValues{}, nil
+	}
+
+	plaintext, err := command.NewShellExe("sops", "--decrypt", "--input-type", "yaml", "--output-type", "yaml", path).RunOut()
+	if err != nil {
+		return nil, errors.Wrapf(err, "decrypt secret values from %q using sops", path)
+	}
+
+	var values SecretValues
+	err = yaml.UnmarshalString(plaintext, &values)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse secret values decrypted from %q", path)
+	}
+
+	return &values, nil
+}
+
+// getEncryptArgs returns the sops arguments to encrypt the values which will be written to path.
+// The plaintext is passed on stdin so that it is never written to disk, and the path is passed
+// as the file name so that the path rules in .sops.yaml are applied.
+func (s sopsSecretBackend) getEncryptArgs(path string) []string {
+	args := []string{"--encrypt", "--input-type", "yaml", "--output-type", "yaml", "--filename-override", path}
+	if len(s.config.AgeRecipients) > 0 {
+		args = append(args, "--age", strings.Join(s.config.AgeRecipients, ","))
+	}
+	return append(args, "/dev/stdin")
+}
+
+func (s sopsSecretBackend) Save(group *SecretGroupConfig, values *SecretValues) error {
+	path := s.getPath(group)
+
+	plaintext, err := yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	// sops looks for .sops.yaml starting in its working directory.
+	exe := command.NewShellExe("sops", s.getEncryptArgs(path)...).WithDir(filepath.Dir(path))
+	exe.GetCmd().Stdin = bytes.NewReader(plaintext)
+	ciphertext, err := exe.RunOut()
+	if err != nil {
+		return errors.Wrapf(err, "encrypt secret values for %q using sops", path)
+	}
+
+	err = ioutil.WriteFile(path, []byte(ciphertext+"\n"), 0600)
+	return errors.Wrapf(err, "write encrypted secret values to %q", path)
+}
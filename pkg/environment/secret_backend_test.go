@@ -0,0 +1,119 @@
+package environment
+
+import (
+	"github.com/naveego/bosun/pkg/command"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var _ = Describe("SecretBackend", func() {
+
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "bosun-secret-backend")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	newGroup := func(backend *SecretBackendConfig) *SecretGroupConfig {
+		group := &SecretGroupConfig{
+			Key:     &SecretKeyConfig{UnsafeStoredPassphrase: "test"},
+			Backend: backend,
+		}
+		group.Name = "test"
+		group.SetFromPath(filepath.Join(dir, "test.secrets.yaml"))
+		return group
+	}
+
+	values := &SecretValues{Values: map[string]string{
+		"password": "hunter2",
+		"token":    "multi\nline: value",
+	}}
+
+	Describe("file", func() {
+
+		It("round trips values through the encrypted group file", func() {
+			group := newGroup(nil)
+			backend, err := newSecretBackend(group)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(backend.Save(group, values)).To(Succeed())
+			Expect(group.SecretValues).ToNot(BeEmpty())
+			Expect(group.SecretValues).ToNot(ContainSubstring("hunter2"))
+
+			loaded, err := backend.Load(group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Values).To(Equal(values.Values))
+		})
+
+		It("returns no values for a new group", func() {
+			group := newGroup(nil)
+			loaded, err := fileSecretBackend{}.Load(group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Values).To(BeEmpty())
+		})
+
+		It("rejects values encrypted with a different key", func() {
+			group := newGroup(nil)
+			Expect(fileSecretBackend{}.Save(group, values)).To(Succeed())
+
+			group.Key.UnsafeStoredPassphrase = "wrong"
+			group.Key.key = nil
+			_, err := fileSecretBackend{}.Load(group)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("sops", func() {
+
+		It("passes the target path to sops so that .sops.yaml path rules apply", func() {
+			backend := newSopsSecretBackend(&SopsSecretBackendConfig{AgeRecipients: []string{"age1a", "age1b"}})
+			args := backend.getEncryptArgs("/secrets/test.secrets.sops.yaml")
+			Expect(strings.Join(args, " ")).To(ContainSubstring("--filename-override /secrets/test.secrets.sops.yaml"))
+			Expect(strings.Join(args, " ")).To(ContainSubstring("--age age1a,age1b"))
+			Expect(args[len(args)-1]).To(Equal("/dev/stdin"))
+		})
+
+		It("round trips values through a sops file encrypted using the .sops.yaml rules", func() {
+			if _, err := exec.LookPath("sops"); err != nil {
+				Skip("sops is not installed")
+			}
+			if _, err := exec.LookPath("age-keygen"); err != nil {
+				Skip("age-keygen is not installed")
+			}
+
+			keyPath := filepath.Join(dir, "age.key")
+			Expect(command.NewShellExe("age-keygen", "-o", keyPath).RunE()).To(Succeed())
+			recipient, err := command.NewShellExe("age-keygen", "-y", keyPath).RunOut()
+			Expect(err).ToNot(HaveOccurred())
+
+			sopsConfig := "creation_rules:\n  - path_regex: \\.secrets\\.sops\\.yaml$\n    age: " + strings.TrimSpace(recipient) + "\n"
+			Expect(ioutil.WriteFile(filepath.Join(dir, ".sops.yaml"), []byte(sopsConfig), 0600)).To(Succeed())
+			Expect(os.Setenv("SOPS_AGE_KEY_FILE", keyPath)).To(Succeed())
+			defer os.Unsetenv("SOPS_AGE_KEY_FILE")
+
+			group := newGroup(&SecretBackendConfig{Type: SecretBackendSops})
+			backend, err := newSecretBackend(group)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(backend.Save(group, values)).To(Succeed())
+			ciphertext, err := ioutil.ReadFile(filepath.Join(dir, "test.secrets.sops.yaml"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(ciphertext)).ToNot(ContainSubstring("hunter2"))
+
+			loaded, err := backend.Load(group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Values).To(Equal(values.Values))
+		})
+	})
+})
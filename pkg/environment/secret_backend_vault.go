@@ -0,0 +1,90 @@
+package environment
+
+import (
+	"fmt"
+	"github.com/naveego/bosun/pkg/core"
+	"github.com/naveego/bosun/pkg/vault"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// VaultSecretBackendConfig stores secret values in a Vault KV v2 secrets engine.
+// The vault client is configured using the usual VAULT_ADDR and VAULT_TOKEN environment variables.
+type VaultSecretBackendConfig struct {
+	// Address of the vault server, if VAULT_ADDR should not be used.
+	Address string `yaml:"address,omitempty"`
+	// Mount is the path the KV v2 engine is mounted at. Defaults to "secret".
+	Mount string `yaml:"mount,omitempty"`
+	// Path is the path of the secret in the KV engine. Defaults to "bosun/{group name}".
+	Path string `yaml:"path,omitempty"`
+}
+
+type vaultSecretBackend struct {
+	config VaultSecretBackendConfig
+}
+
+func newVaultSecretBackend(config *VaultSecretBackendConfig) vaultSecretBackend {
+	b := vaultSecretBackend{}
+	if config != nil {
+		b.config = *config
+	}
+	if b.config.Mount == "" {
+		b.config.Mount = "secret"
+	}
+	return b
+}
+
+func (v vaultSecretBackend) getDataPath(group *SecretGroupConfig) string {
+	path := v.config.Path
+	if path == "" {
+		path = fmt.Sprintf("bosun/%s", group.Name)
+	}
+	return fmt.Sprintf("%s/data/%s", strings.Trim(v.config.Mount, "/"), strings.Trim(path, "/"))
+}
+
+func (v vaultSecretBackend) Load(group *SecretGroupConfig) (*SecretValues, error) {
+	client, err := vault.NewVaultLowlevelClient("", v.config.Address, core.Log)
+	if err != nil {
+		return nil, err
+	}
+
+	path := v.getDataPath(group)
+	secret, err := client.Logical().Read(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read secret values from vault at %q", path)
+	}
+
+	values := &SecretValues{}
+	if secret == nil || secret.Data == nil {
+		return values, nil
+	}
+
+	data, _ := secret.Data["data"].(map[string]interface{})
+	for name, value := range data {
+		if values.Values == nil {
+			values.Values = map[string]string{}
+		}
+		values.Values[name] = fmt.Sprint(value)
+	}
+
+	return values, nil
+}
+
+func (v vaultSecretBackend) Save(group *SecretGroupConfig, values *SecretValues) error {
+	client, err := vault.NewVaultLowlevelClient("", v.config.Address, core.Log)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{}
+	for name, value := range values.Values {
+		data[name] = value
+	}
+
+	path := v.getDataPath(group)
+	_, err = client.Logical().Write(path, map[string]interface{}{
+		"data": data,
+	})
+
+	return errors.Wrapf(err, "write secret values to vault at %q", path)
+}
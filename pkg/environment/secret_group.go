@@ -1,22 +1,23 @@
 package environment
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/rand"
-	"encoding/hex"
 	"github.com/naveego/bosun/pkg/core"
+	"github.com/naveego/bosun/pkg/util/stringsn"
 	"github.com/naveego/bosun/pkg/yaml"
 	"github.com/pkg/errors"
-	"strings"
+	"time"
 )
 
 type SecretGroupConfig struct {
 	core.ConfigShared `yaml:",inline"`
 	Secrets           []*SecretConfig  `yaml:"secrets"`
-	Key               *SecretKeyConfig `yaml:"key"`
+	Key               *SecretKeyConfig `yaml:"key,omitempty"`
+	// Backend controls where the secret values are stored. If not set the values
+	// are encrypted using Key and stored in SecretValues.
+	Backend *SecretBackendConfig `yaml:"backend,omitempty"`
 
-	SecretValues string `yaml:"secretValues"`
+	SecretValues string `yaml:"secretValues,omitempty"`
 
 	// true if this is a new secret group that has no saved file
 	isNew bool `yaml:"-"`
@@ -49,43 +50,17 @@ func newSecretGroup(s *SecretGroupConfig) (*SecretGroup, error) {
 		return group, nil
 	}
 
-	key, nonce, err := group.config.Key.GetKeyComponents(s.Name)
+	backend, err := newSecretBackend(s)
 	if err != nil {
 		return nil, err
 	}
 
-	hextext := s.SecretValues
-	if len(hextext) == 0 {
-		return group, nil
-	}
-
-	ciphertext, err := hex.DecodeString(strings.Replace(string(hextext), "\n", "", -1))
-	if err != nil {
-		return nil, errors.Wrap(err, "invalid secrets file (should be hex encoded)")
-	}
-
-	block, err := aes.NewCipher(key)
+	values, err := backend.Load(s)
 	if err != nil {
-		panic(err.Error())
-	}
-
-	aesgcm, err := cipher.NewGCM(block)
-	if err != nil {
-		panic(err.Error())
-	}
-
-	plaintext, err := aesgcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "invalid secret data")
-	}
-
-	var values SecretValues
-	err = yaml.Unmarshal(plaintext, &values)
-	if err != nil {
-		return nil, errors.Wrapf(err, "")
+		return nil, err
 	}
 
-	group.values = &values
+	group.values = values
 	return group, nil
 }
 
@@ -163,6 +138,7 @@ func (s *SecretGroup) getSecretValue(name string, generationConfig *SecretGenera
 	password := SecureRandomPassword(DefaultPasswordAlphabet, secretConfig.Generation.Length)
 
 	s.values.setValue(name, password)
+	secretConfig.RotatedAt = time.Now()
 
 	err := s.Save()
 	if err != nil {
@@ -229,6 +205,47 @@ func (s *SecretGroup) AddOrUpdateSecretValue(name string, value string) error {
 	return s.Save()
 }
 
+// RotateSecrets generates new values for the named secrets using their generation config,
+// then saves the group. The current value of each secret is retained as {name}.previous
+// so that it can still be resolved while apps are being redeployed.
+// If no names are provided, all secrets which have a generation config are rotated.
+func (s *SecretGroup) RotateSecrets(names ...string) ([]string, error) {
+	var rotated []string
+
+	for _, secretConfig := range s.config.Secrets {
+		if len(names) > 0 && !stringsn.Contains(names, secretConfig.Name) {
+			continue
+		}
+		if secretConfig.Generation == nil {
+			if len(names) > 0 {
+				return nil, errors.Errorf("secret %q in group %q has no generation config so it can't be rotated", secretConfig.Name, s.config.Name)
+			}
+			continue
+		}
+
+		if secretConfig.Generation.Length == 0 {
+			secretConfig.Generation.Length = 20
+		}
+
+		if previous, ok := s.values.Values[secretConfig.Name]; ok {
+			s.values.setValue(secretConfig.Name+PreviousSecretValueSuffix, previous)
+		}
+
+		s.values.setValue(secretConfig.Name, SecureRandomPassword(DefaultPasswordAlphabet, secretConfig.Generation.Length))
+		secretConfig.RotatedAt = time.Now()
+
+		rotated = append(rotated, secretConfig.Name)
+	}
+
+	for _, name := range names {
+		if !stringsn.Contains(rotated, name) {
+			return nil, errors.Errorf("group %q did not contain secret %q", s.config.Name, name)
+		}
+	}
+
+	return rotated, s.Save()
+}
+
 // DeleteSecretConfig deletes a secret config (and the value) from the group, then saves the group.
 func (s *SecretGroup) DeleteSecretConfig(name string) error {
 	var secrets []*SecretConfig
@@ -241,6 +258,7 @@ func (s *SecretGroup) DeleteSecretConfig(name string) error {
 	s.config.Secrets = secrets
 
 	s.values.deleteValue(name)
+	s.values.deleteValue(name + PreviousSecretValueSuffix)
 
 	return s.Save()
 }
@@ -259,7 +277,7 @@ func (s *SecretGroup) DeleteAllSecretValues() error {
 	return s.Save()
 }
 
-// Save encrypts the secret values and saves them to disk.
+// Save stores the secret values in the group's backend and saves the group config to disk.
 func (s *SecretGroup) Save() error {
 	err := s.save()
 	return errors.Wrapf(err, "save secret group %s", s.config.Name)
@@ -268,30 +286,15 @@ func (s *SecretGroup) Save() error {
 func (s *SecretGroup) save() error {
 
 	if s.values.Dirty {
-		// discard previous nonce because we are encrypting new info
-		s.config.Key.Nonce = ""
-
-		key, nonce, err := s.config.Key.GetKeyComponents(s.config.Name)
+		backend, err := newSecretBackend(s.config)
 		if err != nil {
 			return err
 		}
 
-		block, err := aes.NewCipher(key)
-		if err != nil {
-			panic(err.Error())
-		}
-
-		aesgcm, err := cipher.NewGCM(block)
+		err = backend.Save(s.config, s.values)
 		if err != nil {
-			panic(err.Error())
+			return err
 		}
-
-		plaintext, _ := yaml.Marshal(s.values)
-
-		ciphertext := aesgcm.Seal(nil, nonce, plaintext, nil)
-
-		s.config.SecretValues = hex.EncodeToString(ciphertext)
-
 	}
 
 	err := yaml.SaveYaml(s.config.FromPath, s.config)
@@ -301,6 +304,8 @@ func (s *SecretGroup) save() error {
 
 const (
 	DefaultPasswordAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789"
+	// PreviousSecretValueSuffix is appended to the name of a secret to get the value it had before it was last rotated.
+	PreviousSecretValueSuffix = ".previous"
 )
 
 func SecureRandomPassword(alphabet string, length int) string {
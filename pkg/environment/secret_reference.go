@@ -0,0 +1,27 @@
+package environment
+
+import (
+	"regexp"
+)
+
+var secretPathReferenceRE = regexp.MustCompile(`resolveSecretPath\s+"([^"]+)"`)
+
+// FindSecretPathReferences returns the secret paths passed to resolveSecretPath
+// in the templates in the provided text, in the order they appear.
+func FindSecretPathReferences(text string) []string {
+	var out []string
+	for _, match := range secretPathReferenceRE.FindAllStringSubmatch(text, -1) {
+		out = append(out, match[1])
+	}
+	return out
+}
+
+// Matches returns true if the other path refers to the same secret as this path,
+// ignoring any generation parameters.
+func (s *SecretPath) Matches(other *SecretPath) bool {
+	return s.GroupName == other.GroupName && s.SecretName == other.SecretName
+}
+
+func (s *SecretPath) String() string {
+	return s.GroupName + "/" + s.SecretName
+}
@@ -0,0 +1,38 @@
+package environment_test
+
+import (
+	. "github.com/naveego/bosun/pkg/environment"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("SecretConfig", func() {
+
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	defaultMaxAge := 90 * 24 * time.Hour
+
+	It("is not stale if it is not generated", func() {
+		secret := SecretConfig{Name: "manual", RotatedAt: now.AddDate(-1, 0, 0)}
+		Expect(secret.IsStale(now, defaultMaxAge)).To(BeFalse())
+	})
+
+	It("is stale if its rotation time is unknown", func() {
+		secret := SecretConfig{Name: "legacy", Generation: &SecretGenerationConfig{Length: 16}}
+		Expect(secret.IsStale(now, defaultMaxAge)).To(BeTrue())
+	})
+
+	It("is stale once it is older than the default max age", func() {
+		secret := SecretConfig{Name: "generated", Generation: &SecretGenerationConfig{Length: 16}}
+		secret.RotatedAt = now.AddDate(0, 0, -89)
+		Expect(secret.IsStale(now, defaultMaxAge)).To(BeFalse())
+		secret.RotatedAt = now.AddDate(0, 0, -91)
+		Expect(secret.IsStale(now, defaultMaxAge)).To(BeTrue())
+	})
+
+	It("uses its own rotation period if it has one", func() {
+		secret := SecretConfig{Name: "generated", Generation: &SecretGenerationConfig{Length: 16, RotateAfterDays: 7}}
+		secret.RotatedAt = now.AddDate(0, 0, -8)
+		Expect(secret.IsStale(now, defaultMaxAge)).To(BeTrue())
+	})
+})
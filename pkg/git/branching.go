@@ -28,8 +28,10 @@ type BranchSpec struct {
 			"Slug":string,
 		  }
 	*/
-	Feature     string `yaml:"feature"`
-	IsDefaulted bool   `yaml:"-"`
+	Feature string `yaml:"feature"`
+	// CommitRules configure how commits are parsed for change logs and version bumps.
+	CommitRules *CommitRules `yaml:"commitRules,omitempty"`
+	IsDefaulted bool         `yaml:"-"`
 }
 
 func (f BranchSpec) MarshalYAML() (interface{}, error) {
@@ -110,8 +112,9 @@ func (b BranchSpec) WithDefaultsFrom(d BranchSpec) BranchSpec {
 		b.Release = d.Release
 	}
 	if b.Feature == "" {
-		b.Feature = b.Feature
+		b.Feature = d.Feature
 	}
+	b.CommitRules = b.CommitRules.WithDefaultsFrom(d.CommitRules)
 	return b
 }
 
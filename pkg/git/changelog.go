@@ -26,6 +26,8 @@ type GitChangeLog struct {
 type GitChangeLogOptions struct {
 	Description bool
 	UnknownType bool
+	// Rules configure how commits are parsed. Defaults to DefaultCommitRules().
+	Rules *CommitRules
 }
 
 const (
@@ -35,50 +37,27 @@ const (
 	StateLookingForTitle
 	StateLookingForBody
 )
-var bumpMap = map[string]semver.Bump{
-	"feat":              semver.BumpMinor,
-	"fix":               semver.BumpPatch,
-	"refactor":          semver.BumpPatch,
-	"perf":              semver.BumpPatch,
-	"docs":              semver.BumpNone,
-	"style":             semver.BumpNone,
-	"test":              semver.BumpNone,
-	"chore":             semver.BumpNone,
-	"deploy":            semver.BumpPatch,
-	MalformedCommitFlag: semver.BumpPatch,
-}
-
-func init(){
-	for k := range bumpMap {
-		allTypes = append(allTypes, k)
-	}
-}
-
-var allTypes []string
-var allCommitTypeString = strings.Join(allTypes, "|")
 
-// TODO: imporve Regex with $ and ^ to be robust
-var RegexMatchCommitID = regexp.MustCompile(`commit .{40}`)
-var RegexMatchFormattedTitle = regexp.MustCompile(`(` + allCommitTypeString + `)(\([^)]+\))?:(.*)`)
+var RegexMatchCommitID = regexp.MustCompile(`^commit [0-9a-f]{40}`)
 var RegexMatchAlternativeTitle = regexp.MustCompile(`^\* .*`)
-var RegexMatchDate = regexp.MustCompile(`Date: .*`)
-var RegexMatchAuthor = regexp.MustCompile(`Author: ([^<]+)\s+<([^>]+)>`)
-var RegexMatchIssue = regexp.MustCompile(`\s+(resolves )?(@|#)?[0-9]+$|^[0-9]+$`)
+var RegexMatchDate = regexp.MustCompile(`^Date: .*`)
+var RegexMatchAuthor = regexp.MustCompile(`^Author: ([^<]+)\s+<([^>]+)>`)
 
-var RegexGetCommitType = regexp.MustCompile(`(` + allCommitTypeString + `)`)
 var RegexGetCommitId = regexp.MustCompile(` .{40}`)
 var RegexGetDate = regexp.MustCompile(`Date: `)
-var RegexGetIssue = regexp.MustCompile(`[0-9]+$`)
 
 var skipKeys = []*regexp.Regexp{
 	regexp.MustCompile(`^\s*$`),
 }
 
-
-
 const MalformedCommitFlag = "malformed"
 
 func (g GitWrapper) ChangeLog(notInBranch, inBranch string, svc issues.IssueService, options GitChangeLogOptions) (GitChangeLog, error) {
+	rules, err := options.Rules.compile()
+	if err != nil {
+		return GitChangeLog{}, err
+	}
+
 	out, err := g.Exec("log", "--no-merges", fmt.Sprintf("%s..%s", inBranch, notInBranch))
 
 	org, repo := GetRepoRefFromPath(g.dir).OrgAndRepo()
@@ -86,10 +65,35 @@ func (g GitWrapper) ChangeLog(notInBranch, inBranch string, svc issues.IssueServ
 	var committer string
 	var commitId string
 	var title string
+	var scope string
+	var breaking bool
 	var date string
 	var bodyBuilder = strings.Builder{}
 	var commitType string
 	var state = StateLookingForCommitNumber
+
+	newChange := func() GitChange {
+		change := GitChange{
+			CommitID:       commitId,
+			Committer:      committer,
+			CommitType:     commitType,
+			Scope:          scope,
+			Title:          CleanFrontAsterisk(title),
+			Body:           bodyBuilder.String(),
+			Date:           date,
+			Valid:          true,
+			BreakingChange: breaking || rules.isBreakingChange(bodyBuilder.String()),
+		}
+		change.Bump = rules.getBump(change)
+		return change
+	}
+	setTitle := func(line string, parsed parsedTitle) {
+		title = CleanFrontAsterisk(line)
+		commitType = parsed.Type
+		scope = parsed.Scope
+		breaking = parsed.Breaking
+	}
+
 	lines := strings.Split(out, "\n")
 	for i := 0; i < len(lines); i++ {
 		line := lines[i]
@@ -125,16 +129,15 @@ func (g GitWrapper) ChangeLog(notInBranch, inBranch string, svc issues.IssueServ
 				continue
 			}
 		case StateLookingForTitle:
-			titleMatch := RegexMatchFormattedTitle.FindString(line)
+			parsed, isTitle := rules.parseTitle(line)
 			commitIdMatch := RegexMatchCommitID.FindStringSubmatch(line)
 			if len(commitIdMatch) > 0 {
 				commitId = RegexGetCommitId.FindString(commitIdMatch[0])
 				bodyBuilder.Reset()
 				state = StateLookingForAuthor
 
-			} else if len(titleMatch) > 0 {
-				title = line
-				commitType = RegexGetCommitType.FindString(title)
+			} else if isTitle {
+				setTitle(line, parsed)
 				state = StateLookingForBody
 
 			} else if strings.HasPrefix(line, "Merge") {
@@ -143,66 +146,37 @@ func (g GitWrapper) ChangeLog(notInBranch, inBranch string, svc issues.IssueServ
 			} else {
 				// Line is not a standard commit
 				commitType = MalformedCommitFlag
+				scope = ""
+				breaking = false
 				title = fmt.Sprintf("%s: %s", MalformedCommitFlag, line)
 				state = StateLookingForBody
 				continue
 			}
 		case StateLookingForBody:
 			commitIdMatch := RegexMatchCommitID.FindStringSubmatch(line)
-			titleMatch := RegexMatchFormattedTitle.FindString(line)
+			parsed, isTitle := rules.parseTitle(line)
 			alternativeTitleMatch := RegexMatchAlternativeTitle.FindString(line)
-			issueMatch := RegexMatchIssue.MatchString(line)
+			issueNumber, isIssue := rules.parseIssue(line)
 			if len(commitIdMatch) > 0 {
-				change := GitChange{
-					CommitID:       commitId,
-					Committer:      committer,
-					CommitType:     commitType,
-					Title:          CleanFrontAsterisk(title),
-					Body:           bodyBuilder.String(),
-					Date:           date,
-					Valid:          true,
-					BreakingChange: CommitHasBreakingChange(bodyBuilder.String()),
-				}
-				allChanges = append(allChanges, change)
+				allChanges = append(allChanges, newChange())
 				commitId = RegexGetCommitId.FindString(commitIdMatch[0])
 				bodyBuilder.Reset()
 				state = StateLookingForAuthor
 
-			} else if issueMatch {
+			} else if isIssue {
 
-				issueNumber := RegexGetIssue.FindString(line)
 				issue := issues.NewIssueRef(org, repo, issueNumber)
-				issueLink := GetIssueLink(g.dir, issue.String())
 
-				change := GitChange{
-					CommitID:       commitId,
-					Committer:      committer,
-					CommitType:     commitType,
-					Title:          CleanFrontAsterisk(title),
-					Body:           bodyBuilder.String(),
-					Date:           date,
-					Valid:          true,
-					IssueLink:      issueLink,
-					Issue:          &issue,
-					BreakingChange: CommitHasBreakingChange(bodyBuilder.String()),
-				}
+				change := newChange()
+				change.IssueLink = GetIssueLink(g.dir, issue.String())
+				change.Issue = &issue
 				allChanges = append(allChanges, change)
 				bodyBuilder.Reset()
 				state = StateLookingForTitle
 
-			} else if len(titleMatch) > 0 {
-				change := GitChange{
-					CommitID:       commitId,
-					Committer:      committer,
-					CommitType:     commitType,
-					Title:          CleanFrontAsterisk(title),
-					Body:           bodyBuilder.String(),
-					Date:           date,
-					Valid:          true,
-					BreakingChange: CommitHasBreakingChange(bodyBuilder.String()),
-				}
-				allChanges = append(allChanges, change)
-				title = RegexMatchFormattedTitle.FindString(line)
+			} else if isTitle {
+				allChanges = append(allChanges, newChange())
+				setTitle(line, parsed)
 				bodyBuilder.Reset()
 				state = StateLookingForBody
 
@@ -214,6 +188,7 @@ func (g GitWrapper) ChangeLog(notInBranch, inBranch string, svc issues.IssueServ
 					Date:      date,
 					Valid:     false,
 				}
+				change.Bump = rules.getBump(change)
 				allChanges = append(allChanges, change)
 				state = StateLookingForTitle
 			} else {
@@ -233,9 +208,10 @@ func (g GitWrapper) ChangeLog(notInBranch, inBranch string, svc issues.IssueServ
 	return changeLog, err
 }
 
+// CommitHasBreakingChange returns true if the body has a breaking change marker from the default rules.
 func CommitHasBreakingChange(body string) bool {
-	breakingChangeFormat := regexp.MustCompile(`BREAKING CHANGE: .*`)
-	return breakingChangeFormat.MatchString(body)
+	rules, _ := DefaultCommitRules().compile()
+	return rules.isBreakingChange(body)
 }
 
 func (g GitChanges) GetVersionBump() semver.Bump {
@@ -254,13 +230,7 @@ func (g GitChanges) GetVersionBump() semver.Bump {
 func (g GitChanges) GetSeparatedChanges() map[semver.Bump]GitChanges {
 	storeChange := make(map[semver.Bump]GitChanges)
 	for _, change := range g {
-		var bump semver.Bump
-		var known bool
-		if change.BreakingChange {
-			bump = semver.BumpMajor
-		} else if bump, known = bumpMap[change.CommitType]; !known {
-			bump = semver.Unknown
-		}
+		bump := change.GetBump()
 		storeChange[bump] = append(storeChange[bump], change)
 	}
 	return storeChange
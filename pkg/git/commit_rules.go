@@ -0,0 +1,226 @@
+package git
+
+import (
+	"github.com/naveego/bosun/pkg/semver"
+	"github.com/pkg/errors"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CommitRules configure how conventional commits are parsed into changes and version bumps.
+// They can be set on a workspace and on the branching of an app or platform; the app rules
+// take precedence over the workspace rules, which take precedence over DefaultCommitRules.
+type CommitRules struct {
+	// Types maps commit types (like "feat") to the version bump they require.
+	// Set a type to "" to remove it from the defaults.
+	Types map[string]semver.Bump `yaml:"types,omitempty" json:"types,omitempty"`
+	// Scopes maps commit scopes (like "deps") to the version bump they require,
+	// overriding the bump for the type of the commit.
+	Scopes map[string]semver.Bump `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+	// RequireScope makes commits without a scope malformed.
+	RequireScope bool `yaml:"requireScope,omitempty" json:"requireScope,omitempty"`
+	// BreakingChangeMarkers are regexes which mark a commit as a breaking change when they
+	// match its body. A "!" after the type or scope (like "feat!:") always marks a breaking change.
+	BreakingChangeMarkers []string `yaml:"breakingChangeMarkers,omitempty" json:"breakingChangeMarkers,omitempty"`
+	// IssuePatterns are regexes which identify a line of the commit message as an issue reference.
+	// If a pattern has a group named "issue" it is used as the issue number, otherwise the
+	// digits at the end of the match are.
+	IssuePatterns []string `yaml:"issuePatterns,omitempty" json:"issuePatterns,omitempty"`
+	// MalformedBump is the version bump required by commits which are not conventional commits.
+	MalformedBump semver.Bump `yaml:"malformedBump,omitempty" json:"malformedBump,omitempty"`
+}
+
+// DefaultCommitRules returns the rules used when no rules are configured.
+func DefaultCommitRules() *CommitRules {
+	return &CommitRules{
+		Types: map[string]semver.Bump{
+			"feat":     semver.BumpMinor,
+			"fix":      semver.BumpPatch,
+			"refactor": semver.BumpPatch,
+			"perf":     semver.BumpPatch,
+			"revert":   semver.BumpPatch,
+			"deploy":   semver.BumpPatch,
+			"docs":     semver.BumpNone,
+			"style":    semver.BumpNone,
+			"test":     semver.BumpNone,
+			"chore":    semver.BumpNone,
+			"build":    semver.BumpNone,
+			"ci":       semver.BumpNone,
+		},
+		BreakingChangeMarkers: []string{`BREAKING[ -]CHANGE: .*`},
+		IssuePatterns:         []string{`\s+(resolves )?(@|#)?[0-9]+$|^[0-9]+$`},
+		MalformedBump:         semver.BumpPatch,
+	}
+}
+
+// WithDefaultsFrom returns a copy of r with any unset fields copied from d.
+// Types and scopes are merged, with the types and scopes in r taking precedence.
+func (r *CommitRules) WithDefaultsFrom(d *CommitRules) *CommitRules {
+	if r == nil && d == nil {
+		return nil
+	}
+	if r == nil {
+		r = &CommitRules{}
+	}
+	if d == nil {
+		d = &CommitRules{}
+	}
+	out := *r
+	out.Types = mergeBumps(d.Types, r.Types)
+	out.Scopes = mergeBumps(d.Scopes, r.Scopes)
+	if len(out.BreakingChangeMarkers) == 0 {
+		out.BreakingChangeMarkers = d.BreakingChangeMarkers
+	}
+	if len(out.IssuePatterns) == 0 {
+		out.IssuePatterns = d.IssuePatterns
+	}
+	if out.MalformedBump == "" {
+		out.MalformedBump = d.MalformedBump
+	}
+	out.RequireScope = r.RequireScope || d.RequireScope
+	return &out
+}
+
+func mergeBumps(defaults, overrides map[string]semver.Bump) map[string]semver.Bump {
+	if defaults == nil && overrides == nil {
+		return nil
+	}
+	out := map[string]semver.Bump{}
+	for k, v := range defaults {
+		out[k] = v
+	}
+	for k, v := range overrides {
+		if v == "" {
+			delete(out, k)
+		} else {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// compiledCommitRules are CommitRules with their regexes compiled.
+type compiledCommitRules struct {
+	rules          CommitRules
+	title          *regexp.Regexp
+	breakingChange []*regexp.Regexp
+	issues         []*regexp.Regexp
+}
+
+// parsedTitle is the parts of a conventional commit title.
+type parsedTitle struct {
+	Type     string
+	Scope    string
+	Breaking bool
+	Subject  string
+}
+
+// compile fills in any missing rules from the defaults and compiles the regexes.
+func (r *CommitRules) compile() (*compiledCommitRules, error) {
+	rules := *r.WithDefaultsFrom(DefaultCommitRules())
+	c := &compiledCommitRules{rules: rules}
+
+	if len(rules.Types) == 0 {
+		return nil, errors.New("commit rules must have at least one type")
+	}
+	var types []string
+	for t := range rules.Types {
+		types = append(types, regexp.QuoteMeta(t))
+	}
+	// longest first, so that a type which is a prefix of another type doesn't hide it
+	sort.Slice(types, func(i, j int) bool {
+		if len(types[i]) != len(types[j]) {
+			return len(types[i]) > len(types[j])
+		}
+		return types[i] < types[j]
+	})
+	c.title = regexp.MustCompile(`^(?:\* )?(` + strings.Join(types, "|") + `)(?:\(([^)]+)\))?(!)?:\s*(.*)$`)
+
+	for _, pattern := range rules.BreakingChangeMarkers {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid breaking change marker %q", pattern)
+		}
+		c.breakingChange = append(c.breakingChange, re)
+	}
+	for _, pattern := range rules.IssuePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid issue pattern %q", pattern)
+		}
+		c.issues = append(c.issues, re)
+	}
+	return c, nil
+}
+
+// parseTitle returns the parts of title, or false if it is not a conventional commit
+// with one of the configured types.
+func (c *compiledCommitRules) parseTitle(title string) (parsedTitle, bool) {
+	m := c.title.FindStringSubmatch(title)
+	if m == nil {
+		return parsedTitle{}, false
+	}
+	parsed := parsedTitle{Type: m[1], Scope: m[2], Breaking: m[3] == "!", Subject: m[4]}
+	if c.rules.RequireScope && parsed.Scope == "" {
+		return parsedTitle{}, false
+	}
+	return parsed, true
+}
+
+// isBreakingChange returns true if the body contains a breaking change marker.
+func (c *compiledCommitRules) isBreakingChange(body string) bool {
+	for _, re := range c.breakingChange {
+		if re.MatchString(body) {
+			return true
+		}
+	}
+	return false
+}
+
+var regexTrailingNumber = regexp.MustCompile(`[0-9]+$`)
+
+// parseIssue returns the issue number referenced by line, or false if the line is not an issue reference.
+func (c *compiledCommitRules) parseIssue(line string) (string, bool) {
+	for _, re := range c.issues {
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		for i, name := range re.SubexpNames() {
+			if name == "issue" && m[i] != "" {
+				return m[i], true
+			}
+		}
+		if number := regexTrailingNumber.FindString(m[0]); number != "" {
+			return number, true
+		}
+	}
+	return "", false
+}
+
+// getBump returns the version bump required by a change.
+func (c *compiledCommitRules) getBump(change GitChange) semver.Bump {
+	if change.BreakingChange {
+		return semver.BumpMajor
+	}
+	if change.CommitType == MalformedCommitFlag {
+		return c.rules.MalformedBump
+	}
+	if bump, ok := c.rules.Scopes[change.Scope]; ok && change.Scope != "" {
+		return bump
+	}
+	if bump, ok := c.rules.Types[change.CommitType]; ok {
+		return bump
+	}
+	return semver.Unknown
+}
+
+// GetBump returns the version bump required by a change according to the rules.
+func (r *CommitRules) GetBump(change GitChange) (semver.Bump, error) {
+	c, err := r.compile()
+	if err != nil {
+		return "", err
+	}
+	return c.getBump(change), nil
+}
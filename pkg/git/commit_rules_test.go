@@ -0,0 +1,239 @@
+package git
+
+import (
+	"github.com/naveego/bosun/pkg/semver"
+	"reflect"
+	"testing"
+)
+
+func mustCompile(t *testing.T, r *CommitRules) *compiledCommitRules {
+	c, err := r.compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCommitRulesCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   *CommitRules
+		wantErr bool
+	}{
+		{name: "empty rules use the defaults", rules: &CommitRules{}},
+		{name: "custom types", rules: &CommitRules{Types: map[string]semver.Bump{"story": semver.BumpMinor}}},
+		{name: "invalid breaking change marker", rules: &CommitRules{BreakingChangeMarkers: []string{"("}}, wantErr: true},
+		{name: "invalid issue pattern", rules: &CommitRules{IssuePatterns: []string{"["}}, wantErr: true},
+		{name: "every type removed", rules: func() *CommitRules {
+			r := &CommitRules{Types: map[string]semver.Bump{}}
+			for k := range DefaultCommitRules().Types {
+				r.Types[k] = ""
+			}
+			return r
+		}(), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.rules.compile()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommitRulesParseTitle(t *testing.T) {
+	defaults := &CommitRules{}
+	custom := &CommitRules{Types: map[string]semver.Bump{"feature": semver.BumpMinor, "chore": ""}}
+	requireScope := &CommitRules{RequireScope: true}
+
+	tests := []struct {
+		name   string
+		rules  *CommitRules
+		title  string
+		want   parsedTitle
+		wantOK bool
+	}{
+		{name: "feat", rules: defaults, title: "feat: add search", want: parsedTitle{Type: "feat", Subject: "add search"}, wantOK: true},
+		{name: "scope", rules: defaults, title: "fix(api): handle nil", want: parsedTitle{Type: "fix", Scope: "api", Subject: "handle nil"}, wantOK: true},
+		{name: "build", rules: defaults, title: "build: update go", want: parsedTitle{Type: "build", Subject: "update go"}, wantOK: true},
+		{name: "ci with scope", rules: defaults, title: "ci(deploy): cache modules", want: parsedTitle{Type: "ci", Scope: "deploy", Subject: "cache modules"}, wantOK: true},
+		{name: "breaking marker", rules: defaults, title: "feat!: drop v1 api", want: parsedTitle{Type: "feat", Breaking: true, Subject: "drop v1 api"}, wantOK: true},
+		{name: "breaking marker with scope", rules: defaults, title: "refactor(auth)!: rename claims", want: parsedTitle{Type: "refactor", Scope: "auth", Breaking: true, Subject: "rename claims"}, wantOK: true},
+		{name: "squashed list item", rules: defaults, title: "* docs: fix typo", want: parsedTitle{Type: "docs", Subject: "fix typo"}, wantOK: true},
+		{name: "unknown type", rules: defaults, title: "feature: add search"},
+		{name: "not conventional", rules: defaults, title: "Merge branch 'master'"},
+		{name: "type is case sensitive", rules: defaults, title: "Feat: add search"},
+		{name: "custom type", rules: custom, title: "feature: add search", want: parsedTitle{Type: "feature", Subject: "add search"}, wantOK: true},
+		{name: "custom rules keep default types", rules: custom, title: "feat: add search", want: parsedTitle{Type: "feat", Subject: "add search"}, wantOK: true},
+		{name: "removed type", rules: custom, title: "chore: tidy"},
+		{name: "scope required but missing", rules: requireScope, title: "feat: add search"},
+		{name: "scope required and present", rules: requireScope, title: "feat(ui): add search", want: parsedTitle{Type: "feat", Scope: "ui", Subject: "add search"}, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := mustCompile(t, tt.rules).parseTitle(tt.title)
+			if ok != tt.wantOK {
+				t.Fatalf("parseTitle(%q) ok = %v, want %v", tt.title, ok, tt.wantOK)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTitle(%q) = %+v, want %+v", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitRulesIsBreakingChange(t *testing.T) {
+	defaults := mustCompile(t, &CommitRules{})
+	custom := mustCompile(t, &CommitRules{BreakingChangeMarkers: []string{`(?m)^BREAKS: `}})
+
+	tests := []struct {
+		name  string
+		rules *compiledCommitRules
+		body  string
+		want  bool
+	}{
+		{name: "default marker", rules: defaults, body: "some detail\nBREAKING CHANGE: removed the v1 api", want: true},
+		{name: "default marker with dash", rules: defaults, body: "BREAKING-CHANGE: removed the v1 api", want: true},
+		{name: "no marker", rules: defaults, body: "this is not breaking"},
+		{name: "custom marker", rules: custom, body: "detail\nBREAKS: clients", want: true},
+		{name: "custom marker replaces default", rules: custom, body: "BREAKING CHANGE: removed the v1 api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rules.isBreakingChange(tt.body); got != tt.want {
+				t.Errorf("isBreakingChange(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitRulesParseIssue(t *testing.T) {
+	defaults := &CommitRules{}
+	jira := &CommitRules{IssuePatterns: []string{`^Refs: [A-Z]+-(?P<issue>[0-9]+)`, `^Closes #[0-9]+$`}}
+
+	tests := []struct {
+		name   string
+		rules  *CommitRules
+		line   string
+		want   string
+		wantOK bool
+	}{
+		{name: "hash", rules: defaults, line: "fix the bug #123", want: "123", wantOK: true},
+		{name: "resolves", rules: defaults, line: "fix the bug resolves #45", want: "45", wantOK: true},
+		{name: "at", rules: defaults, line: "fix the bug @67", want: "67", wantOK: true},
+		{name: "bare number", rules: defaults, line: "89", want: "89", wantOK: true},
+		{name: "number inside text", rules: defaults, line: "upgrade to v2 of the api"},
+		{name: "no number", rules: defaults, line: "fix the bug"},
+		{name: "named group", rules: jira, line: "Refs: PROJ-321 and more", want: "321", wantOK: true},
+		{name: "trailing digits of a custom pattern", rules: jira, line: "Closes #654", want: "654", wantOK: true},
+		{name: "custom patterns replace the default", rules: jira, line: "fix the bug #123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := mustCompile(t, tt.rules).parseIssue(tt.line)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseIssue(%q) = %q, %v, want %q, %v", tt.line, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCommitRulesGetBump(t *testing.T) {
+	defaults := &CommitRules{}
+	custom := &CommitRules{
+		Types:         map[string]semver.Bump{"docs": semver.BumpPatch, "story": semver.BumpMinor},
+		Scopes:        map[string]semver.Bump{"deps": semver.BumpNone},
+		MalformedBump: semver.BumpNone,
+	}
+
+	tests := []struct {
+		name   string
+		rules  *CommitRules
+		change GitChange
+		want   semver.Bump
+	}{
+		{name: "feat", rules: defaults, change: GitChange{CommitType: "feat"}, want: semver.BumpMinor},
+		{name: "fix", rules: defaults, change: GitChange{CommitType: "fix"}, want: semver.BumpPatch},
+		{name: "build", rules: defaults, change: GitChange{CommitType: "build"}, want: semver.BumpNone},
+		{name: "ci", rules: defaults, change: GitChange{CommitType: "ci"}, want: semver.BumpNone},
+		{name: "breaking", rules: defaults, change: GitChange{CommitType: "feat", BreakingChange: true}, want: semver.BumpMajor},
+		{name: "breaking chore", rules: defaults, change: GitChange{CommitType: "chore", BreakingChange: true}, want: semver.BumpMajor},
+		{name: "malformed", rules: defaults, change: GitChange{CommitType: MalformedCommitFlag}, want: semver.BumpPatch},
+		{name: "unknown type", rules: defaults, change: GitChange{CommitType: "story"}, want: semver.Unknown},
+		{name: "custom type", rules: custom, change: GitChange{CommitType: "story"}, want: semver.BumpMinor},
+		{name: "overridden type", rules: custom, change: GitChange{CommitType: "docs"}, want: semver.BumpPatch},
+		{name: "scope overrides type", rules: custom, change: GitChange{CommitType: "feat", Scope: "deps"}, want: semver.BumpNone},
+		{name: "unconfigured scope", rules: custom, change: GitChange{CommitType: "feat", Scope: "ui"}, want: semver.BumpMinor},
+		{name: "breaking overrides scope", rules: custom, change: GitChange{CommitType: "feat", Scope: "deps", BreakingChange: true}, want: semver.BumpMajor},
+		{name: "custom malformed bump", rules: custom, change: GitChange{CommitType: MalformedCommitFlag}, want: semver.BumpNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.rules.GetBump(tt.change)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("GetBump(%+v) = %q, want %q", tt.change, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitRulesWithDefaultsFrom(t *testing.T) {
+	workspace := &CommitRules{
+		Types:                 map[string]semver.Bump{"feat": semver.BumpMinor, "fix": semver.BumpPatch, "chore": semver.BumpNone},
+		Scopes:                map[string]semver.Bump{"deps": semver.BumpNone},
+		BreakingChangeMarkers: []string{"BREAKING"},
+		IssuePatterns:         []string{"#[0-9]+"},
+		MalformedBump:         semver.BumpPatch,
+		RequireScope:          true,
+	}
+
+	tests := []struct {
+		name     string
+		rules    *CommitRules
+		defaults *CommitRules
+		want     *CommitRules
+	}{
+		{name: "both nil", want: nil},
+		{name: "nil rules", defaults: workspace, want: workspace},
+		{
+			name:     "nil defaults",
+			rules:    &CommitRules{Types: map[string]semver.Bump{"feat": semver.BumpMajor}},
+			defaults: nil,
+			want:     &CommitRules{Types: map[string]semver.Bump{"feat": semver.BumpMajor}},
+		},
+		{
+			name: "overrides are merged",
+			rules: &CommitRules{
+				Types:         map[string]semver.Bump{"feat": semver.BumpMajor, "chore": "", "story": semver.BumpMinor},
+				Scopes:        map[string]semver.Bump{"ui": semver.BumpPatch},
+				IssuePatterns: []string{"JIRA-[0-9]+"},
+				MalformedBump: semver.BumpNone,
+			},
+			defaults: workspace,
+			want: &CommitRules{
+				Types:                 map[string]semver.Bump{"feat": semver.BumpMajor, "fix": semver.BumpPatch, "story": semver.BumpMinor},
+				Scopes:                map[string]semver.Bump{"deps": semver.BumpNone, "ui": semver.BumpPatch},
+				BreakingChangeMarkers: []string{"BREAKING"},
+				IssuePatterns:         []string{"JIRA-[0-9]+"},
+				MalformedBump:         semver.BumpNone,
+				RequireScope:          true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rules.WithDefaultsFrom(tt.defaults)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WithDefaultsFrom() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if workspace.Types["chore"] != semver.BumpNone {
+		t.Error("expected WithDefaultsFrom not to modify the defaults")
+	}
+}
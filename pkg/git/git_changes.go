@@ -14,9 +14,22 @@ type GitChange struct {
 	Issue          *issues.IssueRef
 	CommitID       string
 	CommitType     string
+	Scope          string
 	Committer      string
 	IssueLink      string
 	BreakingChange bool
+	// Bump is the version bump required by the change, according to the rules used to parse it.
+	Bump semver.Bump
+}
+
+// GetBump returns the version bump required by the change. If the change was not
+// created by parsing a change log, the bump is found using the default rules.
+func (g GitChange) GetBump() semver.Bump {
+	if g.Bump != "" {
+		return g.Bump
+	}
+	bump, _ := DefaultCommitRules().GetBump(g)
+	return bump
 }
 
 type GitChangeStory struct {
@@ -37,35 +50,32 @@ func (g GitChanges) FilterByBump(bumps ...semver.Bump) GitChanges {
 		whitelist[b] = true
 	}
 	for _, c := range g {
-		bump, ok := bumpMap[c.CommitType]
-		if !ok {
-			bump = "unknown"
-		}
-		if whitelist[bump] {
+		if whitelist[c.GetBump()] {
 			out = append(out, c)
 		}
 	}
 	return out
 }
 
+// MapToStories groups the changes by the story (parent issue) of the issue each change is linked to.
+// Changes which are not linked to an issue, or whose issue has no parent, are not included.
 func (g GitChanges) MapToStories(svc issues.IssueService) ([]*GitChangeStory, error) {
 
 	childToParentMap := map[issues.IssueRef]issues.IssueRef{}
 
 	refToChangeStory := map[issues.IssueRef]*GitChangeStory{}
 
+	var stories []*GitChangeStory
+
 	for _, change := range g {
-		var changeStory *GitChangeStory
 		if change.Issue == nil {
 			continue
 		}
 		parentRef, ok := childToParentMap[*change.Issue]
 		if ok {
-			changeStory, ok = refToChangeStory[parentRef]
-			if ok {
-				changeStory.Changes = append(changeStory.Changes, change)
-				continue
-			}
+			changeStory := refToChangeStory[parentRef]
+			changeStory.Changes = append(changeStory.Changes, change)
+			continue
 		}
 
 		parentRefs, err := svc.GetParentRefs(*change.Issue)
@@ -84,21 +94,29 @@ func (g GitChanges) MapToStories(svc issues.IssueService) ([]*GitChangeStory, er
 			continue
 		}
 
-		parent := parents[1]
+		parent := parents[0]
 
 		parentRef = parent.Ref()
 
 		childToParentMap[*change.Issue] = parentRef
 
-		changeStory = &GitChangeStory{
+		if changeStory, found := refToChangeStory[parentRef]; found {
+			changeStory.Changes = append(changeStory.Changes, change)
+			continue
+		}
+
+		changeStory := &GitChangeStory{
 			Changes:    GitChanges{change},
 			StoryRef:   parentRef,
 			Link:       fmt.Sprintf("https://github.com/%s/%s/issues/%s", parent.Org, parent.Repo, parent.ID),
 			StoryTitle: parent.Title,
 			StoryBody:  parent.Body,
 		}
+		changeStory.StoryLink = changeStory.Link
 
+		refToChangeStory[parentRef] = changeStory
+		stories = append(stories, changeStory)
 	}
 
-	return nil, nil
+	return stories, nil
 }
@@ -0,0 +1,116 @@
+package git
+
+import (
+	"github.com/naveego/bosun/pkg/issues"
+	"github.com/pkg/errors"
+	"reflect"
+	"testing"
+)
+
+// fakeIssueService implements the parts of issues.IssueService used to find stories.
+type fakeIssueService struct {
+	issues.IssueService
+	parents map[issues.IssueRef][]issues.IssueRef
+	issues  map[issues.IssueRef]issues.Issue
+	// parentRequests counts the calls to GetParentRefs for each issue.
+	parentRequests map[issues.IssueRef]int
+}
+
+func (f *fakeIssueService) GetParentRefs(ref issues.IssueRef) ([]issues.IssueRef, error) {
+	f.parentRequests[ref]++
+	parents, ok := f.parents[ref]
+	if !ok {
+		return nil, errors.Errorf("issue %s not found", ref)
+	}
+	return parents, nil
+}
+
+func (f *fakeIssueService) GetIssue(ref issues.IssueRef) (issues.Issue, error) {
+	issue, ok := f.issues[ref]
+	if !ok {
+		return issues.Issue{}, errors.Errorf("issue %s not found", ref)
+	}
+	return issue, nil
+}
+
+func TestGitChangesMapToStories(t *testing.T) {
+	ref := func(id string) *issues.IssueRef {
+		r := issues.NewIssueRef("naveego", "api", id)
+		return &r
+	}
+	story := issues.Issue{Org: "naveego", Repo: "stories", ID: "100", Title: "Checkout", Body: "Let users check out."}
+	otherStory := issues.Issue{Org: "naveego", Repo: "stories", ID: "200", Title: "Search"}
+
+	svc := &fakeIssueService{
+		parents: map[issues.IssueRef][]issues.IssueRef{
+			*ref("1"): {story.Ref()},
+			*ref("2"): {story.Ref()},
+			*ref("3"): {otherStory.Ref()},
+			*ref("4"): {},
+			*ref("5"): {issues.NewIssueRef("naveego", "stories", "999")},
+		},
+		issues: map[issues.IssueRef]issues.Issue{
+			story.Ref():      story,
+			otherStory.Ref(): otherStory,
+		},
+		parentRequests: map[issues.IssueRef]int{},
+	}
+
+	changes := GitChanges{
+		{Title: "add cart", Issue: ref("1")},
+		{Title: "no issue"},
+		{Title: "add payment", Issue: ref("2")},
+		{Title: "add search", Issue: ref("3")},
+		{Title: "fix cart", Issue: ref("1")},
+		{Title: "no parent", Issue: ref("4")},
+		{Title: "missing parent", Issue: ref("5")},
+		{Title: "unknown issue", Issue: ref("6")},
+	}
+
+	stories, err := changes.MapToStories(svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type summary struct {
+		Ref     string
+		Title   string
+		Body    string
+		Link    string
+		Changes []string
+	}
+	var actual []summary
+	for _, s := range stories {
+		var titles []string
+		for _, c := range s.Changes {
+			titles = append(titles, c.Title)
+		}
+		if s.StoryLink != s.Link {
+			t.Errorf("expected story link %q to equal link %q", s.StoryLink, s.Link)
+		}
+		actual = append(actual, summary{s.StoryRef.String(), s.StoryTitle, s.StoryBody, s.Link, titles})
+	}
+
+	expected := []summary{
+		{"naveego/stories#100", "Checkout", "Let users check out.", "https://github.com/naveego/stories/issues/100", []string{"add cart", "add payment", "fix cart"}},
+		{"naveego/stories#200", "Search", "", "https://github.com/naveego/stories/issues/200", []string{"add search"}},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("MapToStories() =\n%+v\nwant\n%+v", actual, expected)
+	}
+
+	if n := svc.parentRequests[*ref("1")]; n != 1 {
+		t.Errorf("expected the parents of an issue to be requested once, but they were requested %d times", n)
+	}
+}
+
+func TestGitChangesMapToStoriesWithNoLinkedChanges(t *testing.T) {
+	svc := &fakeIssueService{parentRequests: map[issues.IssueRef]int{}}
+	stories, err := GitChanges{{Title: "no issue"}}.MapToStories(svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stories) != 0 {
+		t.Errorf("expected no stories, got %v", stories)
+	}
+}
@@ -20,6 +20,13 @@ func (c ChartHandle) String() string {
 func (c ChartHandle) HasRepo() bool {
 	return strings.Contains(string(c), "/")
 }
+
+// GetChartName returns the name of the chart without the repo.
+func (c ChartHandle) GetChartName() string {
+	segs := strings.Split(string(c), "/")
+	return segs[len(segs)-1]
+}
+
 func (c ChartHandle) WithRepo(repo string) ChartHandle {
 	segs := strings.Split(string(c), "/")
 	switch len(segs) {
@@ -0,0 +1,120 @@
+package kube
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// LocalPortForward is a kubectl port-forward from a local port to a port on a
+// kubernetes resource. Forwards to the same port on the same resource are shared
+// until they have all been stopped.
+type LocalPortForward struct {
+	Host string
+	Port string
+
+	key     string
+	handles int
+	cmd     *exec.Cmd
+}
+
+var (
+	localPortForwardLock sync.Mutex
+	localPortForwardMap  = map[string]*LocalPortForward{}
+)
+
+// StartLocalPortForward forwards a local port to the port on the resource (such as svc/mongodb)
+// and waits until the local port accepts connections. Stop must be called when the port
+// forward is no longer needed.
+func StartLocalPortForward(log *logrus.Entry, namespace string, resource string, port int) (*LocalPortForward, error) {
+	if resource == "" {
+		return nil, errors.New("a resource to forward to is required")
+	}
+
+	key := fmt.Sprintf("%s|%s|%d", namespace, resource, port)
+
+	localPortForwardLock.Lock()
+	defer localPortForwardLock.Unlock()
+
+	if p, ok := localPortForwardMap[key]; ok {
+		p.handles++
+		return p, nil
+	}
+
+	log.Infof("Creating new kubectl port-forward to %s on port %d", resource, port)
+
+	p := &LocalPortForward{key: key, handles: 1}
+	p.cmd = exec.Command("kubectl",
+		"port-forward",
+		"--namespace", namespace,
+		resource,
+		fmt.Sprintf("0:%d", port))
+	p.cmd.Stderr = os.Stderr
+	portFwdOut, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "get kubectl port-forward output")
+	}
+	reader := bufio.NewReader(portFwdOut)
+
+	if err = p.cmd.Start(); err != nil {
+		return nil, errors.Wrapf(err, "error starting kubernetes port forwarding to %s on port %d", resource, port)
+	}
+
+	line, _, err := reader.ReadLine()
+	if err == io.EOF {
+		return nil, errors.Wrap(p.cmd.Wait(), "kubectl port-forward failed")
+	}
+	if err != nil {
+		p.kill()
+		return nil, errors.Wrap(err, "read kubectl port-forward output")
+	}
+	matches := regexp.MustCompile(`Forwarding from ([^:]+):(\d+)`).FindStringSubmatch(string(line))
+	if len(matches) < 3 {
+		p.kill()
+		return nil, errors.Errorf("port forward failed; kubectl said %q", line)
+	}
+	p.Host, p.Port = matches[1], matches[2]
+
+	for attempt := 0; ; attempt++ {
+		log.Debugf("checking for success of kubectl port-forward to %s at %s:%s", resource, p.Host, p.Port)
+		conn, dialErr := net.DialTimeout("tcp", net.JoinHostPort(p.Host, p.Port), time.Second)
+		if dialErr == nil {
+			conn.Close()
+			log.Infof("kubectl port-forward to %s is ready at %s:%s", resource, p.Host, p.Port)
+			break
+		}
+		if attempt == 30 {
+			p.kill()
+			return nil, errors.Wrapf(dialErr, "port forward to %s was not ready", resource)
+		}
+		time.Sleep(time.Second)
+	}
+
+	localPortForwardMap[key] = p
+	return p, nil
+}
+
+// Stop stops the port forward once every user of it has stopped it.
+func (p *LocalPortForward) Stop() {
+	localPortForwardLock.Lock()
+	defer localPortForwardLock.Unlock()
+	p.handles--
+	if p.handles == 0 {
+		p.kill()
+		delete(localPortForwardMap, p.key)
+	}
+}
+
+func (p *LocalPortForward) kill() {
+	if err := p.cmd.Process.Signal(os.Kill); err != nil {
+		logrus.WithError(err).Error("kill of port forward kubectl failed")
+	}
+}
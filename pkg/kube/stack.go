@@ -165,6 +165,22 @@ func (c *Stack) UpdateApp(updates ...StackApp) error {
 	return c.Save()
 }
 
+// RemoveApp removes apps from the stack state.
+func (c *Stack) RemoveApp(names ...string) error {
+
+	state, err := c.GetState(false)
+
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		delete(state.DeployedApps, name)
+	}
+
+	return c.Save()
+}
+
 func (c *Stack) Save() error {
 
 	c.state.Uninitialized = false
@@ -437,7 +453,7 @@ func (k *Stack) GetNamespace(role core.NamespaceRole) (NamespaceConfig, error) {
 	if ns, ok := k.StackTemplate.Namespaces[role]; ok {
 		return ns, nil
 	}
-	return NamespaceConfig{}, errors.Errorf("kubernetes cluster kubeconfig %q does not have a namespace for the role %q", k.StackTemplate.Namespaces, role)
+	return NamespaceConfig{}, errors.Errorf("kubernetes cluster kubeconfig %v does not have a namespace for the role %q", k.StackTemplate.Namespaces, role)
 }
 
 // GetAppValueSetCollectionProvider returns a ValuesSetCollectionProvider that will provide any values set collection
@@ -540,6 +556,12 @@ func (c *Stack) Destroy() error {
 		errs.Collect(err)
 	}
 
+	err = c.Cluster.Client.CoreV1().ConfigMaps(c.Cluster.GetDefaultNamespace()).Delete(makeStackHistoryConfigmapName(c.Name), &metav1.DeleteOptions{})
+
+	if err != nil && !kerrors.IsNotFound(err) {
+		errs.Collect(err)
+	}
+
 	err = errs.ToError()
 	if err != nil {
 		return err
@@ -18,6 +18,8 @@ type StackTemplate struct {
 	Apps              map[string]values.ValueSetCollection `yaml:"apps"`
 	Certs             []ClusterCert                        `yaml:"certs"`
 	ValueOverrides    *values.ValueSetCollection           `yaml:"valueOverrides,omitempty"`
+	// The number of deployment history entries to retain for each stack, defaults to DefaultStackHistoryLimit.
+	HistoryLimit int `yaml:"historyLimit,omitempty"`
 }
 
 type StackState struct {
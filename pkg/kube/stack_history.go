@@ -0,0 +1,198 @@
+package kube
+
+import (
+	"fmt"
+	"github.com/naveego/bosun/pkg/core"
+	"github.com/naveego/bosun/pkg/yaml"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"time"
+)
+
+const (
+	stackHistoryConfigMapKey = "history"
+	StackHistoryLabel        = "bosun.aunalytics.com/stack-history"
+	// DefaultStackHistoryLimit is the number of history entries retained for a stack
+	// if the stack template doesn't specify a limit.
+	DefaultStackHistoryLimit = 250
+)
+
+const (
+	StackHistoryOutcomeDeployed   = "Deployed"
+	StackHistoryOutcomeFailed     = "Failed"
+	StackHistoryOutcomeRolledBack = "RolledBack"
+	// StackHistoryOutcomeUninstalled is recorded when an app installed by a failed deploy is removed.
+	StackHistoryOutcomeUninstalled = "Uninstalled"
+)
+
+// StackHistoryEntry records a single change to an app deployed to a stack.
+type StackHistoryEntry struct {
+	Sequence  int       `yaml:"sequence" json:"sequence"`
+	App       string    `yaml:"app" json:"app"`
+	Timestamp time.Time `yaml:"timestamp" json:"timestamp"`
+	User      string    `yaml:"user" json:"user"`
+	Outcome   string    `yaml:"outcome" json:"outcome"`
+	Error     string    `yaml:"error,omitempty" json:"error,omitempty"`
+	// Hash of the release the deployment plan the app was deployed from was based on, if any.
+	ReleaseHash string `yaml:"releaseHash,omitempty" json:"releaseHash,omitempty"`
+	// The app as it was recorded in the stack before this change, if it was deployed.
+	Previous *StackApp `yaml:"previous,omitempty" json:"previous,omitempty"`
+	Current  StackApp  `yaml:"current" json:"current"`
+}
+
+type StackHistory []StackHistoryEntry
+
+func (s StackHistory) Headers() []string {
+	return []string{
+		"Sequence",
+		"App",
+		"Timestamp",
+		"User",
+		"From",
+		"To",
+		"Commit",
+		"Outcome",
+	}
+}
+
+func (s StackHistory) Rows() [][]string {
+	var out [][]string
+	for _, entry := range s {
+		from := ""
+		if entry.Previous != nil {
+			from = entry.Previous.Version
+		}
+		out = append(out, []string{
+			fmt.Sprint(entry.Sequence),
+			entry.App,
+			entry.Timestamp.Format(time.RFC3339),
+			entry.User,
+			from,
+			entry.Current.Version,
+			entry.Current.Commit,
+			entry.Outcome,
+		})
+	}
+	return out
+}
+
+// ForApp returns the entries for the named app.
+func (s StackHistory) ForApp(name string) StackHistory {
+	var out StackHistory
+	for _, entry := range s {
+		if entry.App == name {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+// Get returns the entry with the provided sequence number.
+func (s StackHistory) Get(sequence int) (StackHistoryEntry, bool) {
+	for _, entry := range s {
+		if entry.Sequence == sequence {
+			return entry, true
+		}
+	}
+	return StackHistoryEntry{}, false
+}
+
+func makeStackHistoryConfigmapName(name string) string {
+	return makeStackConfigmapName(name) + "-history"
+}
+
+// GetHistory returns the deployment history of the stack, oldest first.
+func (c *Stack) GetHistory() (StackHistory, error) {
+	return getStackHistory(c.Cluster.Client, c.Cluster.GetDefaultNamespace(), c.Name)
+}
+
+func getStackHistory(client kubernetes.Interface, namespace string, stackName string) (StackHistory, error) {
+	configmap, err := client.CoreV1().ConfigMaps(namespace).Get(makeStackHistoryConfigmapName(stackName), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var history StackHistory
+	err = yaml.UnmarshalString(configmap.Data[stackHistoryConfigMapKey], &history)
+	return history, errors.Wrapf(err, "parse history for stack %q", stackName)
+}
+
+// RecordHistory appends entries to the deployment history of the stack. Sequence numbers
+// are assigned to the entries, and the oldest entries are removed once the
+// history is longer than the limit set in the stack template.
+func (c *Stack) RecordHistory(entries ...StackHistoryEntry) error {
+	return recordStackHistory(c.Cluster.Client, c.Cluster.GetDefaultNamespace(), c.Cluster.Name, c.Name, c.StackTemplate.HistoryLimit, entries...)
+}
+
+func recordStackHistory(client kubernetes.Interface, namespace string, clusterName string, stackName string, limit int, entries ...StackHistoryEntry) error {
+	configmapName := makeStackHistoryConfigmapName(stackName)
+
+	if limit <= 0 {
+		limit = DefaultStackHistoryLimit
+	}
+
+	for {
+		configmap, err := client.CoreV1().ConfigMaps(namespace).Get(configmapName, metav1.GetOptions{})
+		exists := true
+		if kerrors.IsNotFound(err) {
+			exists = false
+			configmap = &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: configmapName,
+					Labels: map[string]string{
+						StackHistoryLabel: clusterName,
+					},
+				},
+				Data: map[string]string{},
+			}
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		var history StackHistory
+		if err = yaml.UnmarshalString(configmap.Data[stackHistoryConfigMapKey], &history); err != nil {
+			return errors.Wrapf(err, "parse history for stack %q", stackName)
+		}
+
+		history = appendStackHistory(history, limit, entries...)
+
+		configmap.Data[stackHistoryConfigMapKey], _ = yaml.MarshalString(history)
+
+		if exists {
+			_, err = client.CoreV1().ConfigMaps(namespace).Update(configmap)
+		} else {
+			_, err = client.CoreV1().ConfigMaps(namespace).Create(configmap)
+		}
+
+		if kerrors.IsConflict(err) || kerrors.IsAlreadyExists(err) {
+			core.Log.Warn("Conflict while updating stack history, will try again.")
+			<-time.After(1 * time.Second)
+		} else {
+			return errors.WithStack(err)
+		}
+	}
+}
+
+func appendStackHistory(history StackHistory, limit int, entries ...StackHistoryEntry) StackHistory {
+	next := 1
+	if len(history) > 0 {
+		next = history[len(history)-1].Sequence + 1
+	}
+
+	for _, entry := range entries {
+		entry.Sequence = next
+		next++
+		history = append(history, entry)
+	}
+
+	if len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+
+	return history
+}
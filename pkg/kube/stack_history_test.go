@@ -0,0 +1,184 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newFakeConfigMapClient returns a clientset backed by a fake API server which
+// stores config maps in memory, and a function which stops the server.
+func newFakeConfigMapClient(t *testing.T) (kubernetes.Interface, func()) {
+	t.Helper()
+	var mu sync.Mutex
+	configmaps := map[string]*v1.ConfigMap{}
+
+	writeJSON := func(w http.ResponseWriter, status int, value interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(value)
+	}
+	notFound := func(w http.ResponseWriter, name string) {
+		writeJSON(w, http.StatusNotFound, metav1.Status{
+			TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+			Status:   metav1.StatusFailure,
+			Reason:   metav1.StatusReasonNotFound,
+			Message:  fmt.Sprintf("configmaps %q not found", name),
+			Code:     http.StatusNotFound,
+		})
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// paths look like /api/v1/namespaces/{namespace}/configmaps[/{name}]
+		segs := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(segs) < 5 || segs[4] != "configmaps" {
+			http.NotFound(w, r)
+			return
+		}
+		namespace := segs[3]
+
+		switch r.Method {
+		case http.MethodGet:
+			key := namespace + "/" + segs[5]
+			configmap, ok := configmaps[key]
+			if !ok {
+				notFound(w, segs[5])
+				return
+			}
+			writeJSON(w, http.StatusOK, configmap)
+		case http.MethodPost, http.MethodPut:
+			var configmap v1.ConfigMap
+			if err := json.NewDecoder(r.Body).Decode(&configmap); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			configmap.Namespace = namespace
+			configmaps[namespace+"/"+configmap.Name] = &configmap
+			writeJSON(w, http.StatusOK, configmap)
+		default:
+			http.Error(w, "unsupported", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: srv.URL, QPS: 1000, Burst: 1000})
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+	return client, srv.Close
+}
+
+func TestRecordStackHistoryAppendsToConfigMap(t *testing.T) {
+	client, stop := newFakeConfigMapClient(t)
+	defer stop()
+
+	err := recordStackHistory(client, "default", "test-cluster", "test-stack", 0,
+		StackHistoryEntry{App: "a", Outcome: StackHistoryOutcomeDeployed},
+		StackHistoryEntry{App: "b", Outcome: StackHistoryOutcomeFailed})
+	if err != nil {
+		t.Fatalf("record history: %v", err)
+	}
+	err = recordStackHistory(client, "default", "test-cluster", "test-stack", 0,
+		StackHistoryEntry{App: "a", Outcome: StackHistoryOutcomeRolledBack})
+	if err != nil {
+		t.Fatalf("record history again: %v", err)
+	}
+
+	configmap, err := client.CoreV1().ConfigMaps("default").Get(makeStackHistoryConfigmapName("test-stack"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get configmap: %v", err)
+	}
+	if configmap.Labels[StackHistoryLabel] != "test-cluster" {
+		t.Errorf("expected history label to be the cluster name, got %q", configmap.Labels[StackHistoryLabel])
+	}
+
+	history, err := getStackHistory(client, "default", "test-stack")
+	if err != nil {
+		t.Fatalf("get history: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(history))
+	}
+	for i, expected := range []StackHistoryEntry{
+		{Sequence: 1, App: "a", Outcome: StackHistoryOutcomeDeployed},
+		{Sequence: 2, App: "b", Outcome: StackHistoryOutcomeFailed},
+		{Sequence: 3, App: "a", Outcome: StackHistoryOutcomeRolledBack},
+	} {
+		actual := history[i]
+		if actual.Sequence != expected.Sequence || actual.App != expected.App || actual.Outcome != expected.Outcome {
+			t.Errorf("entry %d: expected %+v, got %+v", i, expected, actual)
+		}
+	}
+	if len(history.ForApp("a")) != 2 {
+		t.Errorf("expected 2 entries for app a, got %d", len(history.ForApp("a")))
+	}
+}
+
+func TestRecordStackHistoryTrimsToDefaultLimit(t *testing.T) {
+	client, stop := newFakeConfigMapClient(t)
+	defer stop()
+
+	for i := 0; i < DefaultStackHistoryLimit+10; i++ {
+		err := recordStackHistory(client, "default", "test-cluster", "test-stack", 0, StackHistoryEntry{App: "a"})
+		if err != nil {
+			t.Fatalf("record history %d: %v", i, err)
+		}
+	}
+
+	history, err := getStackHistory(client, "default", "test-stack")
+	if err != nil {
+		t.Fatalf("get history: %v", err)
+	}
+	if len(history) != DefaultStackHistoryLimit {
+		t.Fatalf("expected %d entries, got %d", DefaultStackHistoryLimit, len(history))
+	}
+	if history[0].Sequence != 11 {
+		t.Errorf("expected the oldest 10 entries to be trimmed, first sequence is %d", history[0].Sequence)
+	}
+	if last := history[len(history)-1].Sequence; last != DefaultStackHistoryLimit+10 {
+		t.Errorf("expected last sequence %d, got %d", DefaultStackHistoryLimit+10, last)
+	}
+}
+
+func TestRecordStackHistoryUsesTemplateLimit(t *testing.T) {
+	client, stop := newFakeConfigMapClient(t)
+	defer stop()
+
+	for i := 0; i < 5; i++ {
+		if err := recordStackHistory(client, "default", "test-cluster", "test-stack", 3, StackHistoryEntry{App: "a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := getStackHistory(client, "default", "test-stack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 || history[0].Sequence != 3 {
+		t.Fatalf("expected entries 3-5, got %+v", history)
+	}
+}
+
+func TestGetStackHistoryWithoutConfigMap(t *testing.T) {
+	client, stop := newFakeConfigMapClient(t)
+	defer stop()
+
+	history, err := getStackHistory(client, "default", "missing")
+	if err != nil {
+		t.Fatalf("expected no error for a stack with no history, got %v", err)
+	}
+	if len(history) != 0 {
+		t.Fatalf("expected no entries, got %d", len(history))
+	}
+}
@@ -1,9 +1,9 @@
 package mongo
 
 import (
-	"bufio"
 	"context"
 	"fmt"
+	"github.com/naveego/bosun/pkg/kube"
 	"github.com/naveego/bosun/pkg/vault"
 	"github.com/naveego/bosun/pkg/yaml"
 	"github.com/pkg/errors"
@@ -11,11 +11,6 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
-	"io"
-	"net"
-	"os"
-	"os/exec"
-	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -143,7 +138,7 @@ var preparedConnectionLock = sync.Mutex{}
 type preparedConnectionEntry struct {
 	PreparedConnection PreparedConnection
 	handles            int
-	cmd                *exec.Cmd
+	portForward        *kube.LocalPortForward
 }
 
 func GetPreparedConnection(log *logrus.Entry, c Connection) (PreparedConnection, error) {
@@ -181,75 +176,22 @@ func GetPreparedConnection(log *logrus.Entry, c Connection) (PreparedConnection,
 	}
 
 	if c.KubePort.Forward {
-		log.Info("Creating new kubectl port-forward for connection to MongoDB")
-
-		kubePort := c.KubePort.Port
-		kubeService := c.KubePort.ServiceName
-		entry.cmd = exec.Command("kubectl",
-			"port-forward",
-			"--namespace", c.KubePort.Namespace,
-			kubeService,
-			fmt.Sprintf("0:%d", kubePort))
-
-		entry.cmd.Stderr = os.Stderr
-		portFwdOut, _ := entry.cmd.StdoutPipe()
-
-		reader := bufio.NewReader(portFwdOut)
-		log.Debugf("port-forwarding mongo with service name '%s' and port '%d'", kubeService, kubePort)
-		// Start it up
-		err := entry.cmd.Start()
+		portForward, err := kube.StartLocalPortForward(log, c.KubePort.Namespace, c.KubePort.ServiceName, c.KubePort.Port)
 		if err != nil {
-			return PreparedConnection{}, errors.Wrapf(err, "error starting kubernetes port forwarding to %s on port %d", kubeService, kubePort)
+			return entry.PreparedConnection, err
 		}
-
+		entry.portForward = portForward
+		entry.PreparedConnection.Host = portForward.Host
+		entry.PreparedConnection.Port = portForward.Port
 		entry.PreparedConnection.CleanUp = func() {
 			preparedConnectionLock.Lock()
 			defer preparedConnectionLock.Unlock()
 			entry.handles--
 			if entry.handles == 0 {
-				err := entry.cmd.Process.Signal(os.Kill)
-				if err != nil {
-					logrus.WithError(err).Error("kill of port forward kubectl failed")
-				}
+				entry.portForward.Stop()
 				delete(preparedConnectionMap, key)
 			}
 		}
-
-		err = func() error {
-
-			line, _, readErr := reader.ReadLine()
-			if readErr == io.EOF {
-				readErr = entry.cmd.Wait()
-				return errors.Wrap(readErr, "kubectl port-forward failed")
-			}
-			if readErr != nil {
-				return errors.Wrap(readErr, "read kubectl port-forward output")
-			}
-			matches := regexp.MustCompile(`Forwarding from ([^:]+):(\d+)`).FindStringSubmatch(string(line))
-			if len(matches) < 3 {
-				return errors.Errorf("port forward failed; kubectl said %q", line)
-			}
-
-			entry.PreparedConnection.Host = matches[1]
-			entry.PreparedConnection.Port = matches[2]
-
-			// Wait for port to be available
-			for {
-				log.Debugf("checking for success of kubectl port-forward to mongodb at %s:%s", entry.PreparedConnection.Host, entry.PreparedConnection.Host)
-				conn, _ := net.DialTimeout("tcp", net.JoinHostPort(entry.PreparedConnection.Host, entry.PreparedConnection.Port), time.Second*30)
-				if conn != nil {
-					conn.Close()
-					log.Infof("kubectl port-forward to mongodb is ready at %s:%s", entry.PreparedConnection.Host, entry.PreparedConnection.Port)
-					break
-				}
-			}
-			return nil
-		}()
-
-		if err != nil {
-			return entry.PreparedConnection, err
-		}
-
 	}
 
 	var err error
@@ -335,31 +277,5 @@ func getPasswordCredentials(log *logrus.Entry, c CredentialProvider) (string, st
 
 func getVaultCredentials(log *logrus.Entry, c CredentialProvider) (username string, password string, err error) {
 	log.Debug("getting mongo credentials using 'vault' type")
-	username = ""
-	password = ""
-
-	vaultToken := os.Getenv("VAULT_TOKEN")
-	vaultAddr := os.Getenv("VAULT_ADDR")
-
-	log.Debugf("getting vault client at '%s' with token '%s'", vaultAddr, vaultToken)
-
-	vault, err := vault.NewVaultLowlevelClient(vaultToken, vaultAddr, log)
-	if err != nil {
-		return
-	}
-
-	log.Debugf("getting credentials from vault using path '%s'", c.VaultPath)
-	loginSecret, err := vault.Logical().Read(c.VaultPath)
-	if err != nil {
-		return
-	}
-
-	if loginSecret == nil {
-		err = fmt.Errorf("could not get credentials from vault, try running 'vault read %s' for more information", c.VaultPath)
-		return
-	}
-
-	username = loginSecret.Data["username"].(string)
-	password = loginSecret.Data["password"].(string)
-	return
+	return vault.ReadUsernamePassword(log, c.VaultPath)
 }
@@ -0,0 +1,196 @@
+package mq
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const kafkaContentType = "application/vnd.kafka.binary.v2+json"
+
+// kafkaBroker uses the Confluent REST proxy v2 API: https://docs.confluent.io/current/kafka-rest/api.html
+type kafkaBroker struct {
+	config Config
+	client *http.Client
+}
+
+func newKafkaBroker(config Config) *kafkaBroker {
+	config.URL = strings.TrimRight(config.URL, "/")
+	return &kafkaBroker{config: config, client: http.DefaultClient}
+}
+
+func (b *kafkaBroker) Publish(ctx context.Context, msg Message) error {
+	record := map[string]interface{}{
+		"value": base64.StdEncoding.EncodeToString(msg.Body),
+	}
+	if msg.Key != "" {
+		record["key"] = base64.StdEncoding.EncodeToString([]byte(msg.Key))
+	}
+
+	var result struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	err := b.do(ctx, http.MethodPost, fmt.Sprintf("%s/topics/%s", b.config.URL, url.PathEscape(msg.Subject)), map[string]interface{}{
+		"records": []interface{}{record},
+	}, &result)
+	if err != nil {
+		return errors.Wrapf(err, "publish to topic %q", msg.Subject)
+	}
+	for _, offset := range result.Offsets {
+		if offset.ErrorCode != nil || offset.Error != "" {
+			return errors.Errorf("publish to topic %q failed: %s", msg.Subject, offset.Error)
+		}
+	}
+	return nil
+}
+
+func (b *kafkaBroker) Subscribe(ctx context.Context, topic string, opts SubscribeOptions) (Subscription, error) {
+	offsetReset := "latest"
+	if opts.FromBeginning {
+		offsetReset = "earliest"
+	}
+	name := fmt.Sprintf("bosun-%d", time.Now().UnixNano())
+
+	var instance struct {
+		BaseURI string `json:"base_uri"`
+	}
+	err := b.do(ctx, http.MethodPost, fmt.Sprintf("%s/consumers/%s", b.config.URL, name), map[string]interface{}{
+		"name":               name,
+		"format":             "binary",
+		"auto.offset.reset":  offsetReset,
+		"auto.commit.enable": "false",
+	}, &instance)
+	if err != nil {
+		return nil, errors.Wrap(err, "create kafka consumer")
+	}
+
+	s := &kafkaSubscription{broker: b, topic: topic, baseURI: strings.TrimRight(instance.BaseURI, "/")}
+
+	err = b.do(ctx, http.MethodPost, s.baseURI+"/subscription", map[string]interface{}{
+		"topics": []string{topic},
+	}, nil)
+	if err != nil {
+		s.Close()
+		return nil, errors.Wrapf(err, "subscribe to topic %q", topic)
+	}
+
+	// the consumer isn't assigned any partitions until it polls, so poll once
+	// to make sure that messages published after this returns will be received
+	if err = s.poll(ctx); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (b *kafkaBroker) do(ctx context.Context, method string, address string, body interface{}, out interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, address, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", kafkaContentType)
+	req.Header.Set("Accept", kafkaContentType)
+	if b.config.Username != "" {
+		req.SetBasicAuth(b.config.Username, b.config.Password)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return errors.Errorf("kafka rest proxy returned %s: %s", resp.Status, string(respBody))
+	}
+	if out != nil && len(respBody) > 0 {
+		return errors.Wrap(json.Unmarshal(respBody, out), "parse kafka rest proxy response")
+	}
+	return nil
+}
+
+type kafkaSubscription struct {
+	broker   *kafkaBroker
+	topic    string
+	baseURI  string
+	buffered []Message
+}
+
+type kafkaRecord struct {
+	Topic string `json:"topic"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (s *kafkaSubscription) poll(ctx context.Context) error {
+	var records []kafkaRecord
+	if err := s.broker.do(ctx, http.MethodGet, s.baseURI+"/records", nil, &records); err != nil {
+		return errors.Wrapf(err, "get records from topic %q", s.topic)
+	}
+	for _, record := range records {
+		value, err := base64.StdEncoding.DecodeString(record.Value)
+		if err != nil {
+			return errors.Wrap(err, "decode record value")
+		}
+		key, err := base64.StdEncoding.DecodeString(record.Key)
+		if err != nil {
+			return errors.Wrap(err, "decode record key")
+		}
+		s.buffered = append(s.buffered, Message{Subject: record.Topic, Key: string(key), Body: value})
+	}
+	return nil
+}
+
+func (s *kafkaSubscription) Next(ctx context.Context) (Message, error) {
+	for {
+		if len(s.buffered) > 0 {
+			msg := s.buffered[0]
+			s.buffered = s.buffered[1:]
+			return msg, nil
+		}
+
+		if err := s.poll(ctx); err != nil {
+			return Message{}, err
+		}
+		if len(s.buffered) > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// Close deletes the consumer instance from the REST proxy.
+func (s *kafkaSubscription) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return s.broker.do(ctx, http.MethodDelete, s.baseURI, nil, nil)
+}
@@ -0,0 +1,86 @@
+// Package mq publishes and consumes messages using NATS, RabbitMQ (AMQP) or Kafka.
+// Kafka is reached through the Confluent REST proxy, so that no native client is needed.
+package mq
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+const (
+	BrokerTypeNATS  = "nats"
+	BrokerTypeAMQP  = "amqp"
+	BrokerTypeKafka = "kafka"
+)
+
+// Config defines how to connect to a broker.
+type Config struct {
+	// Type is nats, amqp, or kafka.
+	Type string `yaml:"type" json:"type"`
+	// URL is the address of the NATS server (like nats://localhost:4222), the AMQP
+	// server (like amqp://localhost:5672), or the Kafka REST proxy (like http://localhost:8082).
+	URL string `yaml:"url" json:"url"`
+	// VHost is the AMQP virtual host. Defaults to the virtual host in the URL, or "/".
+	VHost    string `yaml:"vhost,omitempty" json:"vhost,omitempty"`
+	Username string `yaml:"username,omitempty" json:"username,omitempty"`
+	Password string `yaml:"password,omitempty" json:"password,omitempty"`
+}
+
+// Message is a message which has been or will be sent to a broker.
+type Message struct {
+	// Subject is the NATS subject, the AMQP routing key or queue, or the Kafka topic.
+	Subject string `yaml:"subject" json:"subject"`
+	// Exchange is the AMQP exchange. Defaults to the default exchange, which routes
+	// messages to the queue named by the subject.
+	Exchange string `yaml:"exchange,omitempty" json:"exchange,omitempty"`
+	// Key is the Kafka message key.
+	Key string `yaml:"key,omitempty" json:"key,omitempty"`
+	// Headers are the AMQP message headers.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Body    []byte            `yaml:"-" json:"-"`
+}
+
+// SubscribeOptions control where a subscription starts.
+type SubscribeOptions struct {
+	// FromBeginning makes a Kafka subscription start from the earliest message in the topic
+	// instead of the messages published after the subscription is created.
+	FromBeginning bool
+	// Exchange makes an AMQP subscription bind a temporary queue to the exchange,
+	// using the subject as the routing key, instead of reading from the queue named
+	// by the subject.
+	Exchange string
+}
+
+// Broker publishes and consumes messages.
+type Broker interface {
+	Publish(ctx context.Context, msg Message) error
+	// Subscribe returns a subscription to subject, which is a NATS subject, an AMQP queue
+	// (or routing key, if opts.Exchange is set), or a Kafka topic. Messages published after
+	// Subscribe returns will be received. An AMQP subscription to a queue also receives
+	// the messages which are already in the queue, and removes them from it.
+	Subscribe(ctx context.Context, subject string, opts SubscribeOptions) (Subscription, error)
+}
+
+// Subscription receives messages from a broker.
+type Subscription interface {
+	// Next blocks until a message is received or ctx is done.
+	Next(ctx context.Context) (Message, error)
+	Close() error
+}
+
+// NewBroker creates a broker for the config.
+func NewBroker(config Config) (Broker, error) {
+	if config.URL == "" {
+		return nil, errors.New("broker url is required")
+	}
+	switch config.Type {
+	case BrokerTypeNATS:
+		return newNATSBroker(config)
+	case BrokerTypeAMQP:
+		return newRabbitMQBroker(config)
+	case BrokerTypeKafka:
+		return newKafkaBroker(config), nil
+	default:
+		return nil, errors.Errorf("unsupported broker type %q (supported types are %s, %s, and %s)", config.Type, BrokerTypeNATS, BrokerTypeAMQP, BrokerTypeKafka)
+	}
+}
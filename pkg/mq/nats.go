@@ -0,0 +1,228 @@
+package mq
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// natsBroker speaks the NATS client protocol: https://docs.nats.io/reference/reference-protocols/nats-protocol
+type natsBroker struct {
+	address  string
+	username string
+	password string
+}
+
+func newNATSBroker(config Config) (*natsBroker, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid nats url %q", config.URL)
+	}
+	b := &natsBroker{
+		address:  u.Host,
+		username: config.Username,
+		password: config.Password,
+	}
+	if u.Port() == "" {
+		b.address = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil && b.username == "" {
+		b.username = u.User.Username()
+		b.password, _ = u.User.Password()
+	}
+	return b, nil
+}
+
+func (b *natsBroker) Publish(ctx context.Context, msg Message) error {
+	conn, err := b.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err = conn.write(fmt.Sprintf("PUB %s %d\r\n%s\r\n", msg.Subject, len(msg.Body), msg.Body)); err != nil {
+		return err
+	}
+	// the server handles messages in order, so the PONG means the message has been processed
+	return errors.Wrap(conn.ping(ctx), "publish")
+}
+
+func (b *natsBroker) Subscribe(ctx context.Context, subject string, opts SubscribeOptions) (Subscription, error) {
+	conn, err := b.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = conn.write(fmt.Sprintf("SUB %s 1\r\n", subject)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// wait for the server to process the subscription, so that it receives anything published after this returns
+	if err = conn.ping(ctx); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "subscribe")
+	}
+
+	return conn, nil
+}
+
+type natsConn struct {
+	conn     net.Conn
+	writeMu  sync.Mutex
+	pongs    chan struct{}
+	messages chan Message
+	// err is set before done is closed, when reading fails or the connection is closed
+	err       error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (b *natsBroker) connect(ctx context.Context) (*natsConn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", b.address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "connect to nats at %s", b.address)
+	}
+
+	c := &natsConn{
+		conn:     conn,
+		pongs:    make(chan struct{}, 1),
+		messages: make(chan Message, 100),
+		done:     make(chan struct{}),
+	}
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, errors.Errorf("expected INFO from nats server, got %q (%v)", line, err)
+	}
+
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "bosun",
+		"lang":     "go",
+		"protocol": 0,
+	}
+	if b.username != "" {
+		options["user"] = b.username
+		options["pass"] = b.password
+	}
+	connectJSON, _ := json.Marshal(options)
+
+	go c.readLoop(reader)
+
+	if err = c.write(fmt.Sprintf("CONNECT %s\r\n", connectJSON)); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if err = c.ping(ctx); err != nil {
+		c.Close()
+		return nil, errors.Wrap(err, "connect to nats")
+	}
+
+	return c, nil
+}
+
+func (c *natsConn) write(s string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := io.WriteString(c.conn, s)
+	return errors.Wrap(err, "write to nats")
+}
+
+func (c *natsConn) ping(ctx context.Context) error {
+	if err := c.write("PING\r\n"); err != nil {
+		return err
+	}
+	select {
+	case <-c.pongs:
+		return nil
+	case <-c.done:
+		return c.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *natsConn) readLoop(reader *bufio.Reader) {
+	c.finish(c.read(reader))
+}
+
+func (c *natsConn) finish(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.done)
+	})
+}
+
+func (c *natsConn) read(reader *bufio.Reader) error {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return errors.Wrap(err, "read from nats")
+		}
+		line = strings.TrimRight(line, "\r\n")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			if err = c.write("PONG\r\n"); err != nil {
+				return err
+			}
+		case "PONG":
+			select {
+			case c.pongs <- struct{}{}:
+			default:
+			}
+		case "-ERR":
+			return errors.Errorf("nats server error: %s", strings.TrimPrefix(line, "-ERR "))
+		case "MSG":
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			if len(fields) < 4 {
+				return errors.Errorf("invalid message from nats: %q", line)
+			}
+			size, sizeErr := strconv.Atoi(fields[len(fields)-1])
+			if sizeErr != nil {
+				return errors.Errorf("invalid message size from nats: %q", line)
+			}
+			payload := make([]byte, size+2)
+			if _, err = io.ReadFull(reader, payload); err != nil {
+				return errors.Wrap(err, "read message from nats")
+			}
+			select {
+			case c.messages <- Message{Subject: fields[1], Body: payload[:size]}:
+			case <-c.done:
+				return nil
+			}
+		}
+	}
+}
+
+func (c *natsConn) Next(ctx context.Context) (Message, error) {
+	select {
+	case msg := <-c.messages:
+		return msg, nil
+	case <-c.done:
+		return Message{}, c.err
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+func (c *natsConn) Close() error {
+	c.finish(errors.New("nats connection closed"))
+	return c.conn.Close()
+}
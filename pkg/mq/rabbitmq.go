@@ -0,0 +1,165 @@
+package mq
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/streadway/amqp"
+)
+
+// rabbitMQBroker speaks AMQP 0-9-1 to a RabbitMQ server.
+type rabbitMQBroker struct {
+	url    string
+	config amqp.Config
+}
+
+func newRabbitMQBroker(config Config) (*rabbitMQBroker, error) {
+	uri, err := amqp.ParseURI(config.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid amqp url %q", config.URL)
+	}
+	b := &rabbitMQBroker{
+		url:    config.URL,
+		config: amqp.Config{Vhost: config.VHost},
+	}
+	if config.Username != "" {
+		uri.Username, uri.Password = config.Username, config.Password
+	}
+	b.config.SASL = []amqp.Authentication{uri.PlainAuth()}
+	return b, nil
+}
+
+func (b *rabbitMQBroker) connect() (*amqp.Connection, *amqp.Channel, error) {
+	conn, err := amqp.DialConfig(b.url, b.config)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "connect to amqp server")
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, nil, errors.Wrap(err, "open amqp channel")
+	}
+	return conn, ch, nil
+}
+
+func (b *rabbitMQBroker) Publish(ctx context.Context, msg Message) error {
+	conn, ch, err := b.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// a mandatory message which can't be routed is returned before it is confirmed
+	if err = ch.Confirm(false); err != nil {
+		return errors.Wrap(err, "enable publisher confirms")
+	}
+	confirms := ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+	returns := ch.NotifyReturn(make(chan amqp.Return, 1))
+
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	err = ch.Publish(msg.Exchange, msg.Subject, true, false, amqp.Publishing{
+		Headers: headers,
+		Body:    msg.Body,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "publish to exchange %q", msg.Exchange)
+	}
+
+	select {
+	case confirm, ok := <-confirms:
+		if !ok || !confirm.Ack {
+			return errors.Errorf("message published to exchange %q with routing key %q was not acknowledged", msg.Exchange, msg.Subject)
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-returns:
+		return errors.Errorf("message published to exchange %q with routing key %q was not routed to any queue", msg.Exchange, msg.Subject)
+	default:
+		return nil
+	}
+}
+
+// Subscribe reads from the queue named by subject, unless opts.Exchange is set,
+// in which case it binds a temporary queue to the exchange using subject as the
+// routing key and reads from that.
+func (b *rabbitMQBroker) Subscribe(ctx context.Context, subject string, opts SubscribeOptions) (Subscription, error) {
+	conn, ch, err := b.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	queue := subject
+	if opts.Exchange != "" {
+		// the server names the queue and deletes it when the connection is closed
+		var q amqp.Queue
+		q, err = ch.QueueDeclare("", false, true, true, false, nil)
+		if err != nil {
+			conn.Close()
+			return nil, errors.Wrap(err, "create temporary queue")
+		}
+		queue = q.Name
+		if err = ch.QueueBind(queue, subject, opts.Exchange, false, nil); err != nil {
+			conn.Close()
+			return nil, errors.Wrapf(err, "bind temporary queue to exchange %q with routing key %q", opts.Exchange, subject)
+		}
+	} else if _, err = ch.QueueDeclarePassive(queue, false, false, false, false, nil); err != nil {
+		// make sure the queue exists, so that a typo fails fast instead of timing out
+		conn.Close()
+		return nil, errors.Wrapf(err, "get queue %q", queue)
+	}
+
+	// only one message is delivered before it is acknowledged, so messages
+	// which are never read by Next are left in the queue
+	if err = ch.Qos(1, 0, false); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "set prefetch count")
+	}
+	deliveries, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "consume from queue %q", queue)
+	}
+
+	return &rabbitMQSubscription{conn: conn, queue: queue, deliveries: deliveries}, nil
+}
+
+type rabbitMQSubscription struct {
+	conn       *amqp.Connection
+	queue      string
+	deliveries <-chan amqp.Delivery
+}
+
+// Next acknowledges the message it returns, which removes it from the queue.
+// When reading from an existing queue this means that messages which were
+// already in the queue are received, and are not delivered to other consumers.
+func (s *rabbitMQSubscription) Next(ctx context.Context) (Message, error) {
+	select {
+	case d, ok := <-s.deliveries:
+		if !ok {
+			return Message{}, errors.Errorf("consumer of queue %q was closed", s.queue)
+		}
+		if err := d.Ack(false); err != nil {
+			return Message{}, errors.Wrapf(err, "acknowledge message from queue %q", s.queue)
+		}
+		return deliveryToMessage(d), nil
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+func (s *rabbitMQSubscription) Close() error {
+	return s.conn.Close()
+}
+
+func deliveryToMessage(d amqp.Delivery) Message {
+	msg := Message{Subject: d.RoutingKey, Exchange: d.Exchange, Body: d.Body, Headers: map[string]string{}}
+	for k, v := range d.Headers {
+		msg.Headers[k] = fmt.Sprint(v)
+	}
+	return msg
+}
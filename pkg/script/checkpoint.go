@@ -0,0 +1,218 @@
+package script
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"github.com/naveego/bosun/pkg/values"
+	"github.com/naveego/bosun/pkg/yaml"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Checkpoint records the progress of a script run, so that a failed run can be resumed.
+type Checkpoint struct {
+	Script    string    `yaml:"script" json:"script"`
+	FromPath  string    `yaml:"fromPath" json:"fromPath"`
+	StartedAt time.Time `yaml:"startedAt" json:"startedAt"`
+	UpdatedAt time.Time `yaml:"updatedAt" json:"updatedAt"`
+	// Steps are the indexes of the steps the run was supposed to execute.
+	Steps []int `yaml:"steps" json:"steps"`
+	// CompletedSteps are the indexes of the steps which succeeded.
+	CompletedSteps []int `yaml:"completedSteps" json:"completedSteps"`
+	// FailedStep is the index of the step which failed, if any.
+	FailedStep *int   `yaml:"failedStep,omitempty" json:"failedStep,omitempty"`
+	Error      string `yaml:"error,omitempty" json:"error,omitempty"`
+	// Params are the values of the script's params.
+	Params values.Values `yaml:"params,omitempty" json:"params,omitempty"`
+	// Captured are the values which completed steps added to the script values, such as
+	// values captured from HTTP responses. The other values the script was running with
+	// are not saved, because they may include secrets.
+	Captured values.Values `yaml:"captured,omitempty" json:"captured,omitempty"`
+
+	path       string
+	paramNames []string
+	// initialValues are the script values before any steps were run, used to find the captured values.
+	initialValues values.Values
+	scriptValues  *values.PersistableValues
+}
+
+// RemainingSteps returns the steps which haven't been completed, starting with the failed step.
+func (c *Checkpoint) RemainingSteps() []int {
+	completed := map[int]bool{}
+	for _, i := range c.CompletedSteps {
+		completed[i] = true
+	}
+	var out []int
+	for _, i := range c.Steps {
+		if !completed[i] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// ResumeValues returns the values to resume the run with: the values which were provided
+// explicitly when resuming, with the params from the checkpoint which weren't provided
+// and the values captured by the steps which were completed.
+func (c *Checkpoint) ResumeValues(explicit *values.PersistableValues) *values.PersistableValues {
+	out := explicit
+	if out == nil {
+		out = &values.PersistableValues{}
+	}
+	if out.Values == nil {
+		out.Values = values.Values{}
+	}
+	for k, v := range c.Params {
+		if _, ok := out.Values[k]; !ok {
+			out.Values[k] = v
+		}
+	}
+	// values captured before resuming are still captured values
+	c.initialValues = out.Values.Clone()
+	out.Values.Merge(c.Captured.Clone())
+	return out
+}
+
+// track records the params and captured values from scriptValues when the checkpoint is saved.
+func (c *Checkpoint) track(s *Script, scriptValues *values.PersistableValues) {
+	if scriptValues == nil {
+		return
+	}
+	if scriptValues.Values == nil {
+		scriptValues.Values = values.Values{}
+	}
+	c.paramNames = nil
+	for _, param := range s.Params {
+		c.paramNames = append(c.paramNames, param.Name)
+	}
+	if c.initialValues == nil {
+		c.initialValues = scriptValues.Values.Clone()
+	}
+	c.scriptValues = scriptValues
+}
+
+// Save writes the checkpoint to the file it was loaded from or created for.
+func (c *Checkpoint) Save() error {
+	c.UpdatedAt = time.Now()
+	if c.scriptValues != nil {
+		c.Params = values.Values{}
+		for _, name := range c.paramNames {
+			if value, ok := c.scriptValues.Values[name]; ok {
+				c.Params[name] = value
+			}
+		}
+		_, _, captured := values.Distill(c.initialValues, c.scriptValues.Values)
+		for _, name := range c.paramNames {
+			delete(captured, name)
+		}
+		c.Captured = pruneEmptyValues(captured)
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return errors.Wrap(err, "marshal checkpoint")
+	}
+	if err = os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return errors.Wrap(err, "create checkpoint dir")
+	}
+	return errors.Wrap(ioutil.WriteFile(c.path, data, 0600), "write checkpoint")
+}
+
+// GetCheckpointPath returns the path of the checkpoint file for the script in dir.
+func (s *Script) GetCheckpointPath(dir string) string {
+	hash := sha1.Sum([]byte(s.FromPath + "|" + s.Name))
+	name := regexp.MustCompile(`[^a-zA-Z0-9_-]+`).ReplaceAllString(s.Name, "_")
+	return filepath.Join(dir, fmt.Sprintf("%s-%x.yaml", name, hash[:4]))
+}
+
+// LoadCheckpoint loads the checkpoint for the last run of the script from dir.
+func (s *Script) LoadCheckpoint(dir string) (*Checkpoint, error) {
+	path := s.GetCheckpointPath(dir)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errors.Errorf("no checkpoint found for script %q (expected it at %s)", s.Name, path)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read checkpoint")
+	}
+	var checkpoint Checkpoint
+	if err = yaml.Unmarshal(data, &checkpoint); err != nil {
+		return nil, errors.Wrapf(err, "parse checkpoint at %s", path)
+	}
+	checkpoint.path = path
+	return &checkpoint, nil
+}
+
+func (s *Script) newCheckpoint(dir string, steps []int) *Checkpoint {
+	return &Checkpoint{
+		Script:    s.Name,
+		FromPath:  s.FromPath,
+		StartedAt: time.Now(),
+		Steps:     steps,
+		path:      s.GetCheckpointPath(dir),
+	}
+}
+
+// pruneEmptyValues removes the tables which don't contain any values.
+func pruneEmptyValues(v values.Values) values.Values {
+	out := values.Values{}
+	for k, value := range v {
+		if table, ok := value.(values.Values); ok {
+			if table = pruneEmptyValues(table); len(table) == 0 {
+				continue
+			}
+			value = table
+		}
+		out[k] = value
+	}
+	return out
+}
+
+// ResolveStep returns the index of the step identified by ref,
+// which can be the index or the name of the step.
+func (s *Script) ResolveStep(ref string) (int, error) {
+	if i, err := strconv.Atoi(ref); err == nil {
+		if i < 0 || i >= len(s.Steps) {
+			return 0, errors.Errorf("invalid step %d (there are %d steps)", i, len(s.Steps))
+		}
+		return i, nil
+	}
+	var names []string
+	for i, step := range s.Steps {
+		if strings.EqualFold(step.Name, ref) {
+			return i, nil
+		}
+		names = append(names, step.Name)
+	}
+	return 0, errors.Errorf("no step named %q in script %q (steps are %s)", ref, s.Name, strings.Join(names, ", "))
+}
+
+// GetStepRange returns the indexes of the steps from the step identified by from
+// to the step identified by to, inclusive. Empty refs mean the first or last step.
+func (s *Script) GetStepRange(from, to string) ([]int, error) {
+	start, end := 0, len(s.Steps)-1
+	var err error
+	if from != "" {
+		if start, err = s.ResolveStep(from); err != nil {
+			return nil, err
+		}
+	}
+	if to != "" {
+		if end, err = s.ResolveStep(to); err != nil {
+			return nil, err
+		}
+	}
+	if start > end {
+		return nil, errors.Errorf("step %q comes after step %q", from, to)
+	}
+	var out []int
+	for i := start; i <= end; i++ {
+		out = append(out, i)
+	}
+	return out, nil
+}
@@ -0,0 +1,132 @@
+package script
+
+import (
+	"github.com/naveego/bosun/pkg/values"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScriptGetStepRange(t *testing.T) {
+	sut := &Script{Steps: []ScriptStep{{}, {}, {}, {}}}
+	sut.Steps[1].Name = "migrate"
+	sut.Steps[2].Name = "verify"
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     []int
+		wantErr  bool
+	}{
+		{name: "all", want: []int{0, 1, 2, 3}},
+		{name: "from index", from: "2", want: []int{2, 3}},
+		{name: "from name", from: "Migrate", want: []int{1, 2, 3}},
+		{name: "from and to", from: "migrate", to: "verify", want: []int{1, 2}},
+		{name: "to", to: "1", want: []int{0, 1}},
+		{name: "unknown name", from: "missing", wantErr: true},
+		{name: "out of range", to: "4", wantErr: true},
+		{name: "backwards", from: "verify", to: "migrate", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sut.GetStepRange(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetStepRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetStepRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bosun-checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sut := &Script{Steps: []ScriptStep{{}, {}, {}}, Params: []ScriptParam{{Name: "orderID"}}}
+	sut.Name = "migration"
+	sut.FromPath = "/tmp/bosun.yaml"
+
+	scriptValues := &values.PersistableValues{Values: values.Values{
+		"orderID": "42",
+		"db":      values.Values{"host": "db", "password": "secret"},
+	}}
+	checkpoint := sut.newCheckpoint(dir, []int{0, 1, 2})
+	checkpoint.track(sut, scriptValues)
+	scriptValues.Values["token"] = "abc"
+	scriptValues.Values["db"].(values.Values)["id"] = 7
+	checkpoint.CompletedSteps = []int{0}
+	failedStep := 1
+	checkpoint.FailedStep = &failedStep
+	if err = checkpoint.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := sut.LoadCheckpoint(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.RemainingSteps(); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("RemainingSteps() = %v, want [1 2]", got)
+	}
+	if want := (values.Values{"orderID": "42"}); !reflect.DeepEqual(loaded.Params, want) {
+		t.Errorf("Params = %v, want %v", loaded.Params, want)
+	}
+	if want := (values.Values{"token": "abc", "db": values.Values{"id": 7}}); !reflect.DeepEqual(loaded.Captured, want) {
+		t.Errorf("Captured = %v, want %v", loaded.Captured, want)
+	}
+	data, err := ioutil.ReadFile(sut.GetCheckpointPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("checkpoint contains values which were not params or captured:\n%s", data)
+	}
+
+	other := &Script{}
+	other.Name = "other"
+	if _, err = other.LoadCheckpoint(dir); err == nil {
+		t.Error("expected an error when there is no checkpoint")
+	}
+}
+
+func TestCheckpointResumeValues(t *testing.T) {
+	sut := &Script{Params: []ScriptParam{{Name: "orderID"}, {Name: "region"}}}
+	checkpoint := &Checkpoint{
+		Params:   values.Values{"orderID": "42", "region": "us"},
+		Captured: values.Values{"token": "abc"},
+	}
+
+	explicit := &values.PersistableValues{Values: values.Values{"orderID": "43", "password": "secret"}}
+	got := checkpoint.ResumeValues(explicit)
+	want := values.Values{"orderID": "43", "region": "us", "password": "secret", "token": "abc"}
+	if !reflect.DeepEqual(got.Values, want) {
+		t.Errorf("ResumeValues() = %v, want %v", got.Values, want)
+	}
+
+	// values captured before and after resuming are both kept
+	checkpoint.path = filepath.Join(t.TempDir(), "checkpoint.yaml")
+	checkpoint.track(sut, got)
+	got.Values["id"] = 7
+	if err := checkpoint.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if want := (values.Values{"orderID": "43", "region": "us"}); !reflect.DeepEqual(checkpoint.Params, want) {
+		t.Errorf("Params = %v, want %v", checkpoint.Params, want)
+	}
+	if want := (values.Values{"token": "abc", "id": 7}); !reflect.DeepEqual(checkpoint.Captured, want) {
+		t.Errorf("Captured = %v, want %v", checkpoint.Captured, want)
+	}
+
+	got = (&Checkpoint{Params: values.Values{"orderID": "42"}}).ResumeValues(nil)
+	if want := (values.Values{"orderID": "42"}); !reflect.DeepEqual(got.Values, want) {
+		t.Errorf("ResumeValues(nil) = %v, want %v", got.Values, want)
+	}
+}
@@ -0,0 +1,161 @@
+package script
+
+import (
+	"fmt"
+	"github.com/naveego/bosun/pkg/semver"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"strconv"
+	"strings"
+)
+
+const (
+	ParamTypeString = "string"
+	ParamTypeInt    = "int"
+	ParamTypeBool   = "bool"
+	ParamTypeEnum   = "enum"
+	ParamTypeSemver = "semver"
+)
+
+// Validate checks that the param declaration is usable.
+func (p ScriptParam) Validate() error {
+	if p.Name == "" {
+		return errors.New("param must have a name")
+	}
+	switch p.getType() {
+	case ParamTypeString, ParamTypeInt, ParamTypeBool, ParamTypeSemver:
+	case ParamTypeEnum:
+		if len(p.Options) == 0 {
+			return errors.Errorf("param %q is an enum but has no options", p.Name)
+		}
+	default:
+		return errors.Errorf("param %q has unsupported type %q (supported types are %s)", p.Name, p.Type,
+			strings.Join([]string{ParamTypeString, ParamTypeInt, ParamTypeBool, ParamTypeEnum, ParamTypeSemver}, ", "))
+	}
+	if p.DefaultValue != nil {
+		if _, err := p.Coerce(p.DefaultValue); err != nil {
+			return errors.Wrapf(err, "param %q has invalid default value", p.Name)
+		}
+	}
+	return nil
+}
+
+func (p ScriptParam) getType() string {
+	if p.Type == "" {
+		return ParamTypeString
+	}
+	return strings.ToLower(p.Type)
+}
+
+// Parse converts raw into a value of the param's type.
+func (p ScriptParam) Parse(raw string) (interface{}, error) {
+	switch p.getType() {
+	case ParamTypeInt:
+		i, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, errors.Errorf("%q is not an int", raw)
+		}
+		return i, nil
+	case ParamTypeBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.Errorf("%q is not a bool", raw)
+		}
+		return b, nil
+	case ParamTypeEnum:
+		for _, option := range p.Options {
+			if option == raw {
+				return raw, nil
+			}
+		}
+		return nil, errors.Errorf("%q is not one of %s", raw, strings.Join(p.Options, ", "))
+	case ParamTypeSemver:
+		v, err := semver.NewVersion(raw)
+		if err != nil {
+			return nil, errors.Errorf("%q is not a semantic version: %s", raw, err)
+		}
+		return v.String(), nil
+	default:
+		return raw, nil
+	}
+}
+
+// Coerce converts a value from yaml or the release values into a value of the param's type.
+func (p ScriptParam) Coerce(value interface{}) (interface{}, error) {
+	if s, ok := value.(string); ok {
+		return p.Parse(s)
+	}
+	return p.Parse(fmt.Sprint(value))
+}
+
+// Usage returns the help text for the param.
+func (p ScriptParam) Usage() string {
+	usage := p.Description
+	if p.getType() == ParamTypeEnum {
+		usage = strings.TrimSpace(fmt.Sprintf("%s (one of %s)", usage, strings.Join(p.Options, ", ")))
+	}
+	if p.DefaultValue == nil {
+		usage = strings.TrimSpace(usage + " (required)")
+	}
+	return usage
+}
+
+// AddParamFlags adds a flag for each of the script's params to flags.
+// The flags check the type of the values they are set to.
+func (s *Script) AddParamFlags(flags *pflag.FlagSet) error {
+	for _, param := range s.Params {
+		if err := param.Validate(); err != nil {
+			return err
+		}
+		value := &paramValue{param: param}
+		if param.DefaultValue != nil {
+			value.raw = fmt.Sprint(param.DefaultValue)
+		}
+		flag := flags.VarPF(value, param.Name, "", param.Usage())
+		if param.getType() == ParamTypeBool {
+			flag.NoOptDefVal = "true"
+		}
+	}
+	return nil
+}
+
+// GetParamValues returns the values of the params which were set in flags,
+// which must have been populated by AddParamFlags.
+func (s *Script) GetParamValues(flags *pflag.FlagSet) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, param := range s.Params {
+		flag := flags.Lookup(param.Name)
+		if flag == nil || !flag.Changed {
+			continue
+		}
+		if value, ok := flag.Value.(*paramValue); ok {
+			out[param.Name] = value.value
+		}
+	}
+	return out
+}
+
+// paramValue is a pflag.Value which validates the value using the param.
+type paramValue struct {
+	param ScriptParam
+	raw   string
+	value interface{}
+}
+
+func (p *paramValue) String() string {
+	return p.raw
+}
+
+func (p *paramValue) Set(raw string) error {
+	value, err := p.param.Parse(raw)
+	if err != nil {
+		return err
+	}
+	p.raw = raw
+	p.value = value
+	return nil
+}
+
+func (p *paramValue) Type() string {
+	return p.param.getType()
+}
@@ -0,0 +1,78 @@
+package script
+
+import (
+	"github.com/spf13/pflag"
+	"reflect"
+	"testing"
+)
+
+func TestScriptParamParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		param   ScriptParam
+		raw     string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "string", param: ScriptParam{Name: "p"}, raw: "x", want: "x"},
+		{name: "int", param: ScriptParam{Name: "p", Type: ParamTypeInt}, raw: "42", want: 42},
+		{name: "invalid int", param: ScriptParam{Name: "p", Type: ParamTypeInt}, raw: "x", wantErr: true},
+		{name: "bool", param: ScriptParam{Name: "p", Type: ParamTypeBool}, raw: "true", want: true},
+		{name: "enum", param: ScriptParam{Name: "p", Type: ParamTypeEnum, Options: []string{"a", "b"}}, raw: "b", want: "b"},
+		{name: "invalid enum", param: ScriptParam{Name: "p", Type: ParamTypeEnum, Options: []string{"a", "b"}}, raw: "c", wantErr: true},
+		{name: "semver", param: ScriptParam{Name: "p", Type: ParamTypeSemver}, raw: "1.2.3-rc.1", want: "1.2.3-rc.1"},
+		{name: "invalid semver", param: ScriptParam{Name: "p", Type: ParamTypeSemver}, raw: "1.2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.param.Parse(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScriptParamFlags(t *testing.T) {
+	sut := &Script{Params: []ScriptParam{
+		{Name: "count", Type: ParamTypeInt, DefaultValue: 1},
+		{Name: "enabled", Type: ParamTypeBool, DefaultValue: false},
+		{Name: "version", Type: ParamTypeSemver},
+	}}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	if err := sut.AddParamFlags(flags); err != nil {
+		t.Fatal(err)
+	}
+	if err := flags.Parse([]string{"--count", "3", "--enabled", "--version=1.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{"count": 3, "enabled": true, "version": "1.0.0"}
+	if got := sut.GetParamValues(flags); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetParamValues() = %#v, want %#v", got, want)
+	}
+
+	flags = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	_ = sut.AddParamFlags(flags)
+	if err := flags.Parse([]string{"--count", "many"}); err == nil {
+		t.Error("expected an error for an invalid int")
+	}
+}
+
+func TestScriptParamValidate(t *testing.T) {
+	invalid := []ScriptParam{
+		{Type: ParamTypeString},
+		{Name: "p", Type: "float"},
+		{Name: "p", Type: ParamTypeEnum},
+		{Name: "p", Type: ParamTypeInt, DefaultValue: "x"},
+	}
+	for _, param := range invalid {
+		if err := param.Validate(); err == nil {
+			t.Errorf("expected %#v to be invalid", param)
+		}
+	}
+}
@@ -18,6 +18,7 @@ import (
 type ScriptContext interface {
 	actions.ActionContext
 	GetReleaseValues() *values.PersistableValues
+	WithPersistableValues(v *values.PersistableValues) interface{}
 }
 
 type Script struct {
@@ -28,11 +29,16 @@ type Script struct {
 	Params            []ScriptParam    `yaml:"params,omitempty" json:"params,omitempty"`
 }
 
+// ScriptParam is a value which must be provided to the script, usually as a flag.
+// Type can be string (the default), int, bool, enum, or semver. Params without a
+// DefaultValue are required.
 type ScriptParam struct {
 	Name         string      `yaml:"name,omitempty" json:"name,omitempty"`
 	Type         string      `yaml:"type,omitempty" json:"type,omitempty"`
 	Description  string      `yaml:"description,omitempty" json:"description,omitempty"`
 	DefaultValue interface{} `yaml:"defaultValue,omitempty"`
+	// Options are the allowed values for an enum param.
+	Options []string `yaml:"options,omitempty" json:"options,omitempty"`
 }
 
 func (s *Script) SetFromPath(path string) {
@@ -111,8 +117,25 @@ type scriptStepV1 struct {
 	Literal     *command.CommandValue  `yaml:"literal,omitempty" json:"literal,omitempty"`
 }
 
+// ExecuteOptions control which steps of a script are run and how the progress of the run is saved.
+type ExecuteOptions struct {
+	// Steps are the indexes of the steps to run; all steps are run if this is empty.
+	Steps []int
+	// CheckpointDir is the directory the checkpoint for the run is saved in.
+	// No checkpoint is saved if this is empty.
+	CheckpointDir string
+	// Resume runs the steps which the last run didn't complete, using the
+	// params and captured values from the last run. Requires CheckpointDir.
+	Resume bool
+}
+
 func (s *Script) Execute(ctx ScriptContext, steps ...int) error {
+	return s.ExecuteWithOptions(ctx, ExecuteOptions{Steps: steps})
+}
+
+func (s *Script) ExecuteWithOptions(ctx ScriptContext, opts ExecuteOptions) error {
 	var err error
+	steps := opts.Steps
 
 	ctx = ctx.WithPwd(s.FromPath).(ScriptContext)
 
@@ -136,24 +159,62 @@ func (s *Script) Execute(ctx ScriptContext, steps ...int) error {
 		}
 	}
 
+	var checkpoint *Checkpoint
+	if opts.Resume {
+		if opts.CheckpointDir == "" {
+			return errors.New("cannot resume a script without a checkpoint dir")
+		}
+		checkpoint, err = s.LoadCheckpoint(opts.CheckpointDir)
+		if err != nil {
+			return err
+		}
+		steps = checkpoint.RemainingSteps()
+		if len(steps) == 0 {
+			return errors.Errorf("the last run of script %q completed all its steps, there is nothing to resume", s.Name)
+		}
+		ctx = ctx.WithPersistableValues(checkpoint.ResumeValues(ctx.GetReleaseValues())).(ScriptContext)
+		ctx.Log().Infof("Resuming script from step %d (%d of %d steps were completed).", steps[0], len(checkpoint.CompletedSteps), len(checkpoint.Steps))
+		checkpoint.FailedStep = nil
+		checkpoint.Error = ""
+	}
+
 	if len(s.Params) > 0 {
 		releaseValues := ctx.GetReleaseValues()
 		if releaseValues == nil {
 			return errors.New("script has params but no release values provided")
 		}
 
+		if releaseValues.Values == nil {
+			releaseValues.Values = values.Values{}
+		}
+
 		for _, param := range s.Params {
-			_, ok := releaseValues.Values[param.Name]
+			if validateErr := param.Validate(); validateErr != nil {
+				return validateErr
+			}
+			value, ok := releaseValues.Values[param.Name]
 			if !ok {
 				if param.DefaultValue == nil {
 					return errors.Errorf("script param %q does not have a value set", param.Name)
 				}
-				releaseValues.Values[param.Name] = param.DefaultValue
+				value = param.DefaultValue
 			}
+			value, err = param.Coerce(value)
+			if err != nil {
+				return errors.Wrapf(err, "invalid value for script param %q", param.Name)
+			}
+			releaseValues.Values[param.Name] = value
 		}
 	}
 
 	if s.Literal != nil {
+		if opts.Resume {
+			return errors.Errorf("script %q is a literal script, which cannot be resumed", s.Name)
+		}
+		if ctx.GetParameters().DryRun {
+			actions.PrintDryRun(fmt.Sprintf("script %s", s.Name), fmt.Sprintf("run command: %s", s.Literal.String()))
+			return nil
+		}
 		ctx.Log().Debug("Executing literal script, not bosun script.")
 		scriptCtx := ctx.WithPwd(filepath.Dir(s.FromPath)).(ScriptContext)
 		_, err = s.Literal.Execute(scriptCtx, command.CommandOpts{StreamOutput: true})
@@ -183,15 +244,49 @@ func (s *Script) Execute(ctx ScriptContext, steps ...int) error {
 	}
 
 	for _, i := range steps {
-		if i >= len(s.Steps) {
+		if i < 0 || i >= len(s.Steps) {
 			return errors.Errorf("invalid step %d (there are %d steps)", i, len(s.Steps))
 		}
+	}
+
+	if ctx.GetParameters().DryRun {
+		// a dry run doesn't make any progress, so there's nothing to checkpoint
+		checkpoint = nil
+	} else if opts.CheckpointDir != "" && checkpoint == nil {
+		checkpoint = s.newCheckpoint(opts.CheckpointDir, steps)
+	}
+	if checkpoint != nil {
+		checkpoint.track(s, ctx.GetReleaseValues())
+	}
+	saveCheckpoint := func() {
+		if checkpoint == nil {
+			return
+		}
+		if saveErr := checkpoint.Save(); saveErr != nil {
+			ctx.Log().WithError(saveErr).Warn("Could not save script checkpoint.")
+		}
+	}
+	saveCheckpoint()
+
+	for _, i := range steps {
 		step := s.Steps[i]
 
 		stepCtx := ctx.WithLogField("step", i).(ScriptContext)
 		err = step.Execute(stepCtx, i)
 		if err != nil {
-			return errors.Wrapf(err, "script %q abended on step %q (%d)", s.Name, s.Name, i)
+			if checkpoint != nil {
+				failedStep := i
+				checkpoint.FailedStep = &failedStep
+				checkpoint.Error = err.Error()
+				saveCheckpoint()
+				ctx.Log().Infof("Progress saved to %s, use --resume to continue from step %d.", checkpoint.path, i)
+			}
+			return errors.Wrapf(err, "script %q abended on step %q (%d)", s.Name, step.Name, i)
+		}
+
+		if checkpoint != nil {
+			checkpoint.CompletedSteps = append(checkpoint.CompletedSteps, i)
+			saveCheckpoint()
 		}
 	}
 
@@ -208,7 +303,13 @@ func (s ScriptStep) Execute(ctx ScriptContext, index int) error {
 		log.Info(s.Description)
 	}
 
+	dryRunTitle := fmt.Sprintf("step %d %s", index, s.Name)
+
 	if s.Cmd != nil {
+		if ctx.GetParameters().DryRun {
+			actions.PrintDryRun(dryRunTitle, fmt.Sprintf("run command: %s", s.Cmd.String()))
+			return nil
+		}
 		log.Debug("Step is a shell command, not a bosun command.")
 		cmdCtx := ctx.WithPwd(filepath.Dir(s.FromPath)).(command.ExecutionContext)
 
@@ -243,6 +344,11 @@ func (s ScriptStep) Execute(ctx ScriptContext, index int) error {
 
 	stepArgs = append(stepArgs, "--cluster", ctx.GetStringValue(core.KeyCluster))
 
+	if ctx.GetParameters().DryRun {
+		actions.PrintDryRun(dryRunTitle, fmt.Sprintf("run bosun %s", strings.Join(stepArgs, " ")))
+		return nil
+	}
+
 	log.WithField("args", stepArgs).Info("Executing step")
 
 	err = command.NewShellExe(exe, stepArgs...).WithDir(ctx.Pwd()).RunE()
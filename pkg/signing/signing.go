@@ -0,0 +1,293 @@
+// Package signing creates and verifies detached signatures using ed25519 keys
+// or ECDSA keys created by cosign.
+//
+// Keys are read from PEM files. Private keys can be unencrypted PKCS8 keys
+// (like those created by `openssl genpkey -algorithm ed25519`) or encrypted
+// cosign keys (created by `cosign generate-key-pair`). Public keys are PKIX keys.
+//
+// Signatures are base64 encoded. ECDSA signatures are made over the SHA256 digest
+// of the payload, so they can be checked using `cosign verify-blob`.
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+)
+
+const (
+	pemTypePrivateKey            = "PRIVATE KEY"
+	pemTypeECPrivateKey          = "EC PRIVATE KEY"
+	pemTypePublicKey             = "PUBLIC KEY"
+	pemTypeCosignPrivateKey      = "ENCRYPTED COSIGN PRIVATE KEY"
+	pemTypeSigstorePrivateKey    = "ENCRYPTED SIGSTORE PRIVATE KEY"
+	cosignKDFScrypt              = "scrypt"
+	cosignCipherNaclSecretbox    = "nacl/secretbox"
+	cosignPasswordEnvironmentVar = "COSIGN_PASSWORD"
+)
+
+// Signer signs payloads.
+type Signer interface {
+	// Sign returns the base64 encoded signature of payload.
+	Sign(payload []byte) ([]byte, error)
+	// Verifier returns a verifier for the public key of the signer.
+	Verifier() Verifier
+}
+
+// Verifier verifies signatures made by a Signer.
+type Verifier interface {
+	// Verify returns an error if signature is not a valid base64 encoded signature of payload.
+	Verify(payload []byte, signature []byte) error
+}
+
+// LoadSigner reads a private key from a PEM file. The password is only used
+// for encrypted cosign keys; if it is nil the COSIGN_PASSWORD environment variable is used.
+func LoadSigner(path string, password []byte) (Signer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read private key")
+	}
+	signer, err := ParseSigner(data, password)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse private key %q", path)
+	}
+	return signer, nil
+}
+
+// ParseSigner parses a PEM encoded private key.
+func ParseSigner(data []byte, password []byte) (Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case pemTypePrivateKey:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case pemTypeECPrivateKey:
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case pemTypeCosignPrivateKey, pemTypeSigstorePrivateKey:
+		var der []byte
+		der, err = decryptCosignKey(block.Bytes, password)
+		if err == nil {
+			key, err = x509.ParsePKCS8PrivateKey(der)
+		}
+	default:
+		return nil, errors.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return ed25519Signer{key: k}, nil
+	case *ecdsa.PrivateKey:
+		return ecdsaSigner{key: k}, nil
+	default:
+		return nil, errors.Errorf("unsupported private key type %T (ed25519 and ECDSA keys are supported)", key)
+	}
+}
+
+// LoadVerifier reads a public key from a PEM file.
+func LoadVerifier(path string) (Verifier, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read public key")
+	}
+	verifier, err := ParseVerifier(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse public key %q", path)
+	}
+	return verifier, nil
+}
+
+// ParseVerifier parses a PEM encoded PKIX public key.
+func ParseVerifier(data []byte) (Verifier, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if block.Type != pemTypePublicKey {
+		return nil, errors.Errorf("unsupported PEM block type %q (expected %q)", block.Type, pemTypePublicKey)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return ed25519Verifier{key: k}, nil
+	case *ecdsa.PublicKey:
+		return ecdsaVerifier{key: k}, nil
+	default:
+		return nil, errors.Errorf("unsupported public key type %T (ed25519 and ECDSA keys are supported)", key)
+	}
+}
+
+// GenerateKeyPair creates a new ed25519 key pair, returning the PEM encoded private and public keys.
+func GenerateKeyPair() (privateKey []byte, publicKey []byte, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateKey = pem.EncodeToMemory(&pem.Block{Type: pemTypePrivateKey, Bytes: privDER})
+	publicKey = pem.EncodeToMemory(&pem.Block{Type: pemTypePublicKey, Bytes: pubDER})
+	return privateKey, publicKey, nil
+}
+
+type ed25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+func (s ed25519Signer) Sign(payload []byte) ([]byte, error) {
+	return encodeSignature(ed25519.Sign(s.key, payload)), nil
+}
+
+func (s ed25519Signer) Verifier() Verifier {
+	return ed25519Verifier{key: s.key.Public().(ed25519.PublicKey)}
+}
+
+type ed25519Verifier struct {
+	key ed25519.PublicKey
+}
+
+func (v ed25519Verifier) Verify(payload []byte, signature []byte) error {
+	sig, err := decodeSignature(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(v.key, payload, sig) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+type ecdsaSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s ecdsaSigner) Sign(payload []byte) ([]byte, error) {
+	digest := sha256.Sum256(payload)
+	sig, err := s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return encodeSignature(sig), nil
+}
+
+func (s ecdsaSigner) Verifier() Verifier {
+	return ecdsaVerifier{key: &s.key.PublicKey}
+}
+
+type ecdsaVerifier struct {
+	key *ecdsa.PublicKey
+}
+
+func (v ecdsaVerifier) Verify(payload []byte, signature []byte) error {
+	sig, err := decodeSignature(signature)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(payload)
+	var parsed struct {
+		R, S *big.Int
+	}
+	if rest, err := asn1.Unmarshal(sig, &parsed); err != nil || len(rest) > 0 {
+		return errors.New("invalid signature encoding")
+	}
+	if !ecdsa.Verify(v.key, digest[:], parsed.R, parsed.S) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func encodeSignature(sig []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(sig))
+}
+
+func decodeSignature(signature []byte) ([]byte, error) {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return nil, errors.Wrap(err, "signature is not base64 encoded")
+	}
+	return sig, nil
+}
+
+// cosignEncryptedKey is the JSON document in the PEM block of an encrypted cosign private key.
+type cosignEncryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func decryptCosignKey(data []byte, password []byte) ([]byte, error) {
+	var encrypted cosignEncryptedKey
+	if err := json.Unmarshal(data, &encrypted); err != nil {
+		return nil, errors.Wrap(err, "parse encrypted cosign key")
+	}
+	if encrypted.KDF.Name != cosignKDFScrypt {
+		return nil, errors.Errorf("unsupported key derivation function %q", encrypted.KDF.Name)
+	}
+	if encrypted.Cipher.Name != cosignCipherNaclSecretbox {
+		return nil, errors.Errorf("unsupported cipher %q", encrypted.Cipher.Name)
+	}
+	if len(encrypted.Cipher.Nonce) != 24 {
+		return nil, errors.Errorf("invalid nonce length %d", len(encrypted.Cipher.Nonce))
+	}
+
+	if password == nil {
+		password = []byte(os.Getenv(cosignPasswordEnvironmentVar))
+	}
+
+	params := encrypted.KDF.Params
+	secretKey, err := scrypt.Key(password, encrypted.KDF.Salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "derive key")
+	}
+
+	var nonce [24]byte
+	var key [32]byte
+	copy(nonce[:], encrypted.Cipher.Nonce)
+	copy(key[:], secretKey)
+	der, ok := secretbox.Open(nil, encrypted.Ciphertext, &nonce, &key)
+	if !ok {
+		return nil, errors.Errorf("decrypt key (check the password or the %s environment variable)", cosignPasswordEnvironmentVar)
+	}
+	return der, nil
+}
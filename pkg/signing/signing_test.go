@@ -0,0 +1,104 @@
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+	"testing"
+)
+
+func checkRoundTrip(t *testing.T, signer Signer, verifier Verifier) {
+	t.Helper()
+	payload := []byte("files:\n  platform.yaml: abc\n")
+	sig, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err = verifier.Verify(payload, sig); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err = verifier.Verify([]byte("files:\n  platform.yaml: abd\n"), sig); err == nil {
+		t.Fatal("expected tampered payload to fail verification")
+	}
+	if err = verifier.Verify(payload, []byte("not a signature")); err == nil {
+		t.Fatal("expected garbage signature to fail verification")
+	}
+}
+
+func TestEd25519(t *testing.T) {
+	priv, pub, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ParseSigner(priv, nil)
+	if err != nil {
+		t.Fatalf("parse signer: %v", err)
+	}
+	verifier, err := ParseVerifier(pub)
+	if err != nil {
+		t.Fatalf("parse verifier: %v", err)
+	}
+	checkRoundTrip(t, signer, verifier)
+	checkRoundTrip(t, signer, signer.Verifier())
+
+	_, otherPub, _ := GenerateKeyPair()
+	otherVerifier, _ := ParseVerifier(otherPub)
+	sig, _ := signer.Sign([]byte("payload"))
+	if err = otherVerifier.Verify([]byte("payload"), sig); err == nil {
+		t.Fatal("expected signature from a different key to fail verification")
+	}
+}
+
+func TestECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalECPrivateKey(key)
+	signer, err := ParseSigner(pem.EncodeToMemory(&pem.Block{Type: pemTypeECPrivateKey, Bytes: der}), nil)
+	if err != nil {
+		t.Fatalf("parse signer: %v", err)
+	}
+	pubDER, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	verifier, err := ParseVerifier(pem.EncodeToMemory(&pem.Block{Type: pemTypePublicKey, Bytes: pubDER}))
+	if err != nil {
+		t.Fatalf("parse verifier: %v", err)
+	}
+	checkRoundTrip(t, signer, verifier)
+}
+
+func TestEncryptedCosignKey(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+
+	var encrypted cosignEncryptedKey
+	encrypted.KDF.Name = cosignKDFScrypt
+	encrypted.KDF.Params.N = 1024
+	encrypted.KDF.Params.R = 8
+	encrypted.KDF.Params.P = 1
+	encrypted.KDF.Salt = []byte("0123456789abcdef0123456789abcdef")
+	encrypted.Cipher.Name = cosignCipherNaclSecretbox
+	encrypted.Cipher.Nonce = []byte("0123456789abcdef01234567")
+	secretKey, _ := scrypt.Key([]byte("hunter2"), encrypted.KDF.Salt, 1024, 8, 1, 32)
+	var nonce [24]byte
+	var box [32]byte
+	copy(nonce[:], encrypted.Cipher.Nonce)
+	copy(box[:], secretKey)
+	encrypted.Ciphertext = secretbox.Seal(nil, der, &nonce, &box)
+	data, _ := json.Marshal(encrypted)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: pemTypeCosignPrivateKey, Bytes: data})
+
+	if _, err := ParseSigner(keyPEM, []byte("wrong")); err == nil {
+		t.Fatal("expected wrong password to fail")
+	}
+	signer, err := ParseSigner(keyPEM, []byte("hunter2"))
+	if err != nil {
+		t.Fatalf("parse signer: %v", err)
+	}
+	checkRoundTrip(t, signer, signer.Verifier())
+}
@@ -0,0 +1,208 @@
+// Package sqldb runs statements and queries against Postgres and MySQL databases
+// using the psql and mysql command line clients, which must be installed.
+package sqldb
+
+import (
+	"context"
+	"fmt"
+	"github.com/naveego/bosun/pkg/command"
+	"github.com/naveego/bosun/pkg/kube"
+	"github.com/naveego/bosun/pkg/vault"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strings"
+)
+
+const (
+	EnginePostgres = "postgres"
+	EngineMySQL    = "mysql"
+)
+
+// Connection defines how to connect to a SQL database. Credentials and kubernetes
+// port forwarding are configured the same way as for a mongo connection.
+type Connection struct {
+	// Engine is postgres or mysql.
+	Engine      string             `yaml:"engine" json:"engine"`
+	DBName      string             `yaml:"dbName" json:"dbName"`
+	Host        string             `yaml:"host,omitempty" json:"host,omitempty"`
+	Port        string             `yaml:"port,omitempty" json:"port,omitempty"`
+	KubePort    KubePortForward    `yaml:"kubePort,omitempty" json:"kubePort,omitempty"`
+	Credentials CredentialProvider `yaml:"credentials" json:"credentials"`
+}
+
+// CredentialProvider defines how the connection should obtain its credentials.
+// Type is "password" to use the username and password, or "vault" to read
+// the username and password from the secret at VaultPath.
+type CredentialProvider struct {
+	Type      string `yaml:"type" json:"type"`
+	Username  string `yaml:"username,omitempty" json:"username,omitempty"`
+	Password  string `yaml:"password,omitempty" json:"password,omitempty"`
+	VaultPath string `yaml:"vaultPath,omitempty" json:"vaultPath,omitempty"`
+}
+
+// KubePortForward defines whether or not we need to tunnel into Kubernetes, and what port to use.
+type KubePortForward struct {
+	Forward     bool   `yaml:"forward" json:"forward"`
+	ServiceName string `yaml:"serviceName" json:"serviceName"`
+	Port        int    `yaml:"port,omitempty" json:"port,omitempty"`
+	Namespace   string `yaml:"namespace" json:"namespace"`
+}
+
+// PreparedConnection is a connection with its credentials resolved and its port forward
+// (if any) running. CleanUp must be called when the connection is no longer needed.
+type PreparedConnection struct {
+	Connection
+	CleanUp func()
+}
+
+func (c Connection) defaultPort() int {
+	if c.Engine == EngineMySQL {
+		return 3306
+	}
+	return 5432
+}
+
+// Prepare resolves the credentials and starts the port forward for the connection.
+// Connections to the same service share a port forward until they have all been cleaned up.
+func (c Connection) Prepare(log *logrus.Entry) (PreparedConnection, error) {
+
+	switch c.Engine {
+	case EnginePostgres, EngineMySQL:
+	default:
+		return PreparedConnection{}, errors.Errorf("unsupported engine %q (supported engines are %s and %s)", c.Engine, EnginePostgres, EngineMySQL)
+	}
+
+	if c.KubePort.Port == 0 {
+		c.KubePort.Port = c.defaultPort()
+	}
+	if c.KubePort.ServiceName != "" && !strings.Contains(c.KubePort.ServiceName, "/") {
+		c.KubePort.ServiceName = "svc/" + c.KubePort.ServiceName
+	}
+
+	var err error
+	switch c.Credentials.Type {
+	case "password":
+	case "vault":
+		c.Credentials.Username, c.Credentials.Password, err = vault.ReadUsernamePassword(log, c.Credentials.VaultPath)
+	default:
+		err = errors.Errorf("the type '%s' is not a supported credential type, must be 'vault' or 'password'", c.Credentials.Type)
+	}
+	if err != nil {
+		return PreparedConnection{}, errors.Wrap(err, "get credentials")
+	}
+
+	p := PreparedConnection{
+		Connection: c,
+		CleanUp:    func() {},
+	}
+
+	if c.KubePort.Forward {
+		if c.KubePort.ServiceName == "" {
+			return PreparedConnection{}, errors.New("kubePort.serviceName is required when kubePort.forward is true")
+		}
+		portForward, err := kube.StartLocalPortForward(log, c.KubePort.Namespace, c.KubePort.ServiceName, c.KubePort.Port)
+		if err != nil {
+			return PreparedConnection{}, err
+		}
+		p.Host, p.Port = portForward.Host, portForward.Port
+		p.CleanUp = portForward.Stop
+	}
+
+	if p.Host == "" {
+		p.Host = "127.0.0.1"
+	}
+	if p.Port == "" {
+		p.Port = fmt.Sprint(c.defaultPort())
+	}
+
+	return p, nil
+}
+
+// Exec runs the SQL statements and returns the output of the client.
+func (p PreparedConnection) Exec(ctx context.Context, statements string) (string, error) {
+	if p.Engine == EngineMySQL {
+		return p.client(ctx, "-e", statements).RunOut()
+	}
+	return p.client(ctx, "-c", statements).RunOut()
+}
+
+// ExecFile runs the SQL statements in the file and returns the output of the client.
+func (p PreparedConnection) ExecFile(ctx context.Context, path string) (string, error) {
+	if p.Engine != EngineMySQL {
+		return p.client(ctx, "-f", path).RunOut()
+	}
+	// mysql can only read a file using its source command, which doesn't
+	// handle every path, so the file is passed on stdin instead.
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrap(err, "open sql file")
+	}
+	defer f.Close()
+	exe := p.client(ctx)
+	exe.GetCmd().Stdin = f
+	return exe.RunOut()
+}
+
+// Rows are the results of a query, with each row mapping column names to values.
+// NULL values are returned as "NULL".
+type Rows []map[string]string
+
+// Query runs the query and returns the rows it produced.
+func (p PreparedConnection) Query(ctx context.Context, query string) (Rows, error) {
+	var args []string
+	if p.Engine == EngineMySQL {
+		args = []string{"--batch", "-e", query}
+	} else {
+		args = []string{"--no-align", "--field-separator", "\t", "--pset", "footer=off", "--pset", "null=NULL", "-c", query}
+	}
+	out, err := p.client(ctx, args...).RunOut()
+	if err != nil {
+		return nil, err
+	}
+	return parseRows(out), nil
+}
+
+// parseRows parses tab separated output with a header line.
+func parseRows(out string) Rows {
+	rows := Rows{}
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) == 0 || lines[0] == "" {
+		return rows
+	}
+	columns := strings.Split(lines[0], "\t")
+	for _, line := range lines[1:] {
+		row := map[string]string{}
+		for i, value := range strings.Split(line, "\t") {
+			if i < len(columns) {
+				row[columns[i]] = value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// client creates a command which runs the client for the engine. The password is
+// passed in an environment variable so that it doesn't appear in logs or errors.
+func (p PreparedConnection) client(ctx context.Context, args ...string) *command.ShellExe {
+	if p.Engine == EngineMySQL {
+		baseArgs := []string{"--protocol=TCP", "-h", p.Host, "-P", p.Port, "-u", p.Credentials.Username, p.DBName}
+		return command.NewShellExe("mysql", append(baseArgs, args...)...).
+			WithEnvValue("MYSQL_PWD", p.Credentials.Password).
+			WithContext(ctx)
+	}
+	baseArgs := []string{"-X", "-q", "-v", "ON_ERROR_STOP=1", "-h", p.Host, "-p", p.Port, "-U", p.Credentials.Username, "-d", p.DBName}
+	return command.NewShellExe("psql", append(baseArgs, args...)...).
+		WithEnvValue("PGPASSWORD", p.Credentials.Password).
+		WithContext(ctx)
+}
+
+// String describes the connection without the credentials.
+func (c Connection) String() string {
+	host := fmt.Sprintf("%s:%s", c.Host, c.Port)
+	if c.KubePort.Forward {
+		host = fmt.Sprintf("%s/%s (port forwarded)", c.KubePort.Namespace, c.KubePort.ServiceName)
+	}
+	return fmt.Sprintf("%s database %q on %s", c.Engine, c.DBName, host)
+}
@@ -0,0 +1,135 @@
+package sqldb
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRows(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want Rows
+	}{
+		{name: "empty", out: "", want: Rows{}},
+		{name: "header only", out: "id\tname\n", want: Rows{}},
+		{
+			name: "rows",
+			out:  "id\tname\n1\tfirst\n2\tNULL\n",
+			want: Rows{
+				{"id": "1", "name": "first"},
+				{"id": "2", "name": "NULL"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRows(tt.out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestConnection(engine string) PreparedConnection {
+	return PreparedConnection{Connection: Connection{
+		Engine:      engine,
+		DBName:      "orders",
+		Host:        "127.0.0.1",
+		Port:        "15432",
+		Credentials: CredentialProvider{Username: "admin", Password: "hunter2"},
+	}}
+}
+
+func TestClientArgs(t *testing.T) {
+	tests := []struct {
+		engine  string
+		args    []string
+		wantExe string
+		want    []string
+		wantEnv string
+	}{
+		{
+			engine:  EnginePostgres,
+			args:    []string{"-c", "select 1"},
+			wantExe: "psql",
+			want:    []string{"-X", "-q", "-v", "ON_ERROR_STOP=1", "-h", "127.0.0.1", "-p", "15432", "-U", "admin", "-d", "orders", "-c", "select 1"},
+			wantEnv: "PGPASSWORD=hunter2",
+		},
+		{
+			engine:  EngineMySQL,
+			args:    []string{"-e", "select 1"},
+			wantExe: "mysql",
+			want:    []string{"--protocol=TCP", "-h", "127.0.0.1", "-P", "15432", "-u", "admin", "orders", "-e", "select 1"},
+			wantEnv: "MYSQL_PWD=hunter2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.engine, func(t *testing.T) {
+			exe := newTestConnection(tt.engine).client(context.Background(), tt.args...)
+			if *exe.Exe != tt.wantExe {
+				t.Errorf("exe = %q, want %q", *exe.Exe, tt.wantExe)
+			}
+			if !reflect.DeepEqual(exe.Args, tt.want) {
+				t.Errorf("args = %q, want %q", exe.Args, tt.want)
+			}
+			if !reflect.DeepEqual(exe.Env, []string{tt.wantEnv}) {
+				t.Errorf("env = %q, want %q", exe.Env, tt.wantEnv)
+			}
+			if strings.Contains(exe.String(), "hunter2") {
+				t.Errorf("expected the password not to be in the command %q", exe.String())
+			}
+		})
+	}
+}
+
+func TestExecFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bosun-sqldb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the fake clients print their args, and mysql also prints what it was given on stdin
+	for name, script := range map[string]string{
+		"psql":  "#!/bin/sh\necho \"$*\"\n",
+		"mysql": "#!/bin/sh\necho \"$*\"\ncat\n",
+	} {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+
+	sqlPath := filepath.Join(dir, "it's a migration.sql")
+	if err = ioutil.WriteFile(sqlPath, []byte("select 1;"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := newTestConnection(EngineMySQL).ExecFile(context.Background(), sqlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "--protocol=TCP -h 127.0.0.1 -P 15432 -u admin orders\nselect 1;"; out != want {
+		t.Errorf("mysql ExecFile() = %q, want %q", out, want)
+	}
+
+	out, err = newTestConnection(EnginePostgres).ExecFile(context.Background(), sqlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out, "-f "+sqlPath) {
+		t.Errorf("expected psql to be given the file with -f, got %q", out)
+	}
+
+	if _, err = newTestConnection(EngineMySQL).ExecFile(context.Background(), filepath.Join(dir, "missing.sql")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,193 @@
+package worker
+
+import (
+	"fmt"
+	"github.com/stevenle/topsort"
+)
+
+// DependencyGraph runs keyed work items concurrently while making sure
+// that an item is only started after all the items it depends on have
+// completed successfully.
+type DependencyGraph struct {
+	order     []string
+	dependsOn map[string][]string
+	after     map[string][]string
+}
+
+// DependencyFailedError is returned for an item which was not run
+// because one of its (possibly transitive) dependencies failed.
+type DependencyFailedError struct {
+	Key        string
+	Dependency string
+}
+
+func (e DependencyFailedError) Error() string {
+	return fmt.Sprintf("%q was not run because its dependency %q failed", e.Key, e.Dependency)
+}
+
+// DependencyCycleError is returned for items which could not be run
+// because they are part of, or depend on, a dependency cycle.
+type DependencyCycleError struct {
+	Key   string
+	Cycle string
+}
+
+func (e DependencyCycleError) Error() string {
+	return fmt.Sprintf("%q was not run because of a dependency cycle: %s", e.Key, e.Cycle)
+}
+
+func NewDependencyGraph() *DependencyGraph {
+	return &DependencyGraph{
+		dependsOn: map[string][]string{},
+		after:     map[string][]string{},
+	}
+}
+
+// Add adds an item to the graph. Dependencies which are never added
+// to the graph are ignored when the graph is run.
+func (g *DependencyGraph) Add(key string, dependsOn ...string) {
+	if _, ok := g.dependsOn[key]; !ok {
+		g.order = append(g.order, key)
+	}
+	g.dependsOn[key] = append(g.dependsOn[key], dependsOn...)
+}
+
+// After adds an item to the graph which is only started after the predecessors
+// have completed, but which is still run if they fail.
+func (g *DependencyGraph) After(key string, predecessors ...string) {
+	g.Add(key)
+	g.after[key] = append(g.after[key], predecessors...)
+}
+
+// Keys returns the keys in the graph in the order they were added.
+func (g *DependencyGraph) Keys() []string {
+	return append([]string{}, g.order...)
+}
+
+// Run calls work for every item in the graph, running at most concurrency
+// items at the same time. When several items are ready to run they are
+// started in the order they were added. If an item fails, the items which
+// depend on it are not run and get a DependencyFailedError instead, but items
+// which were added to run After it are still run.
+// The returned map contains an entry for every item, with a nil value
+// for items which completed successfully.
+func (g *DependencyGraph) Run(concurrency int, work func(key string) error) map[string]error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := g.findCycles()
+
+	type result struct {
+		key string
+		err error
+	}
+	done := make(chan result)
+	started := map[string]bool{}
+	for key := range results {
+		started[key] = true
+	}
+	running := 0
+
+	for len(results) < len(g.order) {
+		// Skipping an item can make items added before it skippable,
+		// so scan until nothing else can be started or skipped.
+		for progress := true; progress && running < concurrency; {
+			progress = false
+			for _, key := range g.order {
+				if started[key] || running >= concurrency {
+					continue
+				}
+				ready, err := g.check(key, results)
+				if err != nil {
+					results[key] = err
+					started[key] = true
+					progress = true
+				} else if ready {
+					started[key] = true
+					running++
+					progress = true
+					go func(key string) {
+						done <- result{key: key, err: work(key)}
+					}(key)
+				}
+			}
+		}
+
+		if running == 0 {
+			break
+		}
+
+		r := <-done
+		running--
+		results[r.key] = r.err
+	}
+
+	return results
+}
+
+// check returns whether key is ready to run, or the error to record
+// for it if one of the items it depends on failed.
+func (g *DependencyGraph) check(key string, results map[string]error) (bool, error) {
+	ready := true
+	for _, dep := range g.dependsOn[key] {
+		if _, ok := g.dependsOn[dep]; !ok || dep == key {
+			continue
+		}
+		err, ok := results[dep]
+		if !ok {
+			ready = false
+			continue
+		}
+		if err != nil {
+			failed := dep
+			if depErr, ok := err.(DependencyFailedError); ok {
+				failed = depErr.Dependency
+			}
+			return false, DependencyFailedError{Key: key, Dependency: failed}
+		}
+	}
+	for _, predecessor := range g.after[key] {
+		if _, ok := results[predecessor]; !ok && predecessor != key {
+			if _, known := g.dependsOn[predecessor]; known {
+				ready = false
+			}
+		}
+	}
+	return ready, nil
+}
+
+// findCycles returns a DependencyCycleError for every item which is part of,
+// or depends on, a cycle.
+func (g *DependencyGraph) findCycles() map[string]error {
+	const root = "__ROOT__"
+
+	graph := topsort.NewGraph()
+	graph.AddNode(root)
+	for _, key := range g.order {
+		graph.AddNode(key)
+		_ = graph.AddEdge(root, key)
+	}
+	addEdges := func(key string, deps []string) {
+		for _, dep := range deps {
+			if _, ok := g.dependsOn[dep]; ok && dep != key {
+				_ = graph.AddEdge(key, dep)
+			}
+		}
+	}
+	for _, key := range g.order {
+		addEdges(key, g.dependsOn[key])
+		addEdges(key, g.after[key])
+	}
+
+	results := map[string]error{}
+	if _, err := graph.TopSort(root); err == nil {
+		return results
+	}
+	for _, key := range g.order {
+		if _, err := graph.TopSort(key); err != nil {
+			results[key] = DependencyCycleError{Key: key, Cycle: err.Error()}
+		}
+	}
+	return results
+}
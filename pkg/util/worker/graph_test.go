@@ -0,0 +1,157 @@
+package worker
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDependencyGraph_RunOrder(t *testing.T) {
+	g := NewDependencyGraph()
+	g.Add("a", "b", "c")
+	g.Add("b")
+	g.Add("c", "b", "external")
+
+	var mu sync.Mutex
+	var order []string
+
+	results := g.Run(1, func(key string) error {
+		mu.Lock()
+		order = append(order, key)
+		mu.Unlock()
+		return nil
+	})
+
+	if want := []string{"b", "c", "a"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	for key, err := range results {
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", key, err)
+		}
+	}
+}
+
+func TestDependencyGraph_RunConcurrently(t *testing.T) {
+	g := NewDependencyGraph()
+	g.Add("a")
+	g.Add("b")
+	g.Add("c", "a", "b")
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	var completed []string
+
+	g.Run(2, func(key string) error {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		completed = append(completed, key)
+		mu.Unlock()
+		return nil
+	})
+
+	if maxInFlight != 2 {
+		t.Errorf("max in flight = %d, want 2", maxInFlight)
+	}
+	if completed[len(completed)-1] != "c" {
+		t.Errorf("c should complete last, got %v", completed)
+	}
+}
+
+func TestDependencyGraph_FailureSkipsOnlyDependents(t *testing.T) {
+	g := NewDependencyGraph()
+	g.Add("a")
+	g.Add("b", "a")
+	g.Add("c", "b")
+	g.Add("d")
+
+	failure := errors.New("boom")
+	var mu sync.Mutex
+	ran := map[string]bool{}
+
+	results := g.Run(4, func(key string) error {
+		mu.Lock()
+		ran[key] = true
+		mu.Unlock()
+		if key == "a" {
+			return failure
+		}
+		return nil
+	})
+
+	if results["a"] != failure {
+		t.Errorf("a: got %v, want %v", results["a"], failure)
+	}
+	for _, key := range []string{"b", "c"} {
+		if ran[key] {
+			t.Errorf("%s should not have run", key)
+		}
+		if err, ok := results[key].(DependencyFailedError); !ok || err.Dependency != "a" {
+			t.Errorf("%s: got %v, want DependencyFailedError on a", key, results[key])
+		}
+	}
+	if !ran["d"] || results["d"] != nil {
+		t.Errorf("d should have run successfully, got %v", results["d"])
+	}
+}
+
+func TestDependencyGraph_AfterRunsEvenIfPredecessorFails(t *testing.T) {
+	g := NewDependencyGraph()
+	g.Add("a")
+	g.Add("b", "a")
+	g.After("c", "a", "b")
+	g.Add("d", "c")
+
+	failure := errors.New("boom")
+	var mu sync.Mutex
+	var order []string
+
+	results := g.Run(4, func(key string) error {
+		mu.Lock()
+		order = append(order, key)
+		mu.Unlock()
+		if key == "a" {
+			return failure
+		}
+		return nil
+	})
+
+	if want := []string{"a", "c", "d"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if _, ok := results["b"].(DependencyFailedError); !ok {
+		t.Errorf("b: got %v, want DependencyFailedError", results["b"])
+	}
+	if results["c"] != nil || results["d"] != nil {
+		t.Errorf("c and d should have run successfully, got %v and %v", results["c"], results["d"])
+	}
+}
+
+func TestDependencyGraph_Cycle(t *testing.T) {
+	g := NewDependencyGraph()
+	g.Add("a", "b")
+	g.Add("b", "a")
+	g.Add("c")
+
+	results := g.Run(1, func(key string) error { return nil })
+
+	if results["c"] != nil {
+		t.Errorf("c: unexpected error %v", results["c"])
+	}
+	for _, key := range []string{"a", "b"} {
+		if _, ok := results[key].(DependencyCycleError); !ok {
+			t.Errorf("%s: got %v, want DependencyCycleError", key, results[key])
+		}
+	}
+}
@@ -62,27 +62,32 @@ func (r *PersistableValues) Report() string {
 
 	applyAttributionComments(&valuesRoot, r.Attribution, "")
 
-	outBytes, _ := yml.Marshal(valuesRoot)
+	outBytes, _ := yml.Marshal(&valuesRoot)
 
 	return string(outBytes)
 }
 
+// applyAttributionComments adds the attribution of each value as a comment on its key.
 func applyAttributionComments(node *yml.Node, attributions Values, path string) {
 
-	attribution, err := attributions.GetAtPath(path)
-	if err != nil {
-		if attrString, ok := attribution.(string); ok {
-			node.HeadComment = attrString
-		} else {
-			node.HeadComment = err.Error()
+	switch node.Kind {
+	case yml.DocumentNode:
+		for _, content := range node.Content {
+			applyAttributionComments(content, attributions, path)
 		}
-	}
-
-	for _, content := range node.Content {
-		switch content.Kind {
-		case yml.MappingNode:
-			applyAttributionComments(content, attributions, path+"."+content.Value)
+	case yml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := key.Value
+			if path != "" {
+				keyPath = path + "." + key.Value
+			}
+			if attribution, err := attributions.GetAtPath(keyPath); err == nil {
+				if attrString, ok := attribution.(string); ok {
+					key.HeadComment = attrString
+				}
+			}
+			applyAttributionComments(value, attributions, keyPath)
 		}
 	}
-
 }
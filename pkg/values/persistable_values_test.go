@@ -42,7 +42,7 @@ var _ = Describe("PersistableValues", func() {
 		})
 	})
 
-	FDescribe("reporting", func() {
+	Describe("reporting", func() {
 		It("should report with comments", func() {
 			sut := PersistableValues{
 				Attribution: Values{
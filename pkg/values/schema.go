@@ -0,0 +1,586 @@
+package values
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is a JSON Schema (draft 7 or compatible) which values can be validated against,
+// such as the values.schema.json file in a helm chart. Schemas can be written as JSON or YAML.
+//
+// The supported keywords are type, enum, const, properties, required, additionalProperties,
+// patternProperties, items, minItems, maxItems, uniqueItems, minLength, maxLength, pattern,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, allOf, anyOf, oneOf, not,
+// and $ref pointing to definitions within the same schema. Other keywords are ignored.
+type Schema struct {
+	Source string
+	root   Values
+}
+
+// SchemaError is a single place where values did not match a schema.
+type SchemaError struct {
+	// Path is the path to the invalid value, like "image.tag" or "env[2].name".
+	Path    string `yaml:"path" json:"path"`
+	Message string `yaml:"message" json:"message"`
+	// Source is where the invalid value was set, from the attribution of the values.
+	Source string `yaml:"source,omitempty" json:"source,omitempty"`
+}
+
+func (s SchemaError) Error() string {
+	path := s.Path
+	if path == "" {
+		path = "(root)"
+	}
+	if s.Source != "" {
+		return fmt.Sprintf("%s: %s (set by %s)", path, s.Message, s.Source)
+	}
+	return fmt.Sprintf("%s: %s", path, s.Message)
+}
+
+type SchemaErrors []SchemaError
+
+func (s SchemaErrors) Headers() []string {
+	return []string{"Path", "Error", "Source"}
+}
+
+func (s SchemaErrors) Rows() [][]string {
+	var out [][]string
+	for _, e := range s {
+		out = append(out, []string{e.Path, e.Message, e.Source})
+	}
+	return out
+}
+
+// ReadSchemaFile reads a JSON Schema from a JSON or YAML file.
+func ReadSchemaFile(filename string) (*Schema, error) {
+	root, err := ReadValuesFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read schema from %q", filename)
+	}
+	return &Schema{Source: filename, root: root}, nil
+}
+
+// NewSchema creates a schema from values which have already been parsed.
+func NewSchema(source string, root Values) *Schema {
+	return &Schema{Source: source, root: root}
+}
+
+// Validate checks the values against the schema. If the values are persistable values
+// with attribution, each error is annotated with the source of the invalid value.
+func (s *Schema) Validate(v *PersistableValues) SchemaErrors {
+	validator := schemaValidator{root: s.root}
+	validator.validate("", s.root, normalizeSchemaValue(v.Values))
+
+	for i, e := range validator.errs {
+		validator.errs[i].Source = v.getAttribution(e.Path)
+	}
+
+	sort.SliceStable(validator.errs, func(i, j int) bool {
+		return validator.errs[i].Path < validator.errs[j].Path
+	})
+
+	return validator.errs
+}
+
+// getAttribution finds the source of the value at the path, or of its closest parent.
+func (r *PersistableValues) getAttribution(path string) string {
+	if r.Attribution == nil {
+		return ""
+	}
+	// attribution does not extend into arrays
+	if i := strings.Index(path, "["); i >= 0 {
+		path = path[:i]
+	}
+	segs := strings.Split(path, ".")
+	for len(segs) > 0 && segs[0] != "" {
+		attribution, err := r.Attribution.getAtPath(segs)
+		if err == nil {
+			if source, ok := attribution.(string); ok {
+				return source
+			}
+		}
+		segs = segs[:len(segs)-1]
+	}
+	return ""
+}
+
+type schemaValidator struct {
+	root Values
+	errs SchemaErrors
+}
+
+func (s *schemaValidator) fail(path string, format string, args ...interface{}) {
+	s.errs = append(s.errs, SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// check validates the value against the schema without recording errors.
+func (s *schemaValidator) check(path string, schema interface{}, value interface{}) SchemaErrors {
+	sub := &schemaValidator{root: s.root}
+	sub.validate(path, schema, value)
+	return sub.errs
+}
+
+func (s *schemaValidator) validate(path string, schemaNode interface{}, value interface{}) {
+
+	if b, ok := schemaNode.(bool); ok {
+		if !b {
+			s.fail(path, "no value is allowed here")
+		}
+		return
+	}
+
+	schema := asValues(normalizeSchemaValue(schemaNode))
+	if schema == nil {
+		return
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := s.resolveRef(ref)
+		if err != nil {
+			s.fail(path, "%s", err)
+			return
+		}
+		s.validate(path, resolved, value)
+		return
+	}
+
+	if typ, ok := schema["type"]; ok {
+		var types []string
+		switch t := typ.(type) {
+		case string:
+			types = []string{t}
+		case []interface{}:
+			for _, x := range t {
+				types = append(types, fmt.Sprint(x))
+			}
+		}
+		matched := false
+		for _, t := range types {
+			if matchesSchemaType(t, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			s.fail(path, "expected %s but got %s", strings.Join(types, " or "), describeSchemaType(value))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range enum {
+			if schemaValuesEqual(option, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			var options []string
+			for _, option := range enum {
+				options = append(options, fmt.Sprintf("%v", option))
+			}
+			s.fail(path, "value %v is not one of [%s]", value, strings.Join(options, ", "))
+		}
+	}
+
+	if c, ok := schema["const"]; ok && !schemaValuesEqual(c, value) {
+		s.fail(path, "value %v is not %v", value, c)
+	}
+
+	switch v := value.(type) {
+	case Values:
+		s.validateObject(path, schema, v)
+	case []interface{}:
+		s.validateArray(path, schema, v)
+	case string:
+		s.validateString(path, schema, v)
+	default:
+		if n, ok := toFloat(value); ok {
+			s.validateNumber(path, schema, n)
+		}
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			s.validate(path, sub, value)
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		var firstErrs SchemaErrors
+		matched := false
+		for i, sub := range anyOf {
+			errs := s.check(path, sub, value)
+			if len(errs) == 0 {
+				matched = true
+				break
+			}
+			if i == 0 {
+				firstErrs = errs
+			}
+		}
+		if !matched {
+			s.fail(path, "value does not match any of the allowed schemas (first mismatch: %s)", summarizeSchemaErrors(firstErrs))
+		}
+	}
+
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			if len(s.check(path, sub, value)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			s.fail(path, "value must match exactly one of the allowed schemas, but matched %d", matches)
+		}
+	}
+
+	if not, ok := schema["not"]; ok {
+		if len(s.check(path, not, value)) == 0 {
+			s.fail(path, "value matches a schema it must not match")
+		}
+	}
+}
+
+func (s *schemaValidator) validateObject(path string, schema Values, v Values) {
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			name := fmt.Sprint(r)
+			if _, found := v[name]; !found {
+				s.fail(joinSchemaPath(path, name), "required value is missing")
+			}
+		}
+	}
+
+	properties := asValues(normalizeSchemaValue(schema["properties"]))
+	patternProperties := asValues(normalizeSchemaValue(schema["patternProperties"]))
+	additionalProperties, hasAdditionalProperties := schema["additionalProperties"]
+
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := joinSchemaPath(path, key)
+		child := v[key]
+		matched := false
+
+		if propertySchema, ok := properties[key]; ok {
+			matched = true
+			s.validate(childPath, propertySchema, child)
+		}
+
+		for pattern, patternSchema := range patternProperties {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				s.fail(childPath, "invalid patternProperties pattern %q in schema: %s", pattern, err)
+				continue
+			}
+			if re.MatchString(key) {
+				matched = true
+				s.validate(childPath, patternSchema, child)
+			}
+		}
+
+		if !matched && hasAdditionalProperties {
+			if allowed, ok := additionalProperties.(bool); ok {
+				if !allowed {
+					s.fail(childPath, "%q is not a known property%s", key, suggestSchemaProperty(key, properties))
+				}
+			} else {
+				s.validate(childPath, additionalProperties, child)
+			}
+		}
+	}
+}
+
+func (s *schemaValidator) validateArray(path string, schema Values, v []interface{}) {
+	if min, ok := toFloat(schema["minItems"]); ok && float64(len(v)) < min {
+		s.fail(path, "must have at least %v items but has %d", min, len(v))
+	}
+	if max, ok := toFloat(schema["maxItems"]); ok && float64(len(v)) > max {
+		s.fail(path, "must have at most %v items but has %d", max, len(v))
+	}
+	if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+		for i := range v {
+			for j := i + 1; j < len(v); j++ {
+				if schemaValuesEqual(v[i], v[j]) {
+					s.fail(path, "items %d and %d are equal but items must be unique", i, j)
+				}
+			}
+		}
+	}
+
+	switch items := schema["items"].(type) {
+	case []interface{}:
+		for i, itemSchema := range items {
+			if i < len(v) {
+				s.validate(fmt.Sprintf("%s[%d]", path, i), itemSchema, v[i])
+			}
+		}
+	case nil:
+	default:
+		for i, item := range v {
+			s.validate(fmt.Sprintf("%s[%d]", path, i), items, item)
+		}
+	}
+}
+
+func (s *schemaValidator) validateString(path string, schema Values, v string) {
+	length := float64(len([]rune(v)))
+	if min, ok := toFloat(schema["minLength"]); ok && length < min {
+		s.fail(path, "must be at least %v characters long", min)
+	}
+	if max, ok := toFloat(schema["maxLength"]); ok && length > max {
+		s.fail(path, "must be at most %v characters long", max)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			s.fail(path, "invalid pattern %q in schema: %s", pattern, err)
+		} else if !re.MatchString(v) {
+			s.fail(path, "value %q does not match pattern %q", v, pattern)
+		}
+	}
+}
+
+func (s *schemaValidator) validateNumber(path string, schema Values, v float64) {
+	if min, ok := toFloat(schema["minimum"]); ok && v < min {
+		s.fail(path, "value %v is less than the minimum %v", v, min)
+	}
+	if max, ok := toFloat(schema["maximum"]); ok && v > max {
+		s.fail(path, "value %v is greater than the maximum %v", v, max)
+	}
+	if min, ok := toFloat(schema["exclusiveMinimum"]); ok && v <= min {
+		s.fail(path, "value %v must be greater than %v", v, min)
+	}
+	if max, ok := toFloat(schema["exclusiveMaximum"]); ok && v >= max {
+		s.fail(path, "value %v must be less than %v", v, max)
+	}
+	if multipleOf, ok := toFloat(schema["multipleOf"]); ok && multipleOf != 0 {
+		quotient := v / multipleOf
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			s.fail(path, "value %v is not a multiple of %v", v, multipleOf)
+		}
+	}
+}
+
+// resolveRef resolves a reference to a location in the same schema, like "#/definitions/image".
+func (s *schemaValidator) resolveRef(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, errors.Errorf("schema reference %q is not supported, only references within the same schema are", ref)
+	}
+	var node interface{} = s.root
+	for _, seg := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		seg = strings.Replace(strings.Replace(seg, "~1", "/", -1), "~0", "~", -1)
+		table := asValues(normalizeSchemaValue(node))
+		if table == nil {
+			return nil, errors.Errorf("schema reference %q could not be resolved", ref)
+		}
+		var ok bool
+		node, ok = table[seg]
+		if !ok {
+			return nil, errors.Errorf("schema reference %q could not be resolved", ref)
+		}
+	}
+	return node, nil
+}
+
+// normalizeSchemaValue converts the various map and slice types produced by
+// the yaml decoders into Values and []interface{}.
+func normalizeSchemaValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case Values:
+		out := Values{}
+		for k, x := range v {
+			out[k] = normalizeSchemaValue(x)
+		}
+		return out
+	case map[string]interface{}:
+		return normalizeSchemaValue(Values(v))
+	case map[interface{}]interface{}:
+		out := Values{}
+		for k, x := range v {
+			out[fmt.Sprint(k)] = normalizeSchemaValue(x)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, x := range v {
+			out[i] = normalizeSchemaValue(x)
+		}
+		return out
+	case []Values:
+		out := make([]interface{}, len(v))
+		for i, x := range v {
+			out[i] = normalizeSchemaValue(x)
+		}
+		return out
+	case []string:
+		out := make([]interface{}, len(v))
+		for i, x := range v {
+			out[i] = x
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+func matchesSchemaType(typ string, value interface{}) bool {
+	switch typ {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "object":
+		_, ok := value.(Values)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		n, ok := toFloat(value)
+		return ok && n == math.Trunc(n)
+	default:
+		return true
+	}
+}
+
+func describeSchemaType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case Values:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		if n, ok := toFloat(value); ok {
+			return fmt.Sprintf("number %v", n)
+		}
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func schemaValuesEqual(a, b interface{}) bool {
+	if an, ok := toFloat(a); ok {
+		bn, ok := toFloat(b)
+		return ok && an == bn
+	}
+	return reflect.DeepEqual(normalizeSchemaValue(a), normalizeSchemaValue(b))
+}
+
+func joinSchemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func summarizeSchemaErrors(errs SchemaErrors) string {
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// suggestSchemaProperty returns a hint if key looks like a typo of one of the properties.
+func suggestSchemaProperty(key string, properties Values) string {
+	best := ""
+	bestDistance := 3
+	for name := range properties {
+		d := levenshtein(strings.ToLower(key), strings.ToLower(name))
+		if d < bestDistance || (d == bestDistance && name < best && best != "") {
+			best, bestDistance = name, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur := make([]int, len(br)+1)
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(cur[j-1]+1, prev[j]+1), prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(br)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
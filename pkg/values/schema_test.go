@@ -0,0 +1,125 @@
+package values_test
+
+import (
+	. "github.com/naveego/bosun/pkg/values"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schema", func() {
+
+	// errs are the paths of the expected errors, in order; empty if the doc is valid.
+	DescribeTable("Validate",
+		func(schema string, doc string, errs []string) {
+			schemaValues, err := ReadValues([]byte(schema))
+			Expect(err).ToNot(HaveOccurred())
+			docValues, err := ReadValues([]byte(doc))
+			Expect(err).ToNot(HaveOccurred())
+
+			var paths []string
+			for _, e := range NewSchema("test", schemaValues).Validate(&PersistableValues{Values: docValues}) {
+				paths = append(paths, e.Path)
+			}
+			Expect(paths).To(Equal(errs))
+		},
+		Entry("type string pass", `{properties: {a: {type: string}}}`, `a: x`, nil),
+		Entry("type string fail", `{properties: {a: {type: string}}}`, `a: 1`, []string{"a"}),
+		Entry("type integer pass", `{properties: {a: {type: integer}}}`, `a: 3`, nil),
+		Entry("type integer fail", `{properties: {a: {type: integer}}}`, `a: 3.5`, []string{"a"}),
+		Entry("type number pass", `{properties: {a: {type: number}}}`, `a: 3.5`, nil),
+		Entry("type boolean fail", `{properties: {a: {type: boolean}}}`, `a: "true"`, []string{"a"}),
+		Entry("type null pass", `{properties: {a: {type: "null"}}}`, `a: null`, nil),
+		Entry("type object fail", `{properties: {a: {type: object}}}`, `a: [1]`, []string{"a"}),
+		Entry("type array fail", `{properties: {a: {type: array}}}`, `a: {b: 1}`, []string{"a"}),
+		Entry("type list pass", `{properties: {a: {type: [string, "null"]}}}`, `a: null`, nil),
+		Entry("type list fail", `{properties: {a: {type: [string, "null"]}}}`, `a: 1`, []string{"a"}),
+
+		Entry("required pass", `{required: [a, b]}`, `{a: 1, b: 2}`, nil),
+		Entry("required fail", `{required: [a, b]}`, `{a: 1}`, []string{"b"}),
+		Entry("nested required fail", `{properties: {image: {required: [tag]}}}`, `image: {repository: x}`, []string{"image.tag"}),
+
+		Entry("enum pass", `{properties: {a: {enum: [x, y, 1]}}}`, `a: 1`, nil),
+		Entry("enum fail", `{properties: {a: {enum: [x, y]}}}`, `a: z`, []string{"a"}),
+		Entry("const pass", `{properties: {a: {const: x}}}`, `a: x`, nil),
+		Entry("const fail", `{properties: {a: {const: x}}}`, `a: y`, []string{"a"}),
+
+		Entry("minimum pass", `{properties: {a: {minimum: 1}}}`, `a: 1`, nil),
+		Entry("minimum fail", `{properties: {a: {minimum: 1}}}`, `a: 0`, []string{"a"}),
+		Entry("maximum pass", `{properties: {a: {maximum: 1}}}`, `a: 1`, nil),
+		Entry("maximum fail", `{properties: {a: {maximum: 1}}}`, `a: 1.5`, []string{"a"}),
+		Entry("exclusiveMinimum fail", `{properties: {a: {exclusiveMinimum: 1}}}`, `a: 1`, []string{"a"}),
+		Entry("exclusiveMaximum fail", `{properties: {a: {exclusiveMaximum: 1}}}`, `a: 1`, []string{"a"}),
+		Entry("multipleOf pass", `{properties: {a: {multipleOf: 0.5}}}`, `a: 1.5`, nil),
+		Entry("multipleOf fail", `{properties: {a: {multipleOf: 2}}}`, `a: 3`, []string{"a"}),
+		Entry("minLength fail", `{properties: {a: {minLength: 2}}}`, `a: x`, []string{"a"}),
+		Entry("maxLength counts characters", `{properties: {a: {maxLength: 2}}}`, `a: é€`, nil),
+		Entry("maxLength fail", `{properties: {a: {maxLength: 2}}}`, `a: xyz`, []string{"a"}),
+
+		Entry("pattern pass", `{properties: {a: {pattern: "^v[0-9]+$"}}}`, `a: v12`, nil),
+		Entry("pattern fail", `{properties: {a: {pattern: "^v[0-9]+$"}}}`, `a: "12"`, []string{"a"}),
+		Entry("invalid pattern", `{properties: {a: {pattern: "("}}}`, `a: x`, []string{"a"}),
+
+		Entry("items pass", `{properties: {a: {items: {type: string}}}}`, `a: [x, y]`, nil),
+		Entry("items fail", `{properties: {a: {items: {type: string}}}}`, `a: [x, 1, y, 2]`, []string{"a[1]", "a[3]"}),
+		Entry("tuple items fail", `{properties: {a: {items: [{type: string}, {type: integer}]}}}`, `a: [x, y, z]`, []string{"a[1]"}),
+		Entry("minItems fail", `{properties: {a: {minItems: 2}}}`, `a: [x]`, []string{"a"}),
+		Entry("maxItems fail", `{properties: {a: {maxItems: 1}}}`, `a: [x, y]`, []string{"a"}),
+		Entry("uniqueItems fail", `{properties: {a: {uniqueItems: true}}}`, `a: [x, y, x]`, []string{"a"}),
+		Entry("items of objects fail", `{properties: {env: {items: {required: [name]}}}}`, `env: [{name: a}, {value: b}]`, []string{"env[1].name"}),
+
+		Entry("additionalProperties false pass", `{properties: {a: {}}, additionalProperties: false}`, `a: 1`, nil),
+		Entry("additionalProperties false fail", `{properties: {a: {}}, additionalProperties: false}`, `{a: 1, b: 2}`, []string{"b"}),
+		Entry("additionalProperties schema fail", `{additionalProperties: {type: string}}`, `{a: x, b: 2}`, []string{"b"}),
+		Entry("patternProperties are not additional", `{patternProperties: {"^x-": {type: string}}, additionalProperties: false}`, `{x-a: y}`, nil),
+		Entry("patternProperties fail", `{patternProperties: {"^x-": {type: string}}}`, `{x-a: 1}`, []string{"x-a"}),
+
+		Entry("ref pass", `{definitions: {tag: {type: string}}, properties: {a: {$ref: "#/definitions/tag"}}}`, `a: x`, nil),
+		Entry("ref fail", `{definitions: {tag: {type: string}}, properties: {a: {$ref: "#/definitions/tag"}}}`, `a: 1`, []string{"a"}),
+		Entry("ref to nested object fail", `{definitions: {image: {required: [tag]}}, properties: {image: {$ref: "#/definitions/image"}}}`, `image: {}`, []string{"image.tag"}),
+		Entry("ref missing", `{properties: {a: {$ref: "#/definitions/missing"}}}`, `a: x`, []string{"a"}),
+		Entry("ref to another document", `{properties: {a: {$ref: "other.json#/tag"}}}`, `a: x`, []string{"a"}),
+
+		Entry("allOf fail", `{properties: {a: {allOf: [{type: string}, {minLength: 2}]}}}`, `a: x`, []string{"a"}),
+		Entry("anyOf pass", `{properties: {a: {anyOf: [{type: string}, {type: integer}]}}}`, `a: 1`, nil),
+		Entry("anyOf fail", `{properties: {a: {anyOf: [{type: string}, {type: integer}]}}}`, `a: true`, []string{"a"}),
+		Entry("oneOf fail when both match", `{properties: {a: {oneOf: [{type: integer}, {minimum: 0}]}}}`, `a: 1`, []string{"a"}),
+		Entry("oneOf pass", `{properties: {a: {oneOf: [{type: integer}, {type: string}]}}}`, `a: 1`, nil),
+		Entry("not fail", `{properties: {a: {not: {type: string}}}}`, `a: x`, []string{"a"}),
+		Entry("false schema fail", `{properties: {a: false}}`, `a: x`, []string{"a"}),
+		Entry("unknown keywords are ignored", `{properties: {a: {format: email, x-custom: 1}}}`, `a: x`, nil),
+
+		Entry("json schema", `{"type": "object", "properties": {"replicas": {"type": "integer", "minimum": 1}}, "required": ["replicas"]}`, `replicas: 0`, []string{"replicas"}),
+	)
+
+	It("attributes errors to the source of the invalid value", func() {
+		schemaValues, err := ReadValues([]byte(`{properties: {image: {properties: {tag: {type: string}, pullPolicy: {enum: [Always]}}}}}`))
+		Expect(err).ToNot(HaveOccurred())
+		v := &PersistableValues{
+			Values: Values{"image": Values{"tag": 1, "pullPolicy": "Never"}},
+			Attribution: Values{"image": Values{
+				"tag":        "blue environment",
+				"pullPolicy": "chart values file",
+			}},
+		}
+
+		errs := NewSchema("test", schemaValues).Validate(v)
+
+		Expect(errs).To(HaveLen(2))
+		Expect(errs[0].Path).To(Equal("image.pullPolicy"))
+		Expect(errs[0].Source).To(Equal("chart values file"))
+		Expect(errs[1].Path).To(Equal("image.tag"))
+		Expect(errs[1].Source).To(Equal("blue environment"))
+		Expect(errs[1].Error()).To(ContainSubstring("(set by blue environment)"))
+	})
+
+	It("suggests the property which was probably meant", func() {
+		schemaValues, err := ReadValues([]byte(`{properties: {replicas: {}}, additionalProperties: false}`))
+		Expect(err).ToNot(HaveOccurred())
+
+		errs := NewSchema("test", schemaValues).Validate(&PersistableValues{Values: Values{"replicsa": 1}})
+
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Message).To(ContainSubstring(`did you mean "replicas"?`))
+	})
+})
@@ -55,10 +55,13 @@ func (v ValueSets) Less(i, j int) bool {
 	li := len(v[i].Roles)
 	lj := len(v[j].Roles)
 	// secondary sort by role names
-	if li == lj && li > 0 {
-		ri := v[i].Roles[0]
-		rj := v[j].Roles[0]
-		return ri < rj
+	if li == lj {
+		for k := range v[i].Roles {
+			if ri, rj := v[i].Roles[k], v[j].Roles[k]; ri != rj {
+				return ri < rj
+			}
+		}
+		return false
 	}
 
 	// sets with more roles go before sets with fewer roles
@@ -173,6 +173,17 @@ func (v ValueSetCollection) ExtractValueSet(args ExtractValueSetArgs) ValueSet {
 
 	out := v.DefaultValues.Clone()
 
+	for _, m := range v.MatchValueSets(args) {
+		out = out.WithValues(m)
+	}
+
+	return out
+}
+
+// MatchValueSets returns the value sets (not including the default values) which
+// ExtractValueSet will merge over the default values, in the order they will be merged.
+func (v ValueSetCollection) MatchValueSets(args ExtractValueSetArgs) ValueSets {
+
 	var matched ValueSets
 
 	if len(args.Roles) == 0 {
@@ -211,11 +222,7 @@ func (v ValueSetCollection) ExtractValueSet(args ExtractValueSetArgs) ValueSet {
 		matched = append(matched, candidate)
 	}
 
-	for _, m := range matched {
-		out = out.WithValues(m)
-	}
-
-	return out
+	return matched
 }
 
 // CanonicalizedCopy returns a copy of this ValueSetCollection with
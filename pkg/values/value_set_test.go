@@ -3,6 +3,7 @@ package values_test
 import (
 	"context"
 	"github.com/naveego/bosun/pkg/cli"
+	"github.com/naveego/bosun/pkg/command"
 	"github.com/naveego/bosun/pkg/core"
 	"github.com/naveego/bosun/pkg/filter"
 	"github.com/naveego/bosun/pkg/templating"
@@ -259,7 +260,7 @@ func (mockExecutionContext) GetEnvironmentVariables() map[string]string {
 }
 
 func (mockExecutionContext) TemplateValues() templating.TemplateValues {
-	panic("implement me")
+	return templating.TemplateValues{Values: map[string]interface{}{}}
 }
 
 func (mockExecutionContext) Log() *logrus.Entry {
@@ -277,3 +278,7 @@ func (mockExecutionContext) Ctx() context.Context {
 func (mockExecutionContext) WithTimeout(timeout time.Duration) core.Ctxer {
 	panic("implement me")
 }
+
+func (mockExecutionContext) GetWorkspaceCommand(name string, hint string) *command.CommandValue {
+	panic("implement me")
+}
@@ -3,6 +3,7 @@ package values
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/naveego/bosun/pkg/util"
 	"github.com/naveego/bosun/pkg/yaml"
 	"github.com/pkg/errors"
 	"io"
@@ -387,6 +388,22 @@ func Distill(left Values, right Values) (common Values, leftResidue Values, righ
 	return
 }
 
+// Hash returns a hash of the values which is stable across a round trip through
+// yaml, so that values read back from a deployed helm release can be compared
+// with the values they were deployed with.
+func (v Values) Hash() (string, error) {
+	y, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	roundTripped := Values{}
+	if err = yaml.Unmarshal(y, &roundTripped); err != nil {
+		return "", err
+	}
+	roundTripped.cleanUp()
+	return util.HashToStringViaYaml(roundTripped)
+}
+
 func (v Values) Clone() Values {
 	if v == nil {
 		return Values{}
@@ -100,4 +100,48 @@ var _ = Describe("Values", func() {
 
 	})
 
+	Describe(".Hash", func() {
+
+		build := func(keys ...string) values.Values {
+			out := values.Values{}
+			for i, key := range keys {
+				out[key] = values.Values{"index": i, "list": []interface{}{key, i}}
+			}
+			return out
+		}
+
+		hash := func(v values.Values) string {
+			h, err := v.Hash()
+			Expect(err).ToNot(HaveOccurred())
+			return h
+		}
+
+		It("should not depend on the order of the keys", func() {
+			keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+			expected := hash(build(keys...))
+
+			for i := 0; i < 20; i++ {
+				Expect(hash(build(keys...))).To(Equal(expected))
+			}
+
+			reversed := values.Values{}
+			for i := len(keys) - 1; i >= 0; i-- {
+				reversed[keys[i]] = values.Values{"list": []interface{}{keys[i], i}, "index": i}
+			}
+			Expect(hash(reversed)).To(Equal(expected))
+		})
+
+		It("should not depend on the type of the nested maps", func() {
+			typed := values.Values{"image": values.Values{"repository": "api", "tag": "1.0.0"}}
+			untyped := values.Values{"image": map[interface{}]interface{}{"tag": "1.0.0", "repository": "api"}}
+			Expect(hash(typed)).To(Equal(hash(untyped)))
+		})
+
+		It("should change when a value changes", func() {
+			original := values.Values{"image": values.Values{"repository": "api", "tag": "1.0.0"}}
+			changed := values.Values{"image": values.Values{"repository": "api", "tag": "1.0.1"}}
+			Expect(hash(original)).ToNot(Equal(hash(changed)))
+		})
+	})
+
 })
@@ -0,0 +1,69 @@
+package vault
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DescribeWrites returns a line for each write Apply would make to vault.
+// Only the keys being written are included, because the values are usually secrets.
+func (v *VaultLayout) DescribeWrites() []string {
+	var out []string
+
+	for _, path := range sortedLayoutKeys(v.Auth) {
+		out = append(out, fmt.Sprintf("enable auth method at sys/auth/%s (unless it is already enabled)%s", path, describeKeys(v.Auth[path])))
+	}
+
+	for _, path := range sortedLayoutKeys(v.Mounts) {
+		out = append(out, fmt.Sprintf("mount secret engine at sys/mounts/%s (unless it is already mounted)%s", path, describeKeys(v.Mounts[path])))
+	}
+
+	for _, rawPath := range sortedLayoutKeys(v.Resources) {
+		path, mode := rawPath, ""
+		if u, err := url.Parse(rawPath); err == nil {
+			path, mode = u.Path, u.Query().Get("mode")
+		}
+		switch mode {
+		case "delete":
+			out = append(out, fmt.Sprintf("delete %s", path))
+		case "insert", "create":
+			out = append(out, fmt.Sprintf("write %s (unless it already exists)%s", path, describeKeys(v.Resources[rawPath])))
+		default:
+			out = append(out, fmt.Sprintf("write %s%s", path, describeKeys(v.Resources[rawPath])))
+		}
+	}
+
+	var policies []string
+	for path := range v.Policies {
+		policies = append(policies, path)
+	}
+	sort.Strings(policies)
+	for _, path := range policies {
+		out = append(out, fmt.Sprintf("put policy %s", path))
+	}
+
+	return out
+}
+
+func sortedLayoutKeys(m map[string]map[string]interface{}) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func describeKeys(data map[string]interface{}) string {
+	if len(data) == 0 {
+		return ""
+	}
+	var keys []string
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return fmt.Sprintf(" with keys %s", strings.Join(keys, ", "))
+}
@@ -408,6 +408,28 @@ func NewVaultLowlevelClient(token, vaultAddr string, logBase *logrus.Entry) (*ap
 	return vaultClient, nil
 }
 
+// ReadUsernamePassword reads the username and password from the secret at path,
+// using the vault configured by VAULT_ADDR and VAULT_TOKEN.
+func ReadUsernamePassword(log *logrus.Entry, path string) (username string, password string, err error) {
+	client, err := NewVaultLowlevelClient(os.Getenv("VAULT_TOKEN"), os.Getenv("VAULT_ADDR"), log)
+	if err != nil {
+		return "", "", err
+	}
+
+	log.Debugf("getting credentials from vault using path '%s'", path)
+	secret, err := client.Logical().Read(path)
+	if err != nil {
+		return "", "", err
+	}
+	if secret == nil {
+		return "", "", errors.Errorf("could not get credentials from vault, try running 'vault read %s' for more information", path)
+	}
+
+	username, _ = secret.Data["username"].(string)
+	password, _ = secret.Data["password"].(string)
+	return username, password, nil
+}
+
 func tryGetTokenUsingEC2Metadata(vaultClient *api.Client) (string, error) {
 	resp, err := http.Get("http://169.254.169.254/latest/dynamic/instance-identity/pkcs7")
 	if err != nil {